@@ -0,0 +1,93 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/hiicl/GPU-over-IP-AC922/pkg/audit"
+	"github.com/spf13/cobra"
+)
+
+// audit 查询参数
+var (
+	auditFile  string
+	auditSince string
+	auditUntil string
+	auditUser  string
+	auditGPU   string
+	auditRPC   string
+	auditJSON  bool
+)
+
+var auditCmd = &cobra.Command{
+	Use:   "audit",
+	Short: "查询 GPU 占用、释放与命令执行的审计日志",
+	Long: `按时间范围、用户或 GPU 查询 grpcserver 写入的审计日志（含已轮转的文件）
+时间参数可以是 RFC3339 时间（2006-01-02T15:04:05+08:00）、日期（2006-01-02），
+或相对当前时间的时长（如 12h 表示 12 小时前）`,
+	Run: func(cmd *cobra.Command, args []string) {
+		filter := audit.Filter{User: auditUser, UUID: auditGPU, RPC: auditRPC}
+		var err error
+		if filter.Since, err = parseAuditTime(auditSince); err != nil {
+			fmt.Println("无效的 --since:", err)
+			os.Exit(1)
+		}
+		if filter.Until, err = parseAuditTime(auditUntil); err != nil {
+			fmt.Println("无效的 --until:", err)
+			os.Exit(1)
+		}
+
+		if auditJSON {
+			enc := json.NewEncoder(os.Stdout)
+			err = audit.Query(auditFile, filter, func(r *audit.Record) { enc.Encode(r) })
+		} else {
+			w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+			fmt.Fprintln(w, "TIME\tUSER\tPEER\tRPC\tGPU\tCODE\tEXIT\tDURATION\tCMD/PATH")
+			err = audit.Query(auditFile, filter, func(r *audit.Record) {
+				exit := "-"
+				if r.ExitCode != nil {
+					exit = fmt.Sprint(*r.ExitCode)
+				}
+				detail := r.Cmd
+				if detail == "" {
+					detail = r.Path
+				}
+				fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
+					r.Time.Local().Format("2006-01-02 15:04:05"), r.User, r.Peer, r.RPC, r.UUID,
+					r.Code, exit, time.Duration(r.DurationMs)*time.Millisecond, detail)
+			})
+			w.Flush()
+		}
+		if err != nil {
+			fmt.Println("读取审计日志失败:", err)
+			os.Exit(1)
+		}
+	},
+}
+
+// parseAuditTime 解析时间参数，空字符串返回零值（不限制）
+func parseAuditTime(s string) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+	if d, err := time.ParseDuration(s); err == nil {
+		return time.Now().Add(-d), nil
+	}
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	return time.ParseInLocation("2006-01-02", s, time.Local)
+}
+
+func init() {
+	auditCmd.Flags().StringVar(&auditFile, "file", "/var/log/aitherion/audit.log", "审计日志文件（与 grpcserver -audit-log 一致）")
+	auditCmd.Flags().StringVar(&auditSince, "since", "", "起始时间（含）")
+	auditCmd.Flags().StringVar(&auditUntil, "until", "", "结束时间（不含）")
+	auditCmd.Flags().StringVar(&auditUser, "user", "", "只显示指定用户的记录")
+	auditCmd.Flags().StringVar(&auditGPU, "gpu", "", "只显示指定 GPU UUID 的记录")
+	auditCmd.Flags().StringVar(&auditRPC, "rpc", "", "只显示指定 RPC 或事件（如 RunCommand、JobExit）")
+	auditCmd.Flags().BoolVar(&auditJSON, "json", false, "以 NDJSON 格式输出原始记录")
+}
@@ -0,0 +1,33 @@
+package utils
+
+import (
+	"os/exec"
+	"path/filepath"
+)
+
+// cudaLibDirs 宿主机上 CUDA 驱动库（libcuda.so）的常见目录，AC922 为 ppc64le
+var cudaLibDirs = []string{
+	"/usr/lib/powerpc64le-linux-gnu",
+	"/usr/lib64",
+	"/usr/lib/x86_64-linux-gnu",
+	"/usr/local/cuda/lib64",
+	"/usr/local/cuda/compat",
+}
+
+// DetectCUDALib 返回包含 libcuda.so 的宿主机目录，未找到时返回空字符串
+func DetectCUDALib() string {
+	for _, dir := range cudaLibDirs {
+		if matches, _ := filepath.Glob(filepath.Join(dir, "libcuda.so*")); len(matches) > 0 {
+			return dir
+		}
+	}
+	return ""
+}
+
+// DetectNvidiaSMI 返回宿主机 nvidia-smi 的路径，未找到时返回空字符串
+func DetectNvidiaSMI() string {
+	if p, err := exec.LookPath("nvidia-smi"); err == nil {
+		return p
+	}
+	return ""
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+    "fmt"
+    "log"
+    "strings"
+
+    pb "github.com/hiicl/GPU-over-IP-AC922/proto"
+)
+
+// details.go 实现 details 子命令：打印GPU的温度、功耗、时钟、ECC、退役页、降频原因和进程
+// 用法：details [uuid]，省略 uuid 时查询第一个GPU
+
+// gpuDetails 查询并打印GPU详细信息
+func gpuDetails(client pb.GPUServiceClient, args []string) {
+    uuid := ""
+    if len(args) > 0 {
+        uuid = args[0]
+        client = gpuClient(client, uuid)
+    } else {
+        uuid = firstGPU(client)
+    }
+    ctx, cancel := rpcContext()
+    defer cancel()
+    d, err := client.GetGPUDetails(ctx, &pb.GPURequest{Uuid: uuid})
+    if err != nil {
+        log.Fatalf("Failed to get GPU details: %v", err)
+    }
+
+    fmt.Printf("%s (%s)  serial %s  VBIOS %s  %s\n", d.ProductName, d.Uuid, d.Serial, d.VbiosVersion, d.PerformanceState)
+    fmt.Printf("  Memory:      %d / %d MiB  Utilization %d%%\n", d.UsedMemory, d.TotalMemory, d.Utilization)
+    fmt.Printf("  Temperature: %d C (memory %d C, slowdown %d C, shutdown %d C)\n",
+        d.Temperature, d.MemoryTemperature, d.SlowdownTemperature, d.ShutdownTemperature)
+    fmt.Printf("  Power:       %.2f / %.2f W\n", d.PowerDraw, d.PowerLimit)
+    fmt.Printf("  Clocks:      graphics %d/%d  sm %d/%d  mem %d/%d MHz\n",
+        d.Clocks.GetGraphics(), d.MaxClocks.GetGraphics(), d.Clocks.GetSm(), d.MaxClocks.GetSm(),
+        d.Clocks.GetMemory(), d.MaxClocks.GetMemory())
+    fmt.Printf("  ECC:         enabled=%v  volatile SBE %d DBE %d  aggregate SBE %d DBE %d\n",
+        d.Ecc.GetEnabled(), d.Ecc.GetVolatileSingleBit(), d.Ecc.GetVolatileDoubleBit(),
+        d.Ecc.GetAggregateSingleBit(), d.Ecc.GetAggregateDoubleBit())
+    fmt.Printf("  Retired:     SBE %d  DBE %d  pending=%v\n",
+        d.RetiredPages.GetSingleBit(), d.RetiredPages.GetDoubleBit(), d.RetiredPages.GetPendingRetirement())
+    if len(d.ThrottleReasons) > 0 {
+        fmt.Printf("  Throttle:    %s\n", strings.Join(d.ThrottleReasons, ", "))
+    }
+    for _, p := range d.Processes {
+        fmt.Printf("  PID %-8d %-2s %6d MiB  %s\n", p.Pid, p.Type, p.UsedMemory, p.Name)
+    }
+}
@@ -0,0 +1,165 @@
+package main
+
+import (
+    "context"
+    "flag"
+    "io"
+    "log"
+    "os"
+    "os/signal"
+    "strings"
+    "syscall"
+
+    "golang.org/x/term"
+
+    pb "github.com/hiicl/GPU-over-IP-AC922/proto"
+)
+
+// exec.go 实现交互式会话子命令：
+//   exec [-i] [-t | -it] [-gpu UUID] [cmd...]
+// -i 转发本地标准输入，-t 分配远端PTY并将本地终端切换为raw模式，未指定命令时启动交互式bash
+
+// execSession 占用GPU后通过 Exec 双向流启动交互式会话，退出码与远端命令一致
+func execSession(client pb.GPUServiceClient, args []string) {
+    fs := flag.NewFlagSet("exec", flag.ExitOnError)
+    interactive := fs.Bool("i", false, "转发标准输入")
+    tty := fs.Bool("t", false, "分配PTY")
+    it := fs.Bool("it", false, "等同于 -i -t")
+    gpuUUID := fs.String("gpu", "", "目标GPU的UUID（默认第一个GPU）")
+    fs.Parse(args)
+    if *it {
+        *interactive, *tty = true, true
+    }
+
+    uuid := *gpuUUID
+    if uuid == "" {
+        uuid = firstGPU(client)
+    } else {
+        client = gpuClient(client, uuid)
+    }
+    leaseID, release := holdLease(client, uuid)
+
+    stream, err := client.Exec(context.Background())
+    if err != nil {
+        release()
+        log.Fatalf("Exec failed: %v", err)
+    }
+
+    start := &pb.ExecStart{
+        Uuid:    uuid,
+        LeaseId: leaseID,
+        Cmd:     strings.Join(fs.Args(), " "),
+        Tty:     *tty,
+        Term:    os.Getenv("TERM"),
+    }
+    stdinFd := int(os.Stdin.Fd())
+    restore := func() {}
+    if *tty && term.IsTerminal(stdinFd) {
+        if cols, rows, err := term.GetSize(stdinFd); err == nil {
+            start.Size = &pb.WindowSize{Rows: uint32(rows), Cols: uint32(cols)}
+        }
+        // 本地终端切换为raw模式，按键原样发送到远端PTY
+        if state, err := term.MakeRaw(stdinFd); err == nil {
+            restore = func() { term.Restore(stdinFd, state) }
+        }
+    }
+
+    // 所有发送都经过同一个协程，gRPC流不支持并发Send
+    // 发送协程在 CloseSend 或发送失败后退出并关闭 sendDone，之后不再向 outgoing 写入
+    outgoing := make(chan *pb.ExecRequest, 16)
+    sendDone := make(chan struct{})
+    outgoing <- &pb.ExecRequest{Msg: &pb.ExecRequest_Start{Start: start}}
+    go func() {
+        defer close(sendDone)
+        for msg := range outgoing {
+            if msg == nil {
+                stream.CloseSend()
+                return
+            }
+            if err := stream.Send(msg); err != nil {
+                return
+            }
+        }
+    }()
+
+    if *interactive {
+        go forwardStdin(outgoing, sendDone)
+    } else {
+        outgoing <- nil // 不转发标准输入，远端立即收到EOF
+    }
+    if *tty {
+        go forwardResize(stdinFd, outgoing, sendDone)
+    }
+
+    code := 0
+    for {
+        msg, err := stream.Recv()
+        if err == io.EOF {
+            break
+        }
+        if err != nil {
+            code = 1
+            log.Printf("Exec stream failed: %v", err)
+            break
+        }
+        if msg.Done {
+            code = int(msg.ExitCode)
+            break
+        }
+        os.Stdout.Write(msg.Data)
+    }
+    restore() // os.Exit 不执行defer，需先恢复终端
+    release()
+    os.Exit(code)
+}
+
+// forwardStdin 读取本地标准输入并发送，读到EOF后结束发送；发送协程已退出时停止
+func forwardStdin(outgoing chan<- *pb.ExecRequest, sendDone <-chan struct{}) {
+    buf := make([]byte, 4096)
+    for {
+        n, err := os.Stdin.Read(buf)
+        if n > 0 {
+            msg := &pb.ExecRequest{Msg: &pb.ExecRequest_Stdin{Stdin: append([]byte(nil), buf[:n]...)}}
+            if !queue(outgoing, sendDone, msg) {
+                return
+            }
+        }
+        if err != nil {
+            queue(outgoing, sendDone, nil) // 通知发送协程 CloseSend
+            return
+        }
+    }
+}
+
+// forwardResize 监听本地终端窗口大小变化（SIGWINCH）并发送到远端
+// 发送协程退出（标准输入结束后已 CloseSend，或流已失败）后远端不再接收窗口大小，停止监听
+func forwardResize(fd int, outgoing chan<- *pb.ExecRequest, sendDone <-chan struct{}) {
+    sig := make(chan os.Signal, 1)
+    signal.Notify(sig, syscall.SIGWINCH)
+    defer signal.Stop(sig)
+    for {
+        select {
+        case <-sig:
+        case <-sendDone:
+            return
+        }
+        cols, rows, err := term.GetSize(fd)
+        if err != nil {
+            continue
+        }
+        msg := &pb.ExecRequest{Msg: &pb.ExecRequest_Resize{Resize: &pb.WindowSize{Rows: uint32(rows), Cols: uint32(cols)}}}
+        if !queue(outgoing, sendDone, msg) {
+            return
+        }
+    }
+}
+
+// queue 将消息交给发送协程，发送协程已退出时返回false
+func queue(outgoing chan<- *pb.ExecRequest, sendDone <-chan struct{}, msg *pb.ExecRequest) bool {
+    select {
+    case outgoing <- msg:
+        return true
+    case <-sendDone:
+        return false
+    }
+}
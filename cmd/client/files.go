@@ -0,0 +1,158 @@
+package main
+
+import (
+    "context"
+    "flag"
+    "fmt"
+    "io"
+    "log"
+    "os"
+
+    pb "github.com/hiicl/GPU-over-IP-AC922/proto"
+
+    "github.com/hiicl/GPU-over-IP-AC922/pkg/files"
+)
+
+// files.go 实现文件传输子命令，远端路径均相对于节点文件沙箱根目录：
+//   upload [-resume] <本地文件> <远端路径>
+//   download [-resume] <远端路径> <本地文件>
+// -resume 从已传输的大小处续传，传输完成后校验完整文件的SHA-256
+
+// uploadChunkSize 上传时单个数据片段的大小
+const uploadChunkSize = 256 * 1024
+
+// uploadFile 上传本地文件到节点文件沙箱
+func uploadFile(client pb.GPUServiceClient, args []string) {
+    fs := flag.NewFlagSet("upload", flag.ExitOnError)
+    resume := fs.Bool("resume", false, "从远端已有大小处续传")
+    fs.Parse(args)
+    if fs.NArg() != 2 {
+        log.Fatal("usage: upload [-resume] <local> <remote>")
+    }
+    local, remote := fs.Arg(0), fs.Arg(1)
+
+    size, sum, err := files.HashFile(local)
+    if err != nil {
+        log.Fatalf("Failed to read %s: %v", local, err)
+    }
+
+    var offset int64
+    if *resume {
+        ctx, cancel := rpcContext()
+        st, err := client.StatFile(ctx, &pb.FileRequest{Path: remote})
+        cancel()
+        if err != nil {
+            log.Fatalf("Failed to stat %s: %v", remote, err)
+        }
+        if st.Ok && st.Size <= size {
+            offset = st.Size
+        }
+    }
+
+    f, err := os.Open(local)
+    if err != nil {
+        log.Fatalf("Failed to open %s: %v", local, err)
+    }
+    defer f.Close()
+    if _, err := f.Seek(offset, io.SeekStart); err != nil {
+        log.Fatalf("Failed to seek %s: %v", local, err)
+    }
+
+    // 文件传输可能耗时较长，不设置超时
+    stream, err := client.UploadFile(context.Background())
+    if err != nil {
+        log.Fatalf("Upload failed: %v", err)
+    }
+    hdr := &pb.FileHeader{Path: remote, Offset: offset, Size: size, Sha256: sum}
+    if err := stream.Send(&pb.FileChunk{Msg: &pb.FileChunk_Header{Header: hdr}}); err != nil {
+        log.Fatalf("Upload failed: %v", err)
+    }
+    buf := make([]byte, uploadChunkSize)
+    for {
+        n, err := f.Read(buf)
+        if n > 0 {
+            if sendErr := stream.Send(&pb.FileChunk{Msg: &pb.FileChunk_Data{Data: buf[:n]}}); sendErr != nil {
+                break // 服务端已结束流，错误在 CloseAndRecv 中返回
+            }
+        }
+        if err == io.EOF {
+            break
+        }
+        if err != nil {
+            log.Fatalf("Failed to read %s: %v", local, err)
+        }
+    }
+    result, err := stream.CloseAndRecv()
+    if err != nil {
+        log.Fatalf("Upload failed: %v", err)
+    }
+    if !result.Ok {
+        log.Fatalf("Upload of %s failed: %s", remote, result.Msg)
+    }
+    fmt.Printf("Uploaded %s -> %s (%d bytes, resumed at %d, sha256 %s)\n", local, remote, result.Size, offset, result.Sha256)
+}
+
+// downloadFile 从节点文件沙箱下载文件，完成后校验SHA-256
+func downloadFile(client pb.GPUServiceClient, args []string) {
+    fs := flag.NewFlagSet("download", flag.ExitOnError)
+    resume := fs.Bool("resume", false, "从本地已有大小处续传")
+    fs.Parse(args)
+    if fs.NArg() != 2 {
+        log.Fatal("usage: download [-resume] <remote> <local>")
+    }
+    remote, local := fs.Arg(0), fs.Arg(1)
+
+    flags := os.O_CREATE | os.O_WRONLY | os.O_TRUNC
+    var offset int64
+    if *resume {
+        if info, err := os.Stat(local); err == nil {
+            offset = info.Size()
+            flags = os.O_CREATE | os.O_WRONLY | os.O_APPEND
+        }
+    }
+
+    stream, err := client.DownloadFile(context.Background(), &pb.FileRequest{Path: remote, Offset: offset})
+    if err != nil {
+        log.Fatalf("Download failed: %v", err)
+    }
+    first, err := stream.Recv()
+    if err != nil {
+        log.Fatalf("Download failed: %v", err)
+    }
+    hdr := first.GetHeader()
+    if hdr == nil {
+        log.Fatal("Download failed: missing header")
+    }
+
+    f, err := os.OpenFile(local, flags, 0644)
+    if err != nil {
+        log.Fatalf("Failed to open %s: %v", local, err)
+    }
+    for {
+        msg, err := stream.Recv()
+        if err == io.EOF {
+            break
+        }
+        if err != nil {
+            f.Close()
+            log.Fatalf("Download failed (rerun with -resume to continue): %v", err)
+        }
+        if _, err := f.Write(msg.GetData()); err != nil {
+            f.Close()
+            log.Fatalf("Failed to write %s: %v", local, err)
+        }
+    }
+    if err := f.Close(); err != nil {
+        log.Fatalf("Failed to write %s: %v", local, err)
+    }
+
+    size, sum, err := files.HashFile(local)
+    if err != nil {
+        log.Fatalf("Failed to verify %s: %v", local, err)
+    }
+    if size != hdr.Size || sum != hdr.Sha256 {
+        log.Fatalf("Download of %s failed verification: got %d bytes sha256 %s, expected %d bytes sha256 %s",
+            remote, size, sum, hdr.Size, hdr.Sha256)
+    }
+    fmt.Printf("Downloaded %s -> %s (%d bytes, resumed at %d, sha256 %s)\n", remote, local, size, offset, sum)
+}
@@ -0,0 +1,153 @@
+package main
+
+import (
+    "context"
+    "flag"
+    "fmt"
+    "log"
+    "os"
+    "strings"
+    "time"
+
+    pb "github.com/hiicl/GPU-over-IP-AC922/proto"
+)
+
+// jobs.go 实现异步作业相关子命令：
+//   submit [-gpu UUID] <cmd>   提交作业（自动占用GPU租约，作业运行期间由服务端续期）
+//   job <id>                   查询作业状态
+//   cancel [-grace 30s] <id>   取消作业
+//   jobs [-state running] [-gpu UUID] [-owner NAME]  列出作业
+
+// jobStateNames 作业状态名称与枚举的映射
+var jobStateNames = map[string]pb.JobState{
+    "running":   pb.JobState_JOB_RUNNING,
+    "succeeded": pb.JobState_JOB_SUCCEEDED,
+    "failed":    pb.JobState_JOB_FAILED,
+    "canceled":  pb.JobState_JOB_CANCELED,
+}
+
+// rpcContext 返回子命令单次RPC使用的5秒超时上下文
+func rpcContext() (context.Context, context.CancelFunc) {
+    return context.WithTimeout(context.Background(), 5*time.Second)
+}
+
+// printJob 打印作业信息
+func printJob(j *pb.Job) {
+    state := strings.ToLower(strings.TrimPrefix(j.State.String(), "JOB_"))
+    fmt.Printf("%s  %-9s  GPU %s  owner %s\n", j.Id, state, j.Uuid, j.Owner)
+    fmt.Printf("    cmd:     %s\n", j.Cmd)
+    fmt.Printf("    started: %s\n", time.UnixMilli(j.StartTime).Format(time.RFC3339))
+    if j.EndTime != 0 {
+        fmt.Printf("    ended:   %s  exit code %d\n", time.UnixMilli(j.EndTime).Format(time.RFC3339), j.ExitCode)
+    }
+    fmt.Printf("    log:     %s\n", j.LogPath)
+}
+
+// submitJob 占用GPU并提交异步作业
+// 未指定 -gpu 时使用服务端返回的第一个GPU
+func submitJob(client pb.GPUServiceClient, args []string) {
+    fs := flag.NewFlagSet("submit", flag.ExitOnError)
+    gpuUUID := fs.String("gpu", "", "目标GPU的UUID（默认第一个GPU）")
+    fs.Parse(args)
+    if fs.NArg() == 0 {
+        log.Fatal("usage: submit [-gpu UUID] <cmd>")
+    }
+
+    uuid := *gpuUUID
+    if uuid == "" {
+        uuid = firstGPU(client)
+    } else {
+        client = gpuClient(client, uuid)
+    }
+
+    ctx, cancel := rpcContext()
+    defer cancel()
+
+    lease, err := client.AcquireGPU(ctx, &pb.GPURequest{Uuid: uuid, Owner: os.Getenv("USER")})
+    if err != nil {
+        log.Fatalf("Failed to acquire GPU: %v", err)
+    }
+    if !lease.Ok {
+        log.Fatalf("Failed to acquire GPU %s: %s", uuid, lease.Msg)
+    }
+
+    job, err := client.SubmitJob(ctx, &pb.JobRequest{
+        Uuid:    uuid,
+        Cmd:     strings.Join(fs.Args(), " "),
+        LeaseId: lease.LeaseId,
+        Owner:   os.Getenv("USER"),
+    })
+    if err != nil {
+        log.Fatalf("Failed to submit job: %v", err)
+    }
+    printJob(job)
+    fmt.Printf("    lease:   %s\n", lease.LeaseId)
+}
+
+// getJob 查询作业状态
+func getJob(client pb.GPUServiceClient, args []string) {
+    if len(args) != 1 {
+        log.Fatal("usage: job <id>")
+    }
+    ctx, cancel := rpcContext()
+    defer cancel()
+    job, err := client.GetJob(ctx, &pb.JobID{Id: args[0]})
+    if err != nil {
+        log.Fatalf("Failed to get job: %v", err)
+    }
+    printJob(job)
+}
+
+// cancelJob 取消作业
+func cancelJob(client pb.GPUServiceClient, args []string) {
+    fs := flag.NewFlagSet("cancel", flag.ExitOnError)
+    grace := fs.Duration("grace", 0, "SIGTERM 后等待的宽限期（默认使用服务端配置）")
+    fs.Parse(args)
+    if fs.NArg() != 1 {
+        log.Fatal("usage: cancel [-grace 30s] <id>")
+    }
+    ctx, cancel := rpcContext()
+    defer cancel()
+    job, err := client.CancelJob(ctx, &pb.CancelJobRequest{Id: fs.Arg(0), GraceSeconds: int32(*grace / time.Second)})
+    if err != nil {
+        log.Fatalf("Failed to cancel job: %v", err)
+    }
+    printJob(job)
+}
+
+// listJobs 列出作业
+func listJobs(client pb.GPUServiceClient, args []string) {
+    fs := flag.NewFlagSet("jobs", flag.ExitOnError)
+    state := fs.String("state", "", "作业状态过滤，逗号分隔（running,succeeded,failed,canceled）")
+    gpuUUID := fs.String("gpu", "", "GPU UUID 过滤")
+    owner := fs.String("owner", "", "提交者过滤")
+    fs.Parse(args)
+
+    filter := &pb.JobFilter{Uuid: *gpuUUID, Owner: *owner}
+    if *state != "" {
+        for _, name := range strings.Split(*state, ",") {
+            st, ok := jobStateNames[strings.TrimSpace(name)]
+            if !ok {
+                log.Fatalf("unknown job state %q", name)
+            }
+            filter.States = append(filter.States, st)
+        }
+    }
+
+    if *gpuUUID != "" {
+        client = gpuClient(client, *gpuUUID)
+    }
+    ctx, cancel := rpcContext()
+    defer cancel()
+    list, err := client.ListJobs(ctx, filter)
+    if err != nil {
+        log.Fatalf("Failed to list jobs: %v", err)
+    }
+    if len(list.Jobs) == 0 {
+        fmt.Println("No jobs found.")
+        return
+    }
+    for _, j := range list.Jobs {
+        printJob(j)
+    }
+}
@@ -1,96 +1,382 @@
-package main
-
-import (
-    "context"
-    "fmt"
-    "log"
-    "os"
-    "time"
-
-    "google.golang.org/grpc"
-    pb "github.com/hiicl/GPU-over-IP-AC922/proto"
-
-    "github.com/hiicl/GPU-over-IP-AC922/modules/netbalance"
-)
-
-// cmd/client/main.go 是gRPC服务的客户端入口文件
-// 实现与GPU管理服务交互的命令行客户端，支持服务发现和负载均衡
-func main() {
-    // 1. 获取服务端地址（通过环境变量或自动发现）
-    serverAddrs := os.Getenv("GRPC_SERVER") // 从环境变量读取服务器地址，支持多个逗号分隔
-
-    // 如果没有指定服务器，且启用了自动负载均衡，则自动发现节点IP列表
-    if serverAddrs == "" && os.Getenv("ENABLE_NETBALANCE") == "true" {
-        serverAddrs = netbalance.GetBestDialTarget("50051") // 调用网络平衡模块获取最佳服务节点
-        if serverAddrs == "" {
-            log.Fatal("自动发现未找到可用节点，请设置 GRPC_SERVER")
-        }
-        log.Printf("[AutoDiscover] 使用自动发现的服务器列表: %s\n", serverAddrs)
-    }
-
-    // 默认回退到本地地址
-    if serverAddrs == "" {
-        serverAddrs = "localhost:50051"
-    }
-
-    // 2. 建立gRPC连接（支持负载均衡）
-    // 使用round_robin负载均衡策略连接多个服务器
-    // dns:/// 前缀启用DNS解析多个地址
-    // WithDefaultServiceConfig 设置负载均衡策略为轮询
-    // WithInsecure 禁用TLS（生产环境应使用TLS）
-    // WithBlock 等待连接建立
-    conn, err := grpc.Dial(
-        fmt.Sprintf("dns:///%s", serverAddrs),
-        grpc.WithDefaultServiceConfig(`{"loadBalancingPolicy":"round_robin"}`),
-        grpc.WithInsecure(),
-        grpc.WithBlock(),
-    )
-    if err != nil {
-        log.Fatalf("Did not connect: %v", err)
-    }
-    defer conn.Close()
-
-    // 3. 调用ListGPUs接口获取GPU列表
-    client := pb.NewGPUServiceClient(conn)
-    ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second) // 设置5秒超时
-    defer cancel()
-
-    listResp, err := client.ListGPUs(ctx, &pb.Void{})
-    if err != nil {
-        log.Fatalf("Failed to list GPUs: %v", err)
-    }
-
-    if len(listResp.Gpus) == 0 {
-        log.Println("No GPUs found.")
-        return
-    }
-
-    // 显示可用GPU列表
-    fmt.Println("Available GPUs:")
-    for _, g := range listResp.Gpus {
-        fmt.Printf("- %s (%s)\n", g.Name, g.Uuid) // 显示GPU名称和UUID
-    }
-
-    // 4. 显示第一个GPU的状态
-    target := listResp.Gpus[0].Uuid // 选择第一个GPU作为目标
-    statResp, err := client.GetGPUStatus(ctx, &pb.GPURequest{Uuid: target})
-    if err != nil {
-        log.Fatalf("Failed to get GPU status: %v", err)
-    }
-
-    // 打印GPU状态信息
-    fmt.Printf("Status for GPU %s:\n", target)
-    fmt.Printf("  Used Memory: %d MiB\n", statResp.UsedMemory)  // 显示已使用内存
-    fmt.Printf("  Utilization: %d%%\n", statResp.Utilization)   // 显示GPU利用率
-
-    // 5. 如果命令行有参数，则将其作为命令在第一个GPU上执行
-    if len(os.Args) > 1 {
-        cmd := os.Args[1] // 获取命令行参数作为要执行的命令
-        runResp, err := client.RunCommand(ctx, &pb.RunRequest{Uuid: target, Cmd: cmd})
-        if err != nil {
-            log.Fatalf("Command run failed: %v", err)
-        }
-        // 打印命令输出和退出码
-        fmt.Printf("Output:\n%s\nExit Code: %d\n", runResp.Output, runResp.ExitCode)
-    }
-}
+package main
+
+import (
+    "context"
+    "flag"
+    "fmt"
+    "io"
+    "log"
+    "net"
+    "os"
+    "strconv"
+    "strings"
+    "time"
+
+    "google.golang.org/grpc"
+    pb "github.com/hiicl/GPU-over-IP-AC922/proto"
+
+    "github.com/hiicl/GPU-over-IP-AC922/modules/netbalance"
+    "github.com/hiicl/GPU-over-IP-AC922/pkg/auth"
+    "github.com/hiicl/GPU-over-IP-AC922/pkg/registry"
+)
+
+// subcommands 子命令表，key 为子命令名称
+var subcommands = map[string]func(pb.GPUServiceClient, []string){
+    "submit":   submitJob,
+    "job":      getJob,
+    "cancel":   cancelJob,
+    "jobs":     listJobs,
+    "exec":     execSession,
+    "upload":   uploadFile,
+    "download": downloadFile,
+    "node":     nodeInfo,
+    "details":  gpuDetails,
+    "ps":       listProcesses,
+    "uncordon": uncordonGPU,
+    "info":     serverInfo,
+}
+
+// cmd/client/main.go 是gRPC服务的客户端入口文件
+// 实现与GPU管理服务交互的命令行客户端，支持服务发现和负载均衡
+func main() {
+    // 命令行参数：-stream 使用流式接口执行命令，实时输出stdout/stderr
+    stream := flag.Bool("stream", false, "使用 RunCommandStream 实时输出命令结果")
+    // -watch 订阅服务端所有GPU的状态推送，直到按 Ctrl+C 退出
+    watch := flag.Bool("watch", false, "持续订阅所有GPU的状态（WatchGPUStatus）")
+    interval := flag.Duration("interval", time.Second, "-watch 的采样间隔")
+    onChange := flag.Bool("onchange", false, "-watch 仅在状态变化时输出")
+    // TLS/mTLS 与令牌认证，需与服务端 -tls-* / -token-file 配置对应
+    var authOpts auth.ClientOptions
+    flag.StringVar(&authOpts.CAFile, "tls-ca", os.Getenv("GRPC_TLS_CA"), "校验服务端证书的CA证书包（指定任一 -tls-* 参数即启用TLS）")
+    flag.StringVar(&authOpts.CertFile, "tls-cert", os.Getenv("GRPC_TLS_CERT"), "客户端证书（服务端启用mTLS时必须）")
+    flag.StringVar(&authOpts.KeyFile, "tls-key", os.Getenv("GRPC_TLS_KEY"), "客户端私钥")
+    flag.StringVar(&authOpts.ServerName, "tls-server-name", "", "覆盖证书校验使用的服务端主机名")
+    flag.StringVar(&authOpts.TokenFile, "token-file", os.Getenv("GRPC_TOKEN_FILE"), "Bearer令牌文件（也可通过 GRPC_TOKEN 环境变量直接指定令牌）")
+    controller := flag.String("controller", os.Getenv("CONTROLLER"), "控制器地址 host:port，启用自动发现时从控制器获取在线节点（与服务端使用相同的TLS和令牌配置）")
+    discover := flag.String("discover", os.Getenv("DISCOVER"), "未指定控制器时，监听该组播或广播地址上的节点通告（如 "+netbalance.DefaultAnnounceAddr+"）")
+    discoverWait := flag.Duration("discover-wait", 6*time.Second, "监听节点通告的时长，需大于服务端的 -heartbeat")
+    flag.Parse()
+    authOpts.Token = os.Getenv("GRPC_TOKEN")
+
+    // 1. 获取服务端地址（通过环境变量或自动发现）
+    var targets []string
+    if env := os.Getenv("GRPC_SERVER"); env != "" { // 从环境变量读取服务器地址，支持多个逗号分隔
+        for _, addr := range strings.Split(env, ",") {
+            if addr = strings.TrimSpace(addr); addr != "" {
+                targets = append(targets, addr)
+            }
+        }
+    }
+
+    // 如果没有指定服务器，且启用了自动负载均衡，则自动发现节点IP列表
+    if len(targets) == 0 && os.Getenv("ENABLE_NETBALANCE") == "true" {
+        if err := discoverNodes(*controller, *discover, *discoverWait, authOpts); err != nil {
+            log.Fatalf("自动发现失败: %v", err)
+        }
+        targets = netbalance.DialTargets("50051") // 按带宽排序的节点及其各NUMA分组端口（节点未注册端口时使用50051）
+        if len(targets) == 0 {
+            log.Fatal("自动发现未找到可用节点，请设置 GRPC_SERVER")
+        }
+        log.Printf("[AutoDiscover] 使用自动发现的服务器列表: %s\n", strings.Join(targets, ","))
+    }
+
+    // 默认回退到本地地址
+    if len(targets) == 0 {
+        targets = []string{"localhost:50051"}
+    }
+
+    // 2. 建立gRPC连接：按顺序逐个尝试，连接第一个可用的地址
+    // 每个端口只服务本NUMA分组绑定的GPU，操作指定GPU的命令通过 gpuClient 改连该GPU所在的端口
+    // authOpts 提供传输层凭据（TLS或明文）和每次调用附带的令牌
+    var err error
+    if dialOpts, err = authOpts.DialOptions(); err != nil {
+        log.Fatalf("Invalid auth options: %v", err)
+    }
+    conn, addr, err := dialFirst(targets)
+    if err != nil {
+        log.Fatalf("Did not connect: %v", err)
+    }
+    defer conn.Close()
+    serverAddr = addr
+
+    client := pb.NewGPUServiceClient(conn)
+
+    // 子命令（如 submit / jobs）由对应处理函数执行
+    if flag.NArg() > 0 {
+        if sub, ok := subcommands[flag.Arg(0)]; ok {
+            sub(client, flag.Args()[1:])
+            return
+        }
+    }
+
+    // 3. 调用ListGPUs接口获取GPU列表
+    ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second) // 设置5秒超时
+    defer cancel()
+
+    listResp, err := client.ListGPUs(ctx, &pb.Void{})
+    if err != nil {
+        log.Fatalf("Failed to list GPUs: %v", err)
+    }
+
+    if len(listResp.Gpus) == 0 {
+        log.Println("No GPUs found.")
+        return
+    }
+
+    // 显示可用GPU列表
+    fmt.Println("Available GPUs:")
+    for _, g := range listResp.Gpus {
+        fmt.Printf("- [%d] %s (%s)\n", g.Index, g.Name, g.Uuid) // 显示GPU序号、名称和UUID
+        fmt.Printf("    PCI %s  NUMA %d  %d MiB  CC %s  Driver %s  CUDA %s  Mode %s\n",
+            g.PciBusId, g.NumaNode, g.TotalMemory, g.ComputeCapability,
+            g.DriverVersion, g.CudaVersion, g.ComputeMode)
+        if g.MigEnabled {
+            fmt.Printf("    MIG enabled: use the MIG instances below\n")
+        }
+        if g.Mig != nil {
+            fmt.Printf("    MIG %s on %s (GI %d, CI %d)\n", g.Mig.Profile, g.Mig.ParentUuid, g.Mig.GpuInstanceId, g.Mig.ComputeInstanceId)
+        }
+        if g.Unhealthy {
+            fmt.Printf("    UNHEALTHY: %s\n", g.HealthReason)
+        }
+    }
+
+    if *watch {
+        watchStatus(client, *interval, *onChange)
+        return
+    }
+
+    // 4. 显示第一个可运行程序的健康GPU的状态（全部被隔离时仍选择第一个GPU）
+    // GPU列表只包含所连接端口绑定的GPU，无需改连
+    target := listResp.Gpus[0].Uuid
+    for _, g := range listResp.Gpus {
+        if !g.Unhealthy && !g.MigEnabled {
+            target = g.Uuid
+            break
+        }
+    }
+    statResp, err := client.GetGPUStatus(ctx, &pb.GPURequest{Uuid: target})
+    if err != nil {
+        log.Fatalf("Failed to get GPU status: %v", err)
+    }
+
+    // 打印GPU状态信息
+    fmt.Printf("Status for GPU %s:\n", target)
+    fmt.Printf("  Used Memory: %d MiB\n", statResp.UsedMemory)  // 显示已使用内存
+    fmt.Printf("  Utilization: %d%%\n", statResp.Utilization)   // 显示GPU利用率
+    if statResp.Stale {
+        fmt.Printf("  (stale: sampled %d ms ago)\n", statResp.AgeMs) // 服务端遥测数据过旧
+    }
+
+    // 5. 如果命令行有参数，则将其作为命令在第一个GPU上执行
+    // 执行命令前先占用GPU获得租约，执行结束后释放
+    if flag.NArg() > 0 {
+        cmd := flag.Arg(0) // 获取命令行参数作为要执行的命令
+        leaseID, release := holdLease(client, target)
+        if *stream {
+            code := runStream(client, target, leaseID, cmd)
+            release()
+            os.Exit(code)
+        }
+        runResp, err := client.RunCommand(ctx, &pb.RunRequest{Uuid: target, Cmd: cmd, LeaseId: leaseID})
+        release()
+        if err != nil {
+            log.Fatalf("Command run failed: %v", err)
+        }
+        // 打印命令输出和退出码
+        fmt.Printf("Output:\n%s\nExit Code: %d\n", runResp.Output, runResp.ExitCode)
+    }
+}
+
+// dialTimeout 连接单个服务端地址的超时时间
+const dialTimeout = 5 * time.Second
+
+var (
+    dialOpts   []grpc.DialOption // 连接服务端的拨号参数（传输层凭据和令牌）
+    serverAddr string            // 当前连接的服务端地址
+)
+
+// dialFirst 按顺序连接 targets 中的地址，返回第一个连接成功的连接及其地址
+func dialFirst(targets []string) (*grpc.ClientConn, string, error) {
+    var lastErr error
+    for _, addr := range targets {
+        ctx, cancel := context.WithTimeout(context.Background(), dialTimeout)
+        conn, err := grpc.DialContext(ctx, addr, append(dialOpts, grpc.WithBlock())...)
+        cancel()
+        if err == nil {
+            return conn, addr, nil
+        }
+        log.Printf("[Dial] %s: %v", addr, err)
+        lastErr = err
+    }
+    return nil, "", fmt.Errorf("no server reachable (tried %s): %v", strings.Join(targets, ","), lastErr)
+}
+
+// gpuClient 返回服务指定GPU的客户端
+// 每个端口只服务本NUMA分组绑定的GPU：先按所连接节点 GetNodeInfo 返回的分组查找GPU所在端口，
+// 不在该节点时按注册表中各节点的分组查找；GPU就在当前端口或无法确定时沿用 client
+func gpuClient(client pb.GPUServiceClient, uuid string) pb.GPUServiceClient {
+    target := ""
+    ctx, cancel := rpcContext()
+    info, err := client.GetNodeInfo(ctx, &pb.Void{})
+    cancel()
+    if err == nil {
+        host, _, _ := net.SplitHostPort(serverAddr)
+        for _, g := range info.Groups {
+            for _, gpu := range g.Gpus {
+                if gpu == uuid {
+                    target = net.JoinHostPort(host, strconv.Itoa(int(g.Port)))
+                }
+            }
+        }
+    }
+    if target == "" {
+        target = netbalance.GPUTarget(uuid)
+    }
+    if target == "" || target == serverAddr {
+        return client
+    }
+    conn, addr, err := dialFirst([]string{target})
+    if err != nil {
+        log.Fatalf("Did not connect to GPU %s: %v", uuid, err)
+    }
+    // 连接在进程退出时关闭
+    serverAddr = addr
+    return pb.NewGPUServiceClient(conn)
+}
+
+// discoverNodes 将在线节点写入本地注册表：优先从控制器获取，否则监听局域网节点通告
+func discoverNodes(controller, discover string, wait time.Duration, opts auth.ClientOptions) error {
+    switch {
+    case controller != "":
+        rc, err := registry.NewClient(controller, opts)
+        if err != nil {
+            return err
+        }
+        ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+        defer cancel()
+        _, err = rc.Sync(ctx)
+        return err
+    case discover != "":
+        ctx, cancel := context.WithTimeout(context.Background(), wait)
+        defer cancel()
+        return netbalance.Listen(ctx, discover, nil, netbalance.NodeTimeout)
+    }
+    return fmt.Errorf("需要通过 -controller 或 -discover 指定控制器或节点通告地址")
+}
+
+// firstGPU 返回服务端GPU列表中的第一个GPU的UUID，已启用MIG的物理GPU由其第一个MIG实例代替
+func firstGPU(client pb.GPUServiceClient) string {
+    ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+    defer cancel()
+    list, err := client.ListGPUs(ctx, &pb.Void{})
+    if err != nil {
+        log.Fatalf("Failed to list GPUs: %v", err)
+    }
+    if len(list.Gpus) == 0 {
+        log.Fatal("No GPUs found.")
+    }
+    for _, g := range list.Gpus {
+        if !g.MigEnabled {
+            return g.Uuid
+        }
+    }
+    return list.Gpus[0].Uuid
+}
+
+// holdLease 占用指定GPU并在后台定期续期租约
+// 返回租约ID和释放函数；释放函数停止续期并归还GPU
+func holdLease(client pb.GPUServiceClient, uuid string) (string, func()) {
+    ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+    defer cancel()
+    lease, err := client.AcquireGPU(ctx, &pb.GPURequest{Uuid: uuid, Owner: os.Getenv("USER")})
+    if err != nil {
+        log.Fatalf("Failed to acquire GPU: %v", err)
+    }
+    if !lease.Ok {
+        log.Fatalf("Failed to acquire GPU %s: %s", uuid, lease.Msg)
+    }
+
+    // 在租约剩余时长的三分之一处续期
+    renewEvery := time.Until(time.UnixMilli(lease.ExpiresAt)) / 3
+    if renewEvery < time.Second {
+        renewEvery = time.Second
+    }
+    done := make(chan struct{})
+    go func() {
+        ticker := time.NewTicker(renewEvery)
+        defer ticker.Stop()
+        for {
+            select {
+            case <-done:
+                return
+            case <-ticker.C:
+                rctx, rcancel := context.WithTimeout(context.Background(), 5*time.Second)
+                r, err := client.RenewLease(rctx, &pb.LeaseRequest{LeaseId: lease.LeaseId})
+                rcancel()
+                if err != nil || !r.Ok {
+                    log.Printf("[Lease] renew failed: %v %s", err, r.GetMsg())
+                }
+            }
+        }
+    }()
+
+    release := func() {
+        close(done)
+        rctx, rcancel := context.WithTimeout(context.Background(), 5*time.Second)
+        defer rcancel()
+        if _, err := client.ReleaseGPU(rctx, &pb.GPURequest{Uuid: uuid, LeaseId: lease.LeaseId}); err != nil {
+            log.Printf("[Lease] release failed: %v", err)
+        }
+    }
+    return lease.LeaseId, release
+}
+
+// runStream 通过 RunCommandStream 执行命令，并把输出实时写到本地stdout/stderr
+// 流式执行不设置超时，长时间运行的训练任务会持续输出直到结束
+// 返回远端命令的退出码
+func runStream(client pb.GPUServiceClient, uuid, leaseID, cmd string) int {
+    stream, err := client.RunCommandStream(context.Background(), &pb.RunRequest{Uuid: uuid, Cmd: cmd, LeaseId: leaseID})
+    if err != nil {
+        log.Fatalf("Command stream failed: %v", err)
+    }
+    for {
+        msg, err := stream.Recv()
+        if err == io.EOF {
+            log.Fatal("Command stream closed without exit code")
+        }
+        if err != nil {
+            log.Fatalf("Command stream failed: %v", err)
+        }
+        if msg.Done {
+            return int(msg.ExitCode)
+        }
+        if msg.Stream == pb.OutputStream_STDERR {
+            os.Stderr.Write(msg.Data)
+        } else {
+            os.Stdout.Write(msg.Data)
+        }
+    }
+}
+
+// watchStatus 订阅 WatchGPUStatus 并逐行打印每次推送的GPU状态
+func watchStatus(client pb.GPUServiceClient, interval time.Duration, onChange bool) {
+    stream, err := client.WatchGPUStatus(context.Background(), &pb.GPURequest{
+        IntervalMs: int32(interval / time.Millisecond),
+        OnChange:   onChange,
+    })
+    if err != nil {
+        log.Fatalf("Failed to watch GPU status: %v", err)
+    }
+    for {
+        st, err := stream.Recv()
+        if err == io.EOF {
+            return
+        }
+        if err != nil {
+            log.Fatalf("Watch stream failed: %v", err)
+        }
+        ts := time.UnixMilli(st.Timestamp).Format("15:04:05")
+        fmt.Printf("[%s] %s  Used Memory: %d MiB  Utilization: %d%%\n", ts, st.Uuid, st.UsedMemory, st.Utilization)
+    }
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+    "fmt"
+    "log"
+
+    pb "github.com/hiicl/GPU-over-IP-AC922/proto"
+)
+
+// node.go 实现 node / info 子命令：打印节点清单（NUMA分组、端口、网卡、memext）和服务端版本信息
+
+// nodeInfo 查询并打印节点清单
+func nodeInfo(client pb.GPUServiceClient, args []string) {
+    ctx, cancel := rpcContext()
+    defer cancel()
+    info, err := client.GetNodeInfo(ctx, &pb.Void{})
+    if err != nil {
+        log.Fatalf("Failed to get node info: %v", err)
+    }
+
+    fmt.Printf("Node %s (%s), connected to NUMA %d\n", info.Hostname, info.Arch, info.ServingNumaNode)
+    if info.MemextPoolSize > 0 {
+        fmt.Printf("memext pool: %.2f GB, bound to NUMA %d\n", float64(info.MemextPoolSize)/1e9, info.MemextNumaNode)
+    } else {
+        fmt.Println("memext pool: disabled")
+    }
+    for _, g := range info.Groups {
+        fmt.Printf("NUMA %d  port %d  memory %.2f GB\n", g.NumaNode, g.Port, float64(g.MemTotal)/1e9)
+        fmt.Printf("    GPUs: %v\n", g.Gpus)
+        fmt.Printf("    NICs: %v\n", g.Nics)
+    }
+}
+
+// serverInfo 查询并打印服务端版本和支持的功能
+func serverInfo(client pb.GPUServiceClient, args []string) {
+    ctx, cancel := rpcContext()
+    defer cancel()
+    info, err := client.GetServerInfo(ctx, &pb.Void{})
+    if err != nil {
+        log.Fatalf("Failed to get server info: %v", err)
+    }
+    fmt.Printf("Server version %s, API %s\n", info.Version, info.ApiVersion)
+    fmt.Printf("Features: %v\n", info.Features)
+}
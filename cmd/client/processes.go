@@ -0,0 +1,46 @@
+package main
+
+import (
+    "fmt"
+    "log"
+
+    pb "github.com/hiicl/GPU-over-IP-AC922/proto"
+)
+
+// processes.go 实现 ps 子命令：列出GPU上的计算进程及其所属作业、来源和持有者，标记失控进程
+// 用法：ps [uuid]，省略 uuid 时列出所连接NUMA组的全部GPU
+
+// listProcesses 查询并打印GPU进程
+func listProcesses(client pb.GPUServiceClient, args []string) {
+    req := &pb.GPURequest{}
+    if len(args) > 0 {
+        req.Uuid = args[0]
+        client = gpuClient(client, req.Uuid)
+    }
+    ctx, cancel := rpcContext()
+    defer cancel()
+    resp, err := client.ListGPUProcesses(ctx, req)
+    if err != nil {
+        log.Fatalf("Failed to list GPU processes: %v", err)
+    }
+    if len(resp.Processes) == 0 {
+        fmt.Println("No GPU processes.")
+        return
+    }
+    fmt.Printf("%-42s %-8s %8s  %-5s %-16s %-12s %s\n", "GPU", "PID", "MEMORY", "SRC", "JOB", "OWNER", "COMMAND")
+    for _, p := range resp.Processes {
+        fmt.Printf("%-42s %-8d %4d MiB  %-5s %-16s %-12s %s\n",
+            p.Uuid, p.Pid, p.UsedMemory, orDash(p.Source), orDash(p.JobId), orDash(p.Owner), p.Name)
+        if p.Rogue {
+            fmt.Printf("    ROGUE: %s\n", p.RogueReason)
+        }
+    }
+}
+
+// orDash 空字符串显示为 "-"
+func orDash(s string) string {
+    if s == "" {
+        return "-"
+    }
+    return s
+}
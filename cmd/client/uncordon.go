@@ -0,0 +1,29 @@
+package main
+
+import (
+    "fmt"
+    "log"
+
+    pb "github.com/hiicl/GPU-over-IP-AC922/proto"
+)
+
+// uncordon.go 实现 uncordon 子命令：解除健康检查对GPU的隔离（需要 admin 权限）
+// 用法：uncordon <uuid>
+
+// uncordonGPU 解除GPU隔离
+func uncordonGPU(client pb.GPUServiceClient, args []string) {
+    if len(args) != 1 {
+        log.Fatal("usage: uncordon <uuid>")
+    }
+    client = gpuClient(client, args[0])
+    ctx, cancel := rpcContext()
+    defer cancel()
+    resp, err := client.UncordonGPU(ctx, &pb.GPURequest{Uuid: args[0]})
+    if err != nil {
+        log.Fatalf("Failed to uncordon GPU: %v", err)
+    }
+    if !resp.Ok {
+        log.Fatalf("Failed to uncordon GPU %s: %s", args[0], resp.Msg)
+    }
+    fmt.Printf("GPU %s uncordoned\n", args[0])
+}
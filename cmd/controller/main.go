@@ -0,0 +1,61 @@
+package main
+
+import (
+    "flag"
+    "log"
+    "net/http"
+    "time"
+
+    "github.com/hiicl/GPU-over-IP-AC922/modules/netbalance"
+    "github.com/hiicl/GPU-over-IP-AC922/pkg/auth"
+    "github.com/hiicl/GPU-over-IP-AC922/pkg/registry"
+)
+
+// controller 集群控制器：各节点的 grpcserver 启动时注册并定期发送心跳，
+// 控制器移除超时未心跳的节点，并向客户端提供在线节点列表
+
+// 命令行参数
+var (
+    listenAddr  = flag.String("listen", ":7070", "注册服务监听地址")
+    nodeTimeout = flag.Duration("node-timeout", netbalance.NodeTimeout, "节点超过该时间未发送心跳即被移除")
+
+    tlsCert   = flag.String("tls-cert", "", "服务端TLS证书（为空时不启用TLS）")
+    tlsKey    = flag.String("tls-key", "", "服务端TLS私钥")
+    tlsCA     = flag.String("tls-ca", "", "客户端CA证书包，指定后要求客户端证书（mTLS）")
+    tokenFile = flag.String("token-file", "", "Bearer令牌文件，每行 \"<token> <user>\"（为空时不校验令牌）")
+)
+
+func main() {
+    flag.Parse()
+
+    var tokens *auth.TokenStore
+    if *tokenFile != "" {
+        var err error
+        if tokens, err = auth.NewTokenStore(*tokenFile); err != nil {
+            log.Fatalf("[Fatal] Failed to load tokens: %v", err)
+        }
+        log.Printf("[Auth] bearer tokens loaded from %s", *tokenFile)
+    }
+    srv := registry.NewServer(tokens, *nodeTimeout)
+    go srv.Expire(time.Second)
+
+    hs := &http.Server{Addr: *listenAddr, Handler: srv}
+    if *tlsCert != "" {
+        cfg, err := auth.ServerTLSConfig(*tlsCert, *tlsKey, *tlsCA)
+        if err != nil {
+            log.Fatalf("[Fatal] Failed to load TLS config: %v", err)
+        }
+        hs.TLSConfig = cfg
+    } else if *tlsCA != "" {
+        log.Fatalf("[Fatal] -tls-ca requires -tls-cert and -tls-key")
+    }
+
+    log.Printf("[OK] controller ready on %s (TLS: %v, node timeout %s)", *listenAddr, hs.TLSConfig != nil, *nodeTimeout)
+    var err error
+    if hs.TLSConfig != nil {
+        err = hs.ListenAndServeTLS("", "")
+    } else {
+        err = hs.ListenAndServe()
+    }
+    log.Fatalf("[Fatal] Failed to serve: %v", err)
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+    "context"
+    "strings"
+    "time"
+
+    pb "github.com/hiicl/GPU-over-IP-AC922/proto"
+
+    "github.com/hiicl/GPU-over-IP-AC922/pkg/audit"
+    "github.com/hiicl/GPU-over-IP-AC922/pkg/query"
+    "github.com/hiicl/GPU-over-IP-AC922/pkg/scheduler"
+)
+
+// acquire.go 实现 AcquireGPUs 多GPU原子占用
+
+// AcquireGPUs 按约束从本NUMA组绑定的GPU中原子地占用多个GPU
+// 型号和空闲内存约束在此过滤，空闲状态与同NUMA约束由调度器在同一次加锁中判断
+func (s *server) AcquireGPUs(ctx context.Context, req *pb.MultiGPURequest) (*pb.LeaseList, error) {
+    owner := callerName(ctx, req.Owner)
+
+    leases, err := s.sched.AcquireN(s.multiCandidates(req), scheduler.Request{
+        Count:    int(req.Count),
+        Owner:    owner,
+        Tenant:   callerTenant(ctx),
+        TTL:      time.Duration(req.TtlSeconds) * time.Second,
+        SameNUMA: req.SameNuma,
+    })
+    if err != nil {
+        if qerr := quotaError(err); qerr != nil {
+            return nil, qerr
+        }
+        return &pb.LeaseList{Ok: false, Msg: err.Error()}, nil
+    }
+
+    reply := &pb.LeaseList{Ok: true, Msg: "acquired"}
+    uuids := make([]string, 0, len(leases))
+    for _, l := range leases {
+        uuids = append(uuids, l.UUID)
+        reply.Leases = append(reply.Leases, leaseReply(l, "acquired"))
+    }
+    audit.FromContext(ctx).SetGPU(strings.Join(uuids, ","), "")
+    return reply, nil
+}
+
+// multiCandidates 返回满足型号和最小空闲内存约束的绑定GPU
+func (s *server) multiCandidates(req *pb.MultiGPURequest) []string {
+    var status map[string]query.GPUStatus
+    if req.MinFreeMemory > 0 {
+        status = query.ListGPUStatus()
+    }
+    model := strings.ToLower(req.Model)
+
+    var candidates []string
+    for _, g := range query.ListGPUs() {
+        if !s.boundGPUs[g.Uuid] {
+            continue
+        }
+        if model != "" && !strings.Contains(strings.ToLower(g.Name), model) {
+            continue
+        }
+        if req.MinFreeMemory > 0 {
+            st, ok := status[g.Uuid]
+            if !ok || g.TotalMemory-st.UsedMemory < req.MinFreeMemory {
+                continue
+            }
+        }
+        candidates = append(candidates, g.Uuid)
+    }
+    return candidates
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+    pb "github.com/hiicl/GPU-over-IP-AC922/proto"
+
+    "github.com/hiicl/GPU-over-IP-AC922/pkg/audit"
+    "github.com/hiicl/GPU-over-IP-AC922/pkg/jobs"
+)
+
+// audit.go 定义需要审计的RPC，以及从请求消息中提取审计字段的方法
+
+// auditedMethods 记录审计日志的方法：占用/释放GPU、解除隔离、执行命令、作业和文件传输
+var auditedMethods = map[string]bool{
+    "/" + gpuServiceName + "/AcquireGPU":       true,
+    "/" + gpuServiceName + "/AcquireGPUs":      true,
+    "/" + gpuServiceName + "/RenewLease":       true,
+    "/" + gpuServiceName + "/ReleaseGPU":       true,
+    "/" + gpuServiceName + "/UncordonGPU":      true,
+    "/" + gpuServiceName + "/RunCommand":       true,
+    "/" + gpuServiceName + "/RunCommandStream": true,
+    "/" + gpuServiceName + "/Exec":             true,
+    "/" + gpuServiceName + "/SubmitJob":        true,
+    "/" + gpuServiceName + "/CancelJob":        true,
+    "/" + gpuServiceName + "/UploadFile":       true,
+    "/" + gpuServiceName + "/DownloadFile":     true,
+}
+
+// describeRequest 从请求消息中提取GPU、租约、命令行、文件路径和作业ID
+func describeRequest(r *audit.Record, msg interface{}) {
+    switch m := msg.(type) {
+    case *pb.GPURequest:
+        r.SetGPU(m.Uuid, m.LeaseId)
+    case *pb.LeaseRequest:
+        r.SetGPU("", m.LeaseId)
+    case *pb.RunRequest:
+        r.SetGPU(m.Uuid, m.LeaseId)
+        r.SetCommand(m.Cmd)
+    case *pb.ExecRequest:
+        if start := m.GetStart(); start != nil {
+            r.SetGPU(start.Uuid, start.LeaseId)
+            r.SetCommand(start.Cmd)
+        }
+    case *pb.JobRequest:
+        r.SetGPU(m.Uuid, m.LeaseId)
+        r.SetCommand(m.Cmd)
+    case *pb.CancelJobRequest:
+        r.SetJob(m.Id)
+    case *pb.FileRequest:
+        r.SetPath(m.Path)
+    case *pb.FileChunk:
+        r.SetPath(m.GetHeader().GetPath())
+    }
+}
+
+// requestUUID 返回请求消息中的GPU UUID，供监控指标使用
+func requestUUID(msg interface{}) string {
+    var r audit.Record
+    describeRequest(&r, msg)
+    return r.UUID
+}
+
+// auditJobExit 作业结束后记录其退出码和运行时长
+// SubmitJob 立即返回，命令的执行结果只能在作业结束时补记
+func (s *server) auditJobExit(job *jobs.Job, peer string) {
+    if s.audit == nil {
+        return
+    }
+    <-job.Done()
+    st := job.Snapshot()
+    r := &audit.Record{
+        Time:       st.StartTime,
+        User:       job.Owner,
+        Peer:       peer,
+        RPC:        "JobExit",
+        UUID:       job.UUID,
+        LeaseID:    job.LeaseID,
+        JobID:      job.ID,
+        Cmd:        job.Cmd,
+        DurationMs: st.EndTime.Sub(st.StartTime).Milliseconds(),
+        Code:       st.State.String(),
+    }
+    r.SetExit(st.ExitCode)
+    s.audit.Log(r)
+}
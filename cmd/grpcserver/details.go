@@ -0,0 +1,33 @@
+package main
+
+import (
+    "context"
+    "errors"
+
+    "google.golang.org/grpc/codes"
+    "google.golang.org/grpc/status"
+
+    pb "github.com/hiicl/GPU-over-IP-AC922/proto"
+
+    "github.com/hiicl/GPU-over-IP-AC922/pkg/query"
+)
+
+// details.go 实现 GetGPUDetails 详细信息查询
+
+// GetGPUDetails 返回GPU的温度、功耗、时钟、ECC错误、退役页、降频原因和进程列表
+// 详细信息查询开销较大，不经过遥测快照，每次调用直接查询后端
+func (s *server) GetGPUDetails(ctx context.Context, req *pb.GPURequest) (*pb.GPUDetails, error) {
+    if !s.boundGPUs[req.Uuid] {
+        return nil, status.Errorf(codes.NotFound, "GPU %s not bound to this NUMA group", req.Uuid)
+    }
+    d, err := query.Details(req.Uuid)
+    switch {
+    case errors.Is(err, query.ErrUnsupported):
+        return nil, status.Errorf(codes.Unimplemented, "GPU details: %v", err)
+    case errors.Is(err, query.ErrNotFound):
+        return nil, status.Errorf(codes.NotFound, "GPU %s: %v", req.Uuid, err)
+    case err != nil:
+        return nil, status.Errorf(codes.Unavailable, "GPU details: %v", err)
+    }
+    return d, nil
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+    "context"
+    "fmt"
+    "time"
+
+    pb "github.com/hiicl/GPU-over-IP-AC922/proto"
+
+    "github.com/hiicl/GPU-over-IP-AC922/pkg/audit"
+    "github.com/hiicl/GPU-over-IP-AC922/pkg/gpu"
+)
+
+// exec.go 实现 Exec 交互式会话
+
+// defaultExecCmd 客户端未指定命令时启动的交互式shell
+const defaultExecCmd = "exec bash -i"
+
+// Exec 启动交互式会话：第一条消息为 ExecStart，之后转发标准输入与窗口大小变化
+// 与 RunCommand 相同，要求GPU绑定到本NUMA组且请求携带有效租约
+// 客户端断开时会话命令随 stream.Context() 取消而终止
+func (s *server) Exec(stream pb.GPUService_ExecServer) error {
+    first, err := stream.Recv()
+    if err != nil {
+        return err
+    }
+    start := first.GetStart()
+    if start == nil {
+        return fmt.Errorf("first exec message must be start")
+    }
+    if err := s.checkRun(&pb.RunRequest{Uuid: start.Uuid, LeaseId: start.LeaseId}); err != nil {
+        return err
+    }
+
+    cmdline := start.Cmd
+    if cmdline == "" {
+        cmdline = defaultExecCmd
+    }
+    ctx, cancel := context.WithCancel(stream.Context())
+    defer cancel()
+    sess, err := gpu.StartSession(ctx, start.Uuid, cmdline, start.Tty,
+        uint16(start.GetSize().GetRows()), uint16(start.GetSize().GetCols()), start.Term)
+    if err != nil {
+        return fmt.Errorf("start exec session: %v", err)
+    }
+
+    // 输入协程：转发标准输入和窗口大小变化，客户端结束发送后关闭标准输入
+    go func() {
+        for {
+            msg, err := stream.Recv()
+            if err != nil {
+                sess.CloseStdin()
+                return
+            }
+            switch m := msg.Msg.(type) {
+            case *pb.ExecRequest_Stdin:
+                sess.Write(m.Stdin)
+            case *pb.ExecRequest_Resize:
+                sess.Resize(uint16(m.Resize.Rows), uint16(m.Resize.Cols))
+            }
+        }
+    }()
+
+    // 当前协程转发输出，直到命令退出
+    buf := make([]byte, 32*1024)
+    for {
+        n, err := sess.Read(buf)
+        if n > 0 {
+            out := &pb.RunOutput{
+                Stream:    pb.OutputStream_STDOUT,
+                Data:      append([]byte(nil), buf[:n]...),
+                Timestamp: time.Now().UnixMilli(),
+            }
+            if sendErr := stream.Send(out); sendErr != nil {
+                cancel() // 终止会话命令
+                sess.Wait()
+                return sendErr
+            }
+        }
+        if err != nil {
+            break
+        }
+    }
+    code := sess.Wait()
+    audit.FromContext(stream.Context()).SetExit(code)
+    return stream.Send(&pb.RunOutput{Done: true, ExitCode: int32(code), Timestamp: time.Now().UnixMilli()})
+}
@@ -0,0 +1,140 @@
+package main
+
+import (
+    "context"
+    "errors"
+    "fmt"
+    "io"
+    "io/fs"
+
+    "google.golang.org/grpc/codes"
+    "google.golang.org/grpc/status"
+    pb "github.com/hiicl/GPU-over-IP-AC922/proto"
+
+    "github.com/hiicl/GPU-over-IP-AC922/pkg/files"
+)
+
+// files.go 实现节点文件沙箱的上传、下载和查询RPC
+
+// fileChunkSize 下载时单个数据片段的大小
+const fileChunkSize = 256 * 1024
+
+// UploadFile 接收客户端上传的文件：第一条消息为 FileHeader，之后为数据片段
+// 传输结束后重新计算完整文件的SHA-256，与 header 中给出的值不一致时返回 ok=false
+func (s *server) UploadFile(stream pb.GPUService_UploadFileServer) error {
+    first, err := stream.Recv()
+    if err != nil {
+        return err
+    }
+    hdr := first.GetHeader()
+    if hdr == nil {
+        return status.Error(codes.InvalidArgument, "first upload message must be header")
+    }
+
+    f, err := s.files.OpenWrite(hdr.Path, hdr.Offset)
+    if err != nil {
+        return fileError(err)
+    }
+    for {
+        msg, err := stream.Recv()
+        if err == io.EOF {
+            break
+        }
+        if err != nil {
+            f.Close()
+            return err // 已写入的部分保留，客户端可从当前大小续传
+        }
+        if _, err := f.Write(msg.GetData()); err != nil {
+            f.Close()
+            return err
+        }
+    }
+    if err := f.Close(); err != nil {
+        return err
+    }
+
+    size, sum, err := s.files.Stat(hdr.Path)
+    if err != nil {
+        return fileError(err)
+    }
+    result := &pb.FileResult{Ok: true, Msg: "uploaded", Path: hdr.Path, Size: size, Sha256: sum}
+    if hdr.Size > 0 && size != hdr.Size {
+        result.Ok = false
+        result.Msg = fmt.Sprintf("size mismatch: expected %d, got %d", hdr.Size, size)
+    } else if hdr.Sha256 != "" && sum != hdr.Sha256 {
+        result.Ok = false
+        result.Msg = "sha256 mismatch"
+    }
+    return stream.SendAndClose(result)
+}
+
+// DownloadFile 从指定偏移开始发送文件：先发送包含完整文件大小和SHA-256的 header，再发送数据片段
+// skipChecksum 为 true 时不计算SHA-256，避免轮询日志时反复哈希整个文件
+func (s *server) DownloadFile(req *pb.FileRequest, stream pb.GPUService_DownloadFileServer) error {
+    var (
+        size int64
+        sum  string
+        err  error
+    )
+    if req.SkipChecksum {
+        size, err = s.files.Size(req.Path)
+    } else {
+        size, sum, err = s.files.Stat(req.Path)
+    }
+    if err != nil {
+        return fileError(err)
+    }
+    if req.Offset < 0 || req.Offset > size {
+        return status.Errorf(codes.OutOfRange, "%s: offset %d out of range (size %d)", req.Path, req.Offset, size)
+    }
+    f, err := s.files.OpenRead(req.Path, req.Offset)
+    if err != nil {
+        return fileError(err)
+    }
+    defer f.Close()
+
+    hdr := &pb.FileHeader{Path: req.Path, Offset: req.Offset, Size: size, Sha256: sum}
+    if err := stream.Send(&pb.FileChunk{Msg: &pb.FileChunk_Header{Header: hdr}}); err != nil {
+        return err
+    }
+    buf := make([]byte, fileChunkSize)
+    for {
+        n, err := f.Read(buf)
+        if n > 0 {
+            // 消息发送后不能再修改，buf 会被下一次读取覆盖，因此复制数据
+            data := append([]byte(nil), buf[:n]...)
+            chunk := &pb.FileChunk{Msg: &pb.FileChunk_Data{Data: data}}
+            if sendErr := stream.Send(chunk); sendErr != nil {
+                return sendErr
+            }
+        }
+        if err == io.EOF {
+            return nil
+        }
+        if err != nil {
+            return err
+        }
+    }
+}
+
+// fileError 将文件沙箱错误转换为gRPC状态，REST网关据此返回对应的HTTP状态码
+func fileError(err error) error {
+    switch {
+    case errors.Is(err, fs.ErrNotExist):
+        return status.Error(codes.NotFound, err.Error())
+    case errors.Is(err, files.ErrOutsideRoot):
+        return status.Error(codes.PermissionDenied, err.Error())
+    case errors.Is(err, files.ErrOffset):
+        return status.Error(codes.OutOfRange, err.Error())
+    }
+    return status.Error(codes.Internal, err.Error())
+}
+
+// StatFile 返回沙箱内文件的大小和SHA-256；文件不存在时返回 ok=false、size=0
+func (s *server) StatFile(ctx context.Context, req *pb.FileRequest) (*pb.FileResult, error) {
+    size, sum, err := s.files.Stat(req.Path)
+    if err != nil {
+        return &pb.FileResult{Ok: false, Msg: err.Error(), Path: req.Path}, nil
+    }
+    return &pb.FileResult{Ok: true, Path: req.Path, Size: size, Sha256: sum}, nil
+}
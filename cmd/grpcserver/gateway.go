@@ -0,0 +1,43 @@
+package main
+
+import (
+    "log"
+    "net/http"
+
+    "github.com/hiicl/GPU-over-IP-AC922/pkg/dashboard"
+    "github.com/hiicl/GPU-over-IP-AC922/pkg/gateway"
+)
+
+// gateway.go 启动 REST/JSON 网关和Web控制台
+
+// runGateway 在 addr 上提供 REST/JSON 网关（/v1/）及Web控制台（/）
+// 网关经进程内连接调用各NUMA分组的 GPUService；启用TLS时使用与gRPC相同的证书和客户端CA
+func runGateway(addr string, shared *services) {
+    var backends []gateway.Backend
+    for _, g := range shared.groups {
+        client, err := gateway.Dial(g.local)
+        if err != nil {
+            log.Fatalf("[Fatal] Failed to connect gateway to NUMA %d: %v", g.node, err)
+        }
+        backends = append(backends, gateway.Backend{Node: g.node, GPUs: g.gpus, Client: client})
+    }
+    if len(backends) == 0 {
+        log.Printf("[Warn] no NUMA groups, REST gateway disabled")
+        return
+    }
+
+    mux := http.NewServeMux()
+    mux.Handle("/v1/", gateway.New(backends))
+    if *dashboardOn {
+        mux.Handle("/", dashboard.Handler())
+    }
+    srv := &http.Server{Addr: addr, Handler: mux, TLSConfig: shared.tls}
+    log.Printf("[OK] REST gateway ready on %s (TLS: %v, dashboard: %v)", addr, shared.tls != nil, *dashboardOn)
+    var err error
+    if shared.tls != nil {
+        err = srv.ListenAndServeTLS("", "")
+    } else {
+        err = srv.ListenAndServe()
+    }
+    log.Fatalf("[Fatal] Failed to serve REST gateway: %v", err)
+}
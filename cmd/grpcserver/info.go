@@ -0,0 +1,91 @@
+package main
+
+import (
+    "context"
+    "log"
+    "time"
+
+    healthpb "google.golang.org/grpc/health/grpc_health_v1"
+
+    pb "github.com/hiicl/GPU-over-IP-AC922/proto"
+
+    "github.com/hiicl/GPU-over-IP-AC922/pkg/gpuhealth"
+    "github.com/hiicl/GPU-over-IP-AC922/pkg/query"
+)
+
+// info.go 实现服务端信息查询和 grpc.health.v1 健康状态维护
+
+// version 构建版本，由 -ldflags "-X main.version=..." 注入
+var version = "dev"
+
+// apiVersion GPUService API 版本，协议不兼容变更时递增主版本
+const apiVersion = "1.0"
+
+// gpuServiceName 健康检查中使用的服务名
+const gpuServiceName = "gpu.GPUService"
+
+// features 服务端支持的功能标识，客户端据此判断可用的RPC
+var features = []string{
+    "run-stream",    // RunCommandStream
+    "watch-status",  // WatchGPUStatus
+    "leases",        // AcquireGPU 租约、RenewLease
+    "multi-acquire", // AcquireGPUs
+    "list-leases",   // ListLeases
+    "jobs",          // SubmitJob / GetJob / CancelJob / ListJobs
+    "exec",          // Exec 交互式会话
+    "files",         // UploadFile / DownloadFile / StatFile
+    "node-info",     // GetNodeInfo
+    "gpu-details",   // GetGPUDetails
+    "gpu-processes", // ListGPUProcesses
+    "quarantine",    // ListGPUs 健康状态、UncordonGPU
+    "mig",           // ListGPUs 列出MIG实例，可像整卡一样占用
+    "health",        // grpc.health.v1
+    "reflection",    // grpc.reflection
+}
+
+// GetServerInfo 返回构建版本、API版本及支持的功能
+func (s *server) GetServerInfo(ctx context.Context, _ *pb.Void) (*pb.ServerInfo, error) {
+    return &pb.ServerInfo{Version: version, ApiVersion: apiVersion, Features: features}, nil
+}
+
+// watchHealth 定期探测GPU后端，更新健康状态，并按健康规则隔离不健康的GPU
+// 后端不可用（查询失败、超时或未列出GPU）时报告 NOT_SERVING
+// 所有 NUMA 分组的 gRPC 服务共享同一个健康状态；后端报告的XID事件写入日志
+func watchHealth(s *services, mon *gpuhealth.Monitor, interval time.Duration) {
+    var gpus []string
+    for _, g := range s.groups {
+        gpus = append(gpus, g.gpus...)
+    }
+    serving := true
+    since := time.Now()
+    for {
+        events := query.XIDEvents(since)
+        for _, e := range events {
+            log.Printf("[Health] XID %d on GPU %s at %s", e.XID, e.UUID, e.Time.Format(time.RFC3339))
+            since = e.Time
+        }
+        mon.ObserveXID(events)
+
+        status := healthpb.HealthCheckResponse_SERVING
+        err := query.Probe(interval)
+        if err != nil {
+            status = healthpb.HealthCheckResponse_NOT_SERVING
+            if serving {
+                log.Printf("[Health] GPU backend failing: %v", err)
+            }
+            serving = false
+        } else if !serving {
+            log.Printf("[Health] GPU backend recovered")
+            serving = true
+        }
+        mon.ObserveProbe(err, gpus)
+        if err == nil && mon.NeedDetails() {
+            if details, err := query.ListDetails(); err == nil {
+                mon.ObserveDetails(details)
+            }
+        }
+        s.health.SetServingStatus("", status)
+        s.health.SetServingStatus(gpuServiceName, status)
+        time.Sleep(interval)
+    }
+}
@@ -0,0 +1,164 @@
+package main
+
+import (
+    "context"
+    "errors"
+    "fmt"
+    "log"
+    "path/filepath"
+    "strings"
+    "time"
+
+    "google.golang.org/grpc/codes"
+    "google.golang.org/grpc/status"
+    pb "github.com/hiicl/GPU-over-IP-AC922/proto"
+
+    "github.com/hiicl/GPU-over-IP-AC922/pkg/audit"
+    "github.com/hiicl/GPU-over-IP-AC922/pkg/auth"
+    "github.com/hiicl/GPU-over-IP-AC922/pkg/jobs"
+)
+
+// jobs.go 实现异步作业相关RPC：SubmitJob / GetJob / CancelJob / ListJobs
+
+// jobReply 将作业转换为响应消息
+// 日志位于文件沙箱内时返回沙箱相对路径，客户端可直接用于 DownloadFile
+func (s *server) jobReply(j *jobs.Job) *pb.Job {
+    st := j.Snapshot()
+    reply := &pb.Job{
+        Id:        j.ID,
+        Uuid:      j.UUID,
+        Cmd:       j.Cmd,
+        Owner:     j.Owner,
+        State:     pb.JobState(st.State),
+        ExitCode:  int32(st.ExitCode),
+        StartTime: st.StartTime.UnixMilli(),
+        LogPath:   j.LogPath,
+        LeaseId:   j.LeaseID,
+    }
+    if rel, err := filepath.Rel(s.files.Root, j.LogPath); err == nil && !strings.HasPrefix(rel, "..") {
+        reply.LogPath = rel
+    }
+    if !st.EndTime.IsZero() {
+        reply.EndTime = st.EndTime.UnixMilli()
+    }
+    return reply
+}
+
+// SubmitJob 校验租约后异步启动作业，立即返回作业信息
+// 作业运行期间服务端自动续期租约，避免长时间训练因租约到期而被他人占用GPU；
+// 续期最长持续 -job-max-runtime，超过后作业被取消，租约按正常TTL到期回收
+func (s *server) SubmitJob(ctx context.Context, req *pb.JobRequest) (*pb.Job, error) {
+    if err := s.checkRun(&pb.RunRequest{Uuid: req.Uuid, LeaseId: req.LeaseId}); err != nil {
+        return nil, err
+    }
+    owner := callerName(ctx, req.Owner)
+    job, err := s.jobs.Submit(req.Uuid, req.Cmd, owner, req.LeaseId)
+    if err != nil {
+        return nil, err
+    }
+    go s.keepLease(job, *jobLimit)
+    audit.FromContext(ctx).SetJob(job.ID)
+    go s.auditJobExit(job, peerAddr(ctx))
+    return s.jobReply(job), nil
+}
+
+// keepLease 在作业运行期间定期续期其租约，作业结束或续期失败后停止
+// 按租约自身的时长续期（占用时指定的 ttlSeconds 或之后续期的时长），在剩余时长的三分之一处续期
+// maxRuntime: 作业最长运行时间，超过后取消作业并停止续期（<=0 表示不限制）
+func (s *server) keepLease(job *jobs.Job, maxRuntime time.Duration) {
+    lease, err := s.sched.Lookup(job.LeaseID)
+    if err != nil {
+        log.Printf("[Job] %s: lease %s: %v", job.ID, job.LeaseID, err)
+        return
+    }
+    renew := time.NewTimer(time.Until(lease.Expiry) / 3)
+    defer renew.Stop()
+    var deadline <-chan time.Time
+    if maxRuntime > 0 {
+        timer := time.NewTimer(maxRuntime)
+        defer timer.Stop()
+        deadline = timer.C
+    }
+    for {
+        select {
+        case <-job.Done():
+            return
+        case <-deadline:
+            log.Printf("[Job] %s: exceeded max runtime %v, canceling and releasing lease %s to its TTL", job.ID, maxRuntime, job.LeaseID)
+            if _, err := s.jobs.Cancel(job.ID, *jobGrace); err != nil {
+                log.Printf("[Job] %s: cancel failed: %v", job.ID, err)
+            }
+            return
+        case <-renew.C:
+            // 持有者可能已按其他时长续期，以租约当前的时长为准
+            if lease, err = s.sched.Lookup(job.LeaseID); err == nil {
+                lease, err = s.sched.Renew(job.LeaseID, lease.TTL)
+            }
+            if err != nil {
+                log.Printf("[Job] %s: renew lease %s failed: %v", job.ID, job.LeaseID, err)
+                return
+            }
+            renew.Reset(lease.TTL / 3)
+        }
+    }
+}
+
+// lookupJob 查找本NUMA组绑定GPU上的作业
+func (s *server) lookupJob(id string) (*jobs.Job, error) {
+    job, err := s.jobs.Get(id)
+    if err == nil && !s.boundGPUs[job.UUID] {
+        err = jobs.ErrNotFound
+    }
+    if errors.Is(err, jobs.ErrNotFound) {
+        return nil, status.Errorf(codes.NotFound, "job %s: %v", id, err)
+    }
+    if err != nil {
+        return nil, fmt.Errorf("job %s: %v", id, err)
+    }
+    return job, nil
+}
+
+// GetJob 查询作业状态
+func (s *server) GetJob(ctx context.Context, req *pb.JobID) (*pb.Job, error) {
+    job, err := s.lookupJob(req.Id)
+    if err != nil {
+        return nil, err
+    }
+    return s.jobReply(job), nil
+}
+
+// CancelJob 取消作业，立即返回（作业在宽限期内退出后状态变为JOB_CANCELED）
+// 启用访问控制时，只有作业提交者或管理员可以取消作业
+func (s *server) CancelJob(ctx context.Context, req *pb.CancelJobRequest) (*pb.Job, error) {
+    grace := *jobGrace
+    if req.GraceSeconds > 0 {
+        grace = time.Duration(req.GraceSeconds) * time.Second
+    }
+    found, err := s.lookupJob(req.Id)
+    if err != nil {
+        return nil, err
+    }
+    if id := auth.FromContext(ctx); found.Owner != callerName(ctx, "") && !id.Can(auth.PermAdmin) {
+        return nil, status.Errorf(codes.PermissionDenied, "job %s belongs to %s, only its owner or an admin may cancel it", req.Id, found.Owner)
+    }
+    job, err := s.jobs.Cancel(req.Id, grace)
+    if err != nil {
+        return nil, fmt.Errorf("job %s: %v", req.Id, err)
+    }
+    return s.jobReply(job), nil
+}
+
+// ListJobs 按过滤条件列出作业（只列出本NUMA组绑定GPU上的作业）
+func (s *server) ListJobs(ctx context.Context, req *pb.JobFilter) (*pb.JobList, error) {
+    filter := jobs.Filter{UUID: req.Uuid, Owner: req.Owner}
+    for _, st := range req.States {
+        filter.States = append(filter.States, jobs.State(st))
+    }
+    reply := &pb.JobList{}
+    for _, job := range s.jobs.List(filter) {
+        if s.boundGPUs[job.UUID] {
+            reply.Jobs = append(reply.Jobs, s.jobReply(job))
+        }
+    }
+    return reply, nil
+}
@@ -1,113 +1,587 @@
-package main
-
-import (
-    "context"
-    "flag"
-    "fmt"
-    "log"
-    "net"
-    "os"
-    "strconv"
-
-    "google.golang.org/grpc"
-    pb "github.com/hiicl/GPU-over-IP-AC922/proto"
-
-    "github.com/hiicl/GPU-over-IP-AC922/pkg/query"
-    "github.com/hiicl/GPU-over-IP-AC922/pkg/scheduler"
-    "github.com/hiicl/GPU-over-IP-AC922/pkg/gpu"
-    "github.com/hiicl/GPU-over-IP-AC922/modules/memext"
-    "github.com/hiicl/GPU-over-IP-AC922/modules/netbalance"
-)
-
-// 单个服务结构（绑定一组GPU）
-type server struct {
-    pb.UnimplementedGPUServiceServer
-    boundGPUs map[string]bool
-}
-
-// 只处理绑定的GPU
-func (s *server) ListGPUs(ctx context.Context, _ *pb.Void) (*pb.GPUList, error) {
-    infos := query.ListGPUs()
-    var filtered []*pb.GPUInfo
-    for _, g := range infos {
-        if s.boundGPUs[g.Uuid] {
-            filtered = append(filtered, g)
-        }
-    }
-    return &pb.GPUList{Gpus: filtered}, nil
-}
-
-func (s *server) GetGPUStatus(ctx context.Context, req *pb.GPURequest) (*pb.GPUStatus, error) {
-    if !s.boundGPUs[req.Uuid] {
-        return nil, fmt.Errorf("GPU %s not bound to this NUMA group", req.Uuid)
-    }
-    st := query.GetGPUStatus(req.Uuid)
-    return &pb.GPUStatus{UsedMemory: st.UsedMemory, Utilization: st.Utilization}, nil
-}
-
-func (s *server) AcquireGPU(ctx context.Context, req *pb.GPURequest) (*pb.Ack, error) {
-    if !s.boundGPUs[req.Uuid] {
-        return &pb.Ack{Ok: false, Msg: "GPU not bound"}, nil
-    }
-    ok := scheduler.Acquire(req.Uuid)
-    return &pb.Ack{Ok: ok, Msg: "acquire result"}
-}
-
-func (s *server) ReleaseGPU(ctx context.Context, req *pb.GPURequest) (*pb.Ack, error) {
-    scheduler.Release(req.Uuid)
-    return &pb.Ack{Ok: true, Msg: "released"}
-}
-
-func (s *server) RunCommand(ctx context.Context, req *pb.RunRequest) (*pb.RunResponse, error) {
-    if !s.boundGPUs[req.Uuid] {
-        return nil, fmt.Errorf("GPU %s not bound to this NUMA group", req.Uuid)
-    }
-    output, code := gpu.RunCommand(req.Uuid, req.Cmd)
-    return &pb.RunResponse{ExitCode: int32(code), Output: output}, nil
-}
-
-// 启动多个 NUMA 分组的 gRPC 服务
-func main() {
-    flag.Parse()
-    memext.Init()
-
-    // 自动获取 NUMA 拓扑
-    groups, err := netbalance.GetNUMATopology()
-    if err != nil {
-        log.Fatalf("[Fatal] Failed to get NUMA topology: %v", err)
-    }
-
-    basePort := 50051
-    for i, group := range groups {
-        port := basePort + i
-        gpuUUIDs := query.GPUUUIDsByIDs(group.GPUIDs)
-
-        go func(p int, gpus []string, nics []string) {
-            log.Printf("[Launch] NUMA %d listening on :%d, GPUs=%v, NICs=%v", group.NUMANode, p, gpus, nics)
-            runGRPCServer(p, gpus)
-        }(port, gpuUUIDs, group.NetIfaces)
-    }
-
-    select {} // 阻塞主线程
-}
-
-// 启动一个 gRPC Server 并绑定 GPU UUIDs
-func runGRPCServer(port int, gpuUUIDs []string) {
-    bound := make(map[string]bool)
-    for _, uuid := range gpuUUIDs {
-        bound[uuid] = true
-    }
-
-    lis, err := net.Listen("tcp", ":"+strconv.Itoa(port))
-    if err != nil {
-        log.Fatalf("[Fatal] Failed to listen on port %d: %v", port, err)
-    }
-
-    grpcServer := grpc.NewServer()
-    pb.RegisterGPUServiceServer(grpcServer, &server{boundGPUs: bound})
-
-    log.Printf("[OK] gRPC server ready on :%d", port)
-    if err := grpcServer.Serve(lis); err != nil {
-        log.Fatalf("[Fatal] Failed to serve gRPC: %v", err)
-    }
-}
+package main
+
+import (
+    "context"
+    "crypto/tls"
+    "flag"
+    "fmt"
+    "log"
+    "net"
+    "os"
+    "path/filepath"
+    "sort"
+    "strconv"
+    "time"
+
+    "google.golang.org/grpc"
+    "google.golang.org/grpc/credentials"
+    "google.golang.org/grpc/health"
+    healthpb "google.golang.org/grpc/health/grpc_health_v1"
+    "google.golang.org/grpc/reflection"
+    pb "github.com/hiicl/GPU-over-IP-AC922/proto"
+
+    "github.com/hiicl/GPU-over-IP-AC922/pkg/audit"
+    "github.com/hiicl/GPU-over-IP-AC922/pkg/auth"
+    "github.com/hiicl/GPU-over-IP-AC922/pkg/files"
+    "github.com/hiicl/GPU-over-IP-AC922/pkg/gpuhealth"
+    "github.com/hiicl/GPU-over-IP-AC922/pkg/jobs"
+    "github.com/hiicl/GPU-over-IP-AC922/pkg/metrics"
+    "github.com/hiicl/GPU-over-IP-AC922/pkg/query"
+    "github.com/hiicl/GPU-over-IP-AC922/pkg/scheduler"
+    "github.com/hiicl/GPU-over-IP-AC922/pkg/gpu"
+    "github.com/hiicl/GPU-over-IP-AC922/modules/memext"
+    "github.com/hiicl/GPU-over-IP-AC922/modules/netbalance"
+)
+
+// 命令行参数
+var (
+    leaseTTL = flag.Duration("lease-ttl", 30*time.Minute, "GPU 租约默认时长，到期未续期自动回收")
+    fileRoot = flag.String("file-root", "/var/lib/aitherion/files", "文件上传/下载沙箱根目录，也是异步作业的工作目录")
+    jobDir   = flag.String("job-dir", "", "异步作业日志目录（默认 <file-root>/.jobs，可通过 DownloadFile 取回）")
+    jobGrace = flag.Duration("job-grace", 30*time.Second, "取消作业时 SIGTERM 到 SIGKILL 的默认宽限期")
+    jobKeep  = flag.Duration("job-retention", 7*24*time.Hour, "已结束作业及其日志的保留时长，超过后删除（0 表示永久保留）")
+    jobLimit = flag.Duration("job-max-runtime", 72*time.Hour, "作业最长运行时间，超过后停止续期租约并取消作业（0 表示不限制）")
+
+    healthInterval = flag.Duration("health-interval", 10*time.Second, "GPU 后端健康探测间隔")
+    healthRules    = flag.String("health-rules", "", "GPU健康规则YAML文件：隔离GPU的XID、ECC错误、温度降频和后端超时阈值（为空时使用默认规则）")
+    gpuBackend     = flag.String("gpu-backend", "nvidia-smi", "GPU 查询后端：nvidia-smi、nvml（需 -tags nvml 构建）、fake（模拟GPU）或 sim（按 -scenario 场景模拟），后两者无需GPU即可运行")
+    fakeGPUs       = flag.Int("fake-gpus", query.DefaultFakeGPUs, "-gpu-backend=fake 时模拟的GPU数量")
+    fakeMIG        = flag.String("fake-mig", "", "-gpu-backend=fake 时启用MIG的GPU及其实例划分，如 \"0:3g.20gb,2g.10gb,1g.5gb;1:7g.40gb\"")
+    sampleInterval = flag.Duration("telemetry-interval", time.Second, "后台遥测采样间隔，所有RPC共享同一份快照（0 表示每次调用直接查询后端）")
+    sampleMaxAge   = flag.Duration("telemetry-max-age", 5*time.Second, "快照超过该时长时，读取方触发一次刷新")
+    sampleWait     = flag.Duration("telemetry-refresh-timeout", 2*time.Second, "读取方等待刷新的上限，超时返回旧快照并标记为过旧")
+    scenarioFile   = flag.String("scenario", "", "-gpu-backend=sim 使用的YAML场景文件（GPU、NUMA拓扑、负载曲线和注入的故障）")
+    rogueInterval  = flag.Duration("rogue-interval", time.Minute, "检查GPU上失控进程（非本服务启动或没有有效租约）的间隔（0 表示不检查）")
+    killRogue      = flag.Bool("kill-rogue", false, "终止连续两次检查都判定为失控的GPU进程（fake、sim 后端的进程为模拟数据，不会被终止）")
+
+    tlsCert   = flag.String("tls-cert", "", "服务端TLS证书（为空时不启用TLS）")
+    tlsKey    = flag.String("tls-key", "", "服务端TLS私钥")
+    tlsCA     = flag.String("tls-ca", "", "客户端CA证书包，指定后要求客户端证书（mTLS）")
+    tokenFile = flag.String("token-file", "", "Bearer令牌文件，每行 \"<token> <user>\"（为空时不校验令牌）")
+    metricsAddr = flag.String("metrics-addr", ":9400", "Prometheus /metrics 监听地址（为空时不启用）")
+    httpAddr    = flag.String("http-addr", ":8080", "REST/JSON 网关监听地址（为空时不启用），与gRPC共用TLS证书、令牌和访问策略")
+    dashboardOn = flag.Bool("dashboard", true, "在REST网关地址的根路径提供Web控制台")
+
+    auditLog     = flag.String("audit-log", "/var/log/aitherion/audit.log", "审计日志文件（NDJSON，为空时不记录）")
+    auditMaxSize = flag.Int64("audit-max-size", 100, "审计日志单个文件大小上限（MB），超过后轮转")
+    auditKeep    = flag.Int("audit-keep", 10, "保留的审计日志轮转文件数量")
+
+    policyFile = flag.String("policy", "", "访问控制策略YAML文件：角色权限和租户GPU配额（为空时不做授权检查）")
+
+    controllerAddr    = flag.String("controller", os.Getenv("CONTROLLER"), "控制器地址 host:port（为空时不注册）")
+    announceAddr      = flag.String("announce", "", "局域网节点通告的组播或广播地址（如 "+netbalance.DefaultAnnounceAddr+"，为空时不通告）")
+    advertiseIP       = flag.String("advertise-ip", "", "向控制器注册或局域网通告的本机IP（默认为发往对方时使用的本机地址）")
+    heartbeatInterval = flag.Duration("heartbeat", 5*time.Second, "向控制器发送心跳和局域网通告的间隔，需小于节点超时时间")
+    controllerCA      = flag.String("controller-tls-ca", "", "校验控制器证书的CA证书包（指定任一 -controller-tls-* 参数即启用TLS）")
+    controllerCert    = flag.String("controller-tls-cert", "", "连接控制器使用的客户端证书（控制器启用mTLS时必须）")
+    controllerKey     = flag.String("controller-tls-key", "", "连接控制器使用的客户端私钥")
+    controllerToken   = flag.String("controller-token-file", "", "连接控制器使用的Bearer令牌文件")
+
+    enableMemExt = flag.Bool("memext", os.Getenv("MEMEXT") == "1", "启用 memext 共享内存池（读取 /mnt/memext/size）")
+)
+
+// services 进程内所有 NUMA 分组共享的组件
+type services struct {
+    sched   *scheduler.Scheduler // GPU租约调度器
+    jobs    *jobs.Manager        // 异步作业管理器
+    files   *files.Sandbox       // 节点文件沙箱
+    groups  []numaGroup          // 所有 NUMA 分组（启动服务前确定，之后只读）
+    health  *health.Server       // grpc.health.v1 健康状态
+    monitor *gpuhealth.Monitor   // 健康规则评估器
+    audit   *audit.Logger        // 审计日志（未启用时为nil）
+    metrics *metrics.Metrics     // Prometheus 指标（未启用时为nil）
+    tls     *tls.Config          // 服务端TLS配置（未启用时为nil），gRPC与REST网关共用
+    opts    []grpc.ServerOption  // 认证、审计、授权拦截器
+}
+
+// numaGroup 一个 NUMA 分组及其服务端口
+type numaGroup struct {
+    node     int                 // NUMA节点编号
+    port     int                 // gRPC端口
+    gpus     []string            // 分组内GPU的UUID
+    nics     []string            // 分组内物理网卡
+    memTotal uint64              // NUMA节点内存总量（字节）
+    local    *auth.LocalListener // REST网关的进程内连接（未启用网关时为nil）
+}
+
+// 单个服务结构（绑定一组GPU）
+type server struct {
+    pb.UnimplementedGPUServiceServer
+    *services
+    boundGPUs map[string]bool
+    numaNode  int // 本服务对应的NUMA节点
+}
+
+// leaseReply 将调度器租约转换为响应消息
+func leaseReply(l *scheduler.Lease, msg string) *pb.Lease {
+    return &pb.Lease{
+        Ok:        true,
+        Msg:       msg,
+        LeaseId:   l.ID,
+        Uuid:      l.UUID,
+        Owner:     l.Owner,
+        ExpiresAt: l.Expiry.UnixMilli(),
+    }
+}
+
+// peerAddr 返回客户端地址（REST网关请求附带HTTP客户端地址），无法获取时返回"unknown"
+func peerAddr(ctx context.Context) string {
+    return auth.PeerAddr(ctx)
+}
+
+// callerName 返回请求的持有者名称
+// 已认证的调用者始终使用认证身份，防止冒用他人名义；否则使用请求中的名称或客户端地址
+func callerName(ctx context.Context, requested string) string {
+    if id := auth.FromContext(ctx); id != nil && id.User != "" {
+        return id.User
+    }
+    if requested != "" {
+        return requested
+    }
+    return peerAddr(ctx)
+}
+
+// sampleTime 返回快照的采样时间（Unix 毫秒，未成功采样时为0）和距今时长（毫秒）
+func sampleTime(smp *query.Sample) (int64, int64) {
+    if smp.Time.IsZero() {
+        return 0, 0
+    }
+    return smp.Time.UnixMilli(), smp.Age().Milliseconds()
+}
+
+// 只处理绑定的GPU
+// 数据来自遥测快照，响应携带采样时间和是否过旧；被健康检查隔离的GPU附带隔离原因
+func (s *server) ListGPUs(ctx context.Context, _ *pb.Void) (*pb.GPUList, error) {
+    smp := query.Latest()
+    cordoned := s.sched.Cordoned()
+    var filtered []*pb.GPUInfo
+    for _, g := range smp.CloneGPUs() {
+        if !s.boundGPUs[g.Uuid] {
+            continue
+        }
+        if reason, ok := cordoned[g.Uuid]; ok {
+            g.Unhealthy, g.HealthReason = true, reason
+        }
+        filtered = append(filtered, g)
+    }
+    ts, age := sampleTime(smp)
+    return &pb.GPUList{Gpus: filtered, Timestamp: ts, AgeMs: age, Stale: smp.Stale()}, nil
+}
+
+func (s *server) GetGPUStatus(ctx context.Context, req *pb.GPURequest) (*pb.GPUStatus, error) {
+    if !s.boundGPUs[req.Uuid] {
+        return nil, fmt.Errorf("GPU %s not bound to this NUMA group", req.Uuid)
+    }
+    smp := query.Latest()
+    st := smp.Status[req.Uuid]
+    ts, age := sampleTime(smp)
+    return &pb.GPUStatus{
+        Uuid:        req.Uuid,
+        UsedMemory:  st.UsedMemory,
+        Utilization: st.Utilization,
+        Timestamp:   ts,
+        AgeMs:       age,
+        Stale:       smp.Stale(),
+    }, nil
+}
+
+func (s *server) AcquireGPU(ctx context.Context, req *pb.GPURequest) (*pb.Lease, error) {
+    if !s.boundGPUs[req.Uuid] {
+        return &pb.Lease{Ok: false, Msg: "GPU not bound"}, nil
+    }
+    lease, err := s.sched.Acquire(req.Uuid, callerName(ctx, req.Owner), callerTenant(ctx), time.Duration(req.TtlSeconds)*time.Second)
+    if err != nil {
+        if qerr := quotaError(err); qerr != nil {
+            return nil, qerr
+        }
+        return &pb.Lease{Ok: false, Msg: err.Error()}, nil
+    }
+    audit.FromContext(ctx).SetGPU(lease.UUID, lease.ID)
+    return leaseReply(lease, "acquired"), nil
+}
+
+// RenewLease 延长租约有效期，需在租约到期前调用
+func (s *server) RenewLease(ctx context.Context, req *pb.LeaseRequest) (*pb.Lease, error) {
+    lease, err := s.sched.Renew(req.LeaseId, time.Duration(req.TtlSeconds)*time.Second)
+    if err != nil {
+        return &pb.Lease{Ok: false, Msg: err.Error()}, nil
+    }
+    return leaseReply(lease, "renewed"), nil
+}
+
+func (s *server) ReleaseGPU(ctx context.Context, req *pb.GPURequest) (*pb.Ack, error) {
+    if err := s.sched.Release(req.Uuid, req.LeaseId); err != nil {
+        return &pb.Ack{Ok: false, Msg: err.Error()}, nil
+    }
+    return &pb.Ack{Ok: true, Msg: "released"}, nil
+}
+
+// UncordonGPU 解除健康检查对GPU的隔离
+func (s *server) UncordonGPU(ctx context.Context, req *pb.GPURequest) (*pb.Ack, error) {
+    if !s.boundGPUs[req.Uuid] {
+        return &pb.Ack{Ok: false, Msg: "GPU not bound"}, nil
+    }
+    if !s.sched.Uncordon(req.Uuid) {
+        return &pb.Ack{Ok: false, Msg: "GPU is not cordoned"}, nil
+    }
+    s.monitor.Uncordoned(req.Uuid)
+    log.Printf("[Health] GPU %s uncordoned by %s", req.Uuid, callerName(ctx, ""))
+    return &pb.Ack{Ok: true, Msg: "uncordoned"}, nil
+}
+
+// ListLeases 列出本NUMA组绑定GPU上的有效租约
+// 租约ID是释放租约和执行命令的凭据，只返回给租约持有者
+func (s *server) ListLeases(ctx context.Context, _ *pb.Void) (*pb.LeaseList, error) {
+    caller := callerName(ctx, "")
+    reply := &pb.LeaseList{Ok: true}
+    for _, l := range s.sched.Leases() {
+        if !s.boundGPUs[l.UUID] {
+            continue
+        }
+        lease := leaseReply(l, "")
+        if l.Owner != caller {
+            lease.LeaseId = ""
+        }
+        reply.Leases = append(reply.Leases, lease)
+    }
+    return reply, nil
+}
+
+// checkRun 校验命令执行请求：GPU必须绑定到本NUMA组且请求携带有效租约
+func (s *server) checkRun(req *pb.RunRequest) error {
+    if !s.boundGPUs[req.Uuid] {
+        return fmt.Errorf("GPU %s not bound to this NUMA group", req.Uuid)
+    }
+    if err := s.sched.Validate(req.Uuid, req.LeaseId); err != nil {
+        return fmt.Errorf("GPU %s: %v", req.Uuid, err)
+    }
+    return nil
+}
+
+func (s *server) RunCommand(ctx context.Context, req *pb.RunRequest) (*pb.RunResponse, error) {
+    if err := s.checkRun(req); err != nil {
+        return nil, err
+    }
+    output, code := gpu.RunCommand(req.Uuid, req.Cmd)
+    audit.FromContext(ctx).SetExit(code)
+    return &pb.RunResponse{ExitCode: int32(code), Output: output}, nil
+}
+
+// RunCommandStream 流式执行命令：输出片段实时推送，最后一条消息携带退出码
+// 客户端断开时 stream.Context() 被取消，命令进程随之终止
+func (s *server) RunCommandStream(req *pb.RunRequest, stream pb.GPUService_RunCommandStreamServer) error {
+    if err := s.checkRun(req); err != nil {
+        return err
+    }
+    code, err := gpu.StreamCommand(stream.Context(), req.Uuid, req.Cmd, func(c gpu.OutputChunk) error {
+        out := &pb.RunOutput{
+            Stream:    pb.OutputStream_STDOUT,
+            Data:      c.Data,
+            Timestamp: c.Timestamp.UnixMilli(),
+        }
+        if c.Stream == gpu.StreamStderr {
+            out.Stream = pb.OutputStream_STDERR
+        }
+        return stream.Send(out)
+    })
+    if err != nil {
+        return err
+    }
+    audit.FromContext(stream.Context()).SetExit(code)
+    return stream.Send(&pb.RunOutput{Done: true, ExitCode: int32(code), Timestamp: time.Now().UnixMilli()})
+}
+
+// 启动多个 NUMA 分组的 gRPC 服务
+func main() {
+    flag.Parse()
+    if *leaseTTL <= 0 {
+        log.Fatalf("[Fatal] -lease-ttl must be positive, got %v", *leaseTTL)
+    }
+    if err := memext.Init(*enableMemExt); err != nil {
+        log.Printf("[Warn] memext disabled: %v", err)
+    }
+
+    backend, err := newBackend(*gpuBackend)
+    if err != nil {
+        log.Fatalf("[Fatal] Failed to init GPU backend: %v", err)
+    }
+    query.SetBackend(backend)
+    log.Printf("[OK] GPU backend: %s", backend.Name())
+    if *sampleInterval > 0 {
+        c := query.NewCollector(backend, *sampleInterval, *sampleMaxAge, *sampleWait)
+        query.SetCollector(c)
+        go c.Run()
+    }
+
+    // 自动获取 NUMA 拓扑
+    groups, err := numaTopology(backend)
+    if err != nil {
+        log.Fatalf("[Fatal] Failed to get NUMA topology: %v", err)
+    }
+
+    // 所有 NUMA 分组共享同一个调度器和作业管理器
+    sched := scheduler.NewScheduler(*leaseTTL)
+    sandbox, err := files.NewSandbox(*fileRoot)
+    if err != nil {
+        log.Fatalf("[Fatal] Failed to init file sandbox: %v", err)
+    }
+    logDir := *jobDir
+    if logDir == "" {
+        logDir = filepath.Join(sandbox.Root, ".jobs")
+    }
+    jobMgr, err := jobs.NewManager(logDir, sandbox.Root, *jobKeep)
+    if err != nil {
+        log.Fatalf("[Fatal] Failed to init job manager: %v", err)
+    }
+    var auditLogger *audit.Logger
+    if *auditLog != "" {
+        if auditLogger, err = audit.NewLogger(*auditLog, *auditMaxSize<<20, *auditKeep); err != nil {
+            log.Fatalf("[Fatal] Failed to open audit log: %v", err)
+        }
+    }
+    tlsConfig, opts, err := serverOptions(sched, auditLogger)
+    if err != nil {
+        log.Fatalf("[Fatal] Failed to init authentication: %v", err)
+    }
+    shared := &services{sched: sched, jobs: jobMgr, files: sandbox, health: health.NewServer(), audit: auditLogger, tls: tlsConfig, opts: opts}
+    rules := gpuhealth.DefaultRules()
+    if *healthRules != "" {
+        if rules, err = gpuhealth.LoadRules(*healthRules); err != nil {
+            log.Fatalf("[Fatal] Failed to load health rules: %v", err)
+        }
+    }
+    if *rogueInterval > 0 {
+        kill := *killRogue
+        if kill && (*gpuBackend == "fake" || *gpuBackend == "sim") {
+            log.Printf("[Warn] -kill-rogue ignored: %s backend reports simulated processes", *gpuBackend)
+            kill = false
+        }
+        go watchRogue(shared, *rogueInterval, kill)
+    }
+
+    // MIG实例与所属物理GPU互斥占用
+    for _, info := range query.ListGPUs() {
+        if info.Mig != nil {
+            sched.SetParent(info.Uuid, info.Mig.ParentUuid)
+        }
+    }
+
+    // 先确定所有分组的端口和GPU，再启动服务，GetNodeInfo 可返回完整拓扑
+    basePort := 50051
+    for i, group := range groups {
+        g := numaGroup{
+            node:     group.NUMANode,
+            port:     basePort + i,
+            gpus:     query.GPUUUIDsByIDs(group.GPUIDs),
+            nics:     group.NetIfs,
+            memTotal: group.MemTotal,
+        }
+        if *httpAddr != "" {
+            g.local = auth.NewLocalListener()
+        }
+        for _, uuid := range g.gpus {
+            sched.SetNUMA(uuid, g.node)
+        }
+        shared.groups = append(shared.groups, g)
+    }
+
+    shared.monitor = gpuhealth.NewMonitor(rules, sched)
+    go watchHealth(shared, shared.monitor, *healthInterval)
+
+    if *metricsAddr != "" {
+        var mg []metrics.Group
+        for _, g := range shared.groups {
+            mg = append(mg, metrics.Group{Node: g.node, Port: g.port, GPUs: g.gpus, MemTotal: g.memTotal})
+        }
+        shared.metrics = metrics.New(sched, mg)
+        go func() {
+            log.Printf("[Metrics] serving /metrics on %s", *metricsAddr)
+            if err := shared.metrics.Serve(*metricsAddr); err != nil {
+                log.Printf("[Warn] metrics listener stopped: %v", err)
+            }
+        }()
+    }
+
+    for _, g := range shared.groups {
+        go func(g numaGroup) {
+            log.Printf("[Launch] NUMA %d listening on :%d, GPUs=%v, NICs=%v", g.node, g.port, g.gpus, g.nics)
+            runGRPCServer(g, shared)
+        }(g)
+    }
+    if *httpAddr != "" {
+        go runGateway(*httpAddr, shared)
+    }
+    if *controllerAddr != "" {
+        go announce(shared)
+    }
+    if *announceAddr != "" {
+        go announceLAN(shared)
+    }
+
+    select {} // 阻塞主线程
+}
+
+// newBackend 按名称创建GPU查询后端，fake 后端使用 -fake-gpus 指定的GPU数量和 -fake-mig 指定的MIG划分，
+// sim 后端加载 -scenario 场景
+func newBackend(name string) (query.Backend, error) {
+    switch name {
+    case "fake":
+        b := query.NewFakeBackend(*fakeGPUs)
+        if *fakeMIG != "" {
+            layout, err := query.ParseMIGLayout(*fakeMIG)
+            if err != nil {
+                return nil, err
+            }
+            for i, profiles := range layout {
+                if err := b.EnableMIG(i, profiles...); err != nil {
+                    return nil, err
+                }
+            }
+        }
+        return b, nil
+    case "sim":
+        if *scenarioFile == "" {
+            return nil, fmt.Errorf("-gpu-backend=sim requires -scenario")
+        }
+        sc, err := query.LoadScenario(*scenarioFile)
+        if err != nil {
+            return nil, err
+        }
+        log.Printf("[Sim] loaded scenario %q from %s", sc.Name, *scenarioFile)
+        return query.NewSimBackend(sc), nil
+    }
+    return query.NewBackend(name)
+}
+
+// numaTopology 返回按 NUMA 节点划分的GPU和网卡
+// sim 后端的拓扑完全来自场景；fake 后端的GPU不在 sysfs 中，按后端报告的NUMA节点分组
+func numaTopology(backend query.Backend) ([]netbalance.NUMAGroup, error) {
+    switch b := backend.(type) {
+    case *query.SimBackend:
+        var groups []netbalance.NUMAGroup
+        index := 0
+        for _, n := range b.Scenario().NUMA {
+            g := netbalance.NUMAGroup{NUMANode: n.Node, GPUIDs: []int{}, NetIfs: append([]string{}, n.NICs...), MemTotal: n.MemGB << 30}
+            for j := 0; j < n.GPUs; j++ {
+                g.GPUIDs = append(g.GPUIDs, index)
+                index++
+            }
+            groups = append(groups, g)
+        }
+        sort.Slice(groups, func(i, j int) bool { return groups[i].NUMANode < groups[j].NUMANode })
+        return groups, nil
+    case *query.FakeBackend:
+        return netbalance.GroupNUMATopology(gpuNUMAMap(query.ListGPUs())), nil
+    }
+    return netbalance.MapNUMATopology()
+}
+
+// gpuNUMAMap 由GPU信息生成 map[设备序号]NUMA节点
+func gpuNUMAMap(gpus []*pb.GPUInfo) map[int]int {
+    m := make(map[int]int)
+    for _, g := range gpus {
+        m[int(g.Index)] = int(g.NumaNode)
+    }
+    return m
+}
+
+// serverOptions 根据命令行参数生成TLS配置以及认证、审计、授权拦截器，并向调度器登记租户配额
+func serverOptions(sched *scheduler.Scheduler, auditLogger *audit.Logger) (*tls.Config, []grpc.ServerOption, error) {
+    var cfg *tls.Config
+    if *tlsCert != "" {
+        var err error
+        if cfg, err = auth.ServerTLSConfig(*tlsCert, *tlsKey, *tlsCA); err != nil {
+            return nil, nil, err
+        }
+        log.Printf("[Auth] TLS enabled, client certificates required: %v", *tlsCA != "")
+    } else if *tlsCA != "" {
+        return nil, nil, fmt.Errorf("-tls-ca requires -tls-cert and -tls-key")
+    } else {
+        log.Printf("[Warn] TLS disabled, traffic is unencrypted")
+    }
+
+    var tokens *auth.TokenStore
+    if *tokenFile != "" {
+        var err error
+        if tokens, err = auth.NewTokenStore(*tokenFile); err != nil {
+            return nil, nil, err
+        }
+        log.Printf("[Auth] bearer tokens loaded from %s", *tokenFile)
+    } else if *tlsCA == "" {
+        log.Printf("[Warn] no -token-file or -tls-ca, all callers are unauthenticated")
+    }
+    a := auth.NewAuthenticator(tokens)
+    unary := []grpc.UnaryServerInterceptor{a.UnaryInterceptor()}
+    stream := []grpc.StreamServerInterceptor{a.StreamInterceptor()}
+
+    // 审计位于认证之后、授权之前，被拒绝的调用同样留有记录
+    if auditLogger != nil {
+        unary = append(unary, auditLogger.UnaryInterceptor(auditedMethods, describeRequest))
+        stream = append(stream, auditLogger.StreamInterceptor(auditedMethods, describeRequest))
+        log.Printf("[Audit] recording to %s", *auditLog)
+    }
+
+    // 授权拦截器位于认证之后，依赖认证得到的调用者身份
+    if *policyFile != "" {
+        policy, err := auth.LoadPolicy(*policyFile)
+        if err != nil {
+            return nil, nil, err
+        }
+        for name, t := range policy.Tenants {
+            sched.SetQuota(name, t.MaxGPUs)
+        }
+        z := auth.NewAuthorizer(policy, methodPermissions)
+        unary = append(unary, z.UnaryInterceptor())
+        stream = append(stream, z.StreamInterceptor())
+        log.Printf("[Auth] access policy loaded from %s (%d tenants)", *policyFile, len(policy.Tenants))
+    }
+    opts := []grpc.ServerOption{
+        grpc.ChainUnaryInterceptor(unary...),
+        grpc.ChainStreamInterceptor(stream...),
+    }
+    return cfg, opts, nil
+}
+
+// 启动一个 gRPC Server 并绑定分组内的 GPU UUIDs
+// 启用REST网关时另起一个不带TLS的 gRPC Server 服务进程内连接，拦截器与对外服务相同
+func runGRPCServer(g numaGroup, shared *services) {
+    bound := make(map[string]bool)
+    for _, uuid := range g.gpus {
+        bound[uuid] = true
+    }
+
+    lis, err := net.Listen("tcp", ":"+strconv.Itoa(g.port))
+    if err != nil {
+        log.Fatalf("[Fatal] Failed to listen on port %d: %v", g.port, err)
+    }
+
+    // 指标拦截器位于最外层，认证、授权失败的调用也被统计
+    var opts []grpc.ServerOption
+    if shared.metrics != nil {
+        opts = append(opts,
+            grpc.ChainUnaryInterceptor(shared.metrics.UnaryInterceptor(g.node, requestUUID)),
+            grpc.ChainStreamInterceptor(shared.metrics.StreamInterceptor(g.node, requestUUID)),
+        )
+    }
+    opts = append(opts, shared.opts...)
+    newServer := func(opts ...grpc.ServerOption) *grpc.Server {
+        s := grpc.NewServer(opts...)
+        pb.RegisterGPUServiceServer(s, &server{services: shared, boundGPUs: bound, numaNode: g.node})
+        healthpb.RegisterHealthServer(s, shared.health)
+        reflection.Register(s)
+        return s
+    }
+
+    if g.local != nil {
+        go newServer(opts...).Serve(g.local)
+    }
+    if shared.tls != nil {
+        opts = append(opts, grpc.Creds(credentials.NewTLS(shared.tls)))
+    }
+    grpcServer := newServer(opts...)
+
+    log.Printf("[OK] gRPC server ready on :%d", g.port)
+    if err := grpcServer.Serve(lis); err != nil {
+        log.Fatalf("[Fatal] Failed to serve gRPC: %v", err)
+    }
+}
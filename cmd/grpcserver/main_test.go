@@ -0,0 +1,72 @@
+package main
+
+import (
+    "os"
+    "path/filepath"
+    "strings"
+    "testing"
+
+    "github.com/hiicl/GPU-over-IP-AC922/pkg/query"
+)
+
+// TestNewBackend 按 -gpu-backend 选择后端，fake 和 sim 后端使用对应的参数
+func TestNewBackend(t *testing.T) {
+    defer func(gpus int, mig, scenario string) {
+        *fakeGPUs, *fakeMIG, *scenarioFile = gpus, mig, scenario
+    }(*fakeGPUs, *fakeMIG, *scenarioFile)
+
+    scenario := filepath.Join(t.TempDir(), "scenario.yaml")
+    yaml := "name: test\nnuma:\n  - node: 0\n    gpus: 1\n  - node: 8\n    gpus: 2\n"
+    if err := os.WriteFile(scenario, []byte(yaml), 0644); err != nil {
+        t.Fatal(err)
+    }
+
+    tests := []struct {
+        name     string
+        backend  string
+        gpus     int
+        mig      string
+        scenario string
+        wantName string
+        wantGPUs int
+        err      string // 期望错误信息包含的内容，为空表示应成功
+    }{
+        {name: "fake", backend: "fake", gpus: 2, wantName: "fake", wantGPUs: 2},
+        {name: "fake with MIG", backend: "fake", gpus: 2, mig: "1:3g.20gb,3g.20gb", wantName: "fake", wantGPUs: 4},
+        {name: "fake with bad MIG", backend: "fake", gpus: 2, mig: "1:8g.80gb", err: "unknown MIG profile"},
+        {name: "fake MIG on missing GPU", backend: "fake", gpus: 1, mig: "3:7g.40gb", err: "fake GPU 3 not found"},
+        {name: "sim", backend: "sim", scenario: scenario, wantName: "sim", wantGPUs: 3},
+        {name: "sim without scenario", backend: "sim", err: "requires -scenario"},
+        {name: "sim with missing scenario", backend: "sim", scenario: scenario + ".missing", err: "read scenario file"},
+        {name: "nvidia-smi", backend: "nvidia-smi", wantName: "nvidia-smi"},
+        {name: "unknown", backend: "cuda", err: `unknown GPU backend "cuda"`},
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            *fakeGPUs, *fakeMIG, *scenarioFile = tt.gpus, tt.mig, tt.scenario
+            b, err := newBackend(tt.backend)
+            if tt.err != "" {
+                if err == nil || !strings.Contains(err.Error(), tt.err) {
+                    t.Fatalf("newBackend(%s) error = %v, want %q", tt.backend, err, tt.err)
+                }
+                return
+            }
+            if err != nil {
+                t.Fatalf("newBackend(%s): %v", tt.backend, err)
+            }
+            if b.Name() != tt.wantName {
+                t.Errorf("newBackend(%s).Name() = %s, want %s", tt.backend, b.Name(), tt.wantName)
+            }
+            if tt.wantGPUs == 0 {
+                return // 真实后端需要GPU，不查询
+            }
+            gpus, err := b.ListGPUs()
+            if err != nil || len(gpus) != tt.wantGPUs {
+                t.Errorf("ListGPUs = %d GPUs, %v; want %d", len(gpus), err, tt.wantGPUs)
+            }
+            if tt.mig != "" && gpus[2].Uuid != query.FakeMIGUUID(1, 1) {
+                t.Errorf("GPU 2 = %s, want the first MIG instance of GPU 1", gpus[2].Uuid)
+            }
+        })
+    }
+}
@@ -0,0 +1,36 @@
+package main
+
+import (
+    "context"
+    "os"
+    "runtime"
+
+    pb "github.com/hiicl/GPU-over-IP-AC922/proto"
+
+    "github.com/hiicl/GPU-over-IP-AC922/modules/memext"
+)
+
+// nodeinfo.go 实现 GetNodeInfo 节点清单查询
+
+// GetNodeInfo 返回节点的主机信息、所有 NUMA 分组（GPU、网卡、内存、端口）和 memext 内存池状态
+// 客户端可据此为目标GPU选择正确的端口，无需读取主机上的拓扑文件
+func (s *server) GetNodeInfo(ctx context.Context, _ *pb.Void) (*pb.NodeInfo, error) {
+    hostname, _ := os.Hostname()
+    info := &pb.NodeInfo{
+        Hostname:        hostname,
+        Arch:            runtime.GOARCH,
+        ServingNumaNode: int32(s.numaNode),
+        MemextPoolSize:  int64(len(memext.Pool())),
+        MemextNumaNode:  int32(memext.NumaNode),
+    }
+    for _, g := range s.groups {
+        info.Groups = append(info.Groups, &pb.NUMAGroupInfo{
+            NumaNode: int32(g.node),
+            Port:     int32(g.port),
+            Gpus:     g.gpus,
+            Nics:     g.nics,
+            MemTotal: int64(g.memTotal),
+        })
+    }
+    return info, nil
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+    "context"
+    "errors"
+
+    "google.golang.org/grpc/codes"
+    "google.golang.org/grpc/status"
+
+    "github.com/hiicl/GPU-over-IP-AC922/pkg/auth"
+    "github.com/hiicl/GPU-over-IP-AC922/pkg/scheduler"
+)
+
+// policy.go 定义各RPC所需的权限，新增RPC时需在此登记，未登记的方法只允许管理员调用
+
+// methodPermissions key: gRPC完整方法名，value: 所需权限
+var methodPermissions = map[string]auth.Permission{
+    "/grpc.reflection.v1.ServerReflection/ServerReflectionInfo":      auth.PermList,
+    "/grpc.reflection.v1alpha.ServerReflection/ServerReflectionInfo": auth.PermList,
+
+    "/" + gpuServiceName + "/GetServerInfo":    auth.PermList,
+    "/" + gpuServiceName + "/GetNodeInfo":      auth.PermList,
+    "/" + gpuServiceName + "/ListGPUs":         auth.PermList,
+    "/" + gpuServiceName + "/GetGPUStatus":     auth.PermList,
+    "/" + gpuServiceName + "/GetGPUDetails":    auth.PermList,
+    "/" + gpuServiceName + "/ListGPUProcesses": auth.PermList,
+    "/" + gpuServiceName + "/WatchGPUStatus":   auth.PermList,
+    "/" + gpuServiceName + "/GetJob":           auth.PermList,
+    "/" + gpuServiceName + "/ListJobs":         auth.PermList,
+    "/" + gpuServiceName + "/ListLeases":       auth.PermList,
+    "/" + gpuServiceName + "/StatFile":         auth.PermList,
+
+    "/" + gpuServiceName + "/AcquireGPU":  auth.PermAcquire,
+    "/" + gpuServiceName + "/AcquireGPUs": auth.PermAcquire,
+    "/" + gpuServiceName + "/RenewLease":  auth.PermAcquire,
+    "/" + gpuServiceName + "/ReleaseGPU":  auth.PermAcquire,
+
+    "/" + gpuServiceName + "/RunCommand":       auth.PermRun,
+    "/" + gpuServiceName + "/RunCommandStream": auth.PermRun,
+    "/" + gpuServiceName + "/Exec":             auth.PermRun,
+    "/" + gpuServiceName + "/UploadFile":       auth.PermRun,
+    "/" + gpuServiceName + "/DownloadFile":     auth.PermRun,
+    "/" + gpuServiceName + "/SubmitJob":        auth.PermRun,
+    "/" + gpuServiceName + "/CancelJob":        auth.PermRun, // 取消他人作业还需要 admin 权限
+
+    "/" + gpuServiceName + "/UncordonGPU": auth.PermAdmin,
+}
+
+// callerTenant 返回调用者所属租户，未启用访问控制或不属于任何租户时返回空字符串
+func callerTenant(ctx context.Context) string {
+    if id := auth.FromContext(ctx); id != nil {
+        return id.Tenant
+    }
+    return ""
+}
+
+// quotaError 将超出租户配额的错误转换为 ResourceExhausted，其他错误返回nil
+func quotaError(err error) error {
+    if errors.Is(err, scheduler.ErrQuotaExceeded) {
+        return status.Error(codes.ResourceExhausted, err.Error())
+    }
+    return nil
+}
@@ -0,0 +1,187 @@
+package main
+
+import (
+    "context"
+    "errors"
+    "fmt"
+    "log"
+    "sort"
+    "syscall"
+    "time"
+
+    "google.golang.org/grpc/codes"
+    "google.golang.org/grpc/status"
+
+    pb "github.com/hiicl/GPU-over-IP-AC922/proto"
+
+    "github.com/hiicl/GPU-over-IP-AC922/pkg/gpu"
+    "github.com/hiicl/GPU-over-IP-AC922/pkg/jobs"
+    "github.com/hiicl/GPU-over-IP-AC922/pkg/query"
+)
+
+// processes.go 实现 ListGPUProcesses：列出GPU上的计算进程，并归属到启动它的作业或命令
+// 归属方法：沿 /proc 中的父进程链（以及进程组）向上查找，直到遇到本服务启动的作业或命令
+// 以下进程判定为失控进程（rogue）：
+//   - 不是本服务启动的，如直接登录节点运行的程序
+//   - 启动它的作业租约已失效，或所在GPU当前没有租约
+//   - 运行在启动时指定的GPU以外（在MIG实例上启动的命令，后端报告的是其所属物理GPU）
+// 服务端运行在容器中时需与宿主机共享PID命名空间，否则后端报告的进程ID无法对应到进程树
+
+const (
+    sourceJob       = "job" // 异步作业
+    maxProcessDepth = 64    // 向上查找父进程的最大层数
+)
+
+// processOrigin 本服务启动的作业或命令
+type processOrigin struct {
+    source  string // 来源：job、run 或 exec
+    uuid    string // 启动时指定的GPU
+    jobID   string // 作业ID（仅作业）
+    owner   string // 作业提交者（仅作业）
+    leaseID string // 作业使用的租约（仅作业）
+}
+
+// processOrigins 返回本服务启动的、仍在运行的作业和命令，key: 进程ID
+func (s *services) processOrigins() map[int]processOrigin {
+    origins := make(map[int]processOrigin)
+    for pid, c := range gpu.SpawnedCommands() {
+        origins[pid] = processOrigin{source: c.Source, uuid: c.UUID}
+    }
+    for _, j := range s.jobs.List(jobs.Filter{States: []jobs.State{jobs.Running}}) {
+        origins[j.PID()] = processOrigin{source: sourceJob, uuid: j.UUID, jobID: j.ID, owner: j.Owner, leaseID: j.LeaseID}
+    }
+    return origins
+}
+
+// findOrigin 沿父进程链查找启动该进程的作业或命令
+// 作业在独立进程组中运行，其子进程即使被重新挂到 init 下，仍可通过进程组找到
+func findOrigin(pid int, origins map[int]processOrigin) (processOrigin, bool) {
+    for depth := 0; pid > 1 && depth < maxProcessDepth; depth++ {
+        if o, ok := origins[pid]; ok {
+            return o, true
+        }
+        ppid, pgid, err := gpu.ProcParent(pid)
+        if err != nil {
+            break
+        }
+        if o, ok := origins[pgid]; ok && o.source == sourceJob {
+            return o, true
+        }
+        pid = ppid
+    }
+    return processOrigin{}, false
+}
+
+// gpuProcesses 列出GPU上的计算进程并完成归属，只返回 keep 为true的GPU上的进程
+func (s *services) gpuProcesses(keep func(uuid string) bool) ([]*pb.GPUProcess, error) {
+    procs, err := query.ListProcesses()
+    if err != nil {
+        return nil, err
+    }
+    origins := s.processOrigins()
+    holders := make(map[string]string) // key: GPU UUID，value: 租约持有者
+    for _, l := range s.sched.Leases() {
+        holders[l.UUID] = l.Owner
+    }
+    parents := make(map[string]string) // key: MIG实例UUID，value: 物理GPU UUID
+    for _, g := range query.ListGPUs() {
+        if g.Mig != nil {
+            parents[g.Uuid] = g.Mig.ParentUuid
+        }
+    }
+
+    var result []*pb.GPUProcess
+    for _, p := range procs {
+        if !keep(p.UUID) {
+            continue
+        }
+        gp := &pb.GPUProcess{Pid: int32(p.PID), Name: p.Name, Type: "C", UsedMemory: p.UsedMemory, Uuid: p.UUID}
+        o, ok := findOrigin(p.PID, origins)
+        if ok {
+            gp.Source, gp.JobId = o.source, o.jobID
+        }
+        holder, leased := holders[p.UUID]
+        if ok && parents[o.uuid] == p.UUID {
+            holder, leased = holders[o.uuid]
+        }
+        switch {
+        case !ok:
+            gp.Owner = holder
+            gp.RogueReason = "not started by this server"
+        case o.uuid != p.UUID && parents[o.uuid] != p.UUID:
+            gp.Owner = o.owner
+            gp.RogueReason = fmt.Sprintf("started for GPU %s", o.uuid)
+        case o.source == sourceJob:
+            gp.Owner = o.owner
+            if err := s.sched.Validate(o.uuid, o.leaseID); err != nil {
+                gp.RogueReason = fmt.Sprintf("job lease: %v", err)
+            }
+        case !leased:
+            gp.RogueReason = "GPU has no active lease"
+        default:
+            gp.Owner = holder
+        }
+        gp.Rogue = gp.RogueReason != ""
+        result = append(result, gp)
+    }
+    sort.Slice(result, func(i, k int) bool {
+        if result[i].Uuid != result[k].Uuid {
+            return result[i].Uuid < result[k].Uuid
+        }
+        return result[i].Pid < result[k].Pid
+    })
+    return result, nil
+}
+
+// ListGPUProcesses 列出本NUMA组GPU上的计算进程，uuid 为空时返回全部绑定GPU
+func (s *server) ListGPUProcesses(ctx context.Context, req *pb.GPURequest) (*pb.GPUProcessList, error) {
+    if req.Uuid != "" && !s.boundGPUs[req.Uuid] {
+        return nil, status.Errorf(codes.NotFound, "GPU %s not bound to this NUMA group", req.Uuid)
+    }
+    procs, err := s.gpuProcesses(func(uuid string) bool {
+        return s.boundGPUs[uuid] && (req.Uuid == "" || uuid == req.Uuid)
+    })
+    if errors.Is(err, query.ErrUnsupported) {
+        return nil, status.Errorf(codes.Unimplemented, "GPU processes: %v", err)
+    }
+    if err != nil {
+        return nil, status.Errorf(codes.Unavailable, "GPU processes: %v", err)
+    }
+    return &pb.GPUProcessList{Processes: procs}, nil
+}
+
+// watchRogue 定期检查所有GPU上的失控进程，首次发现时记录日志
+// kill 为 true 时终止连续两次检查都判定为失控的进程，避免误杀刚启动、尚未登记的命令
+func watchRogue(s *services, interval time.Duration, kill bool) {
+    var seen map[int32]bool // 上次检查判定为失控的进程
+    for {
+        time.Sleep(interval)
+        procs, err := s.gpuProcesses(func(string) bool { return true })
+        if err != nil {
+            if errors.Is(err, query.ErrUnsupported) {
+                log.Printf("[Rogue] GPU backend cannot list processes, rogue process check disabled")
+                return
+            }
+            continue // 后端故障由健康检查记录
+        }
+        current := make(map[int32]bool)
+        for _, p := range procs {
+            if !p.Rogue || p.Pid <= 0 {
+                continue
+            }
+            current[p.Pid] = true
+            if !seen[p.Pid] {
+                log.Printf("[Rogue] pid %d (%s) on GPU %s using %d MiB: %s", p.Pid, p.Name, p.Uuid, p.UsedMemory, p.RogueReason)
+                continue
+            }
+            if kill {
+                if err := syscall.Kill(int(p.Pid), syscall.SIGKILL); err != nil {
+                    log.Printf("[Rogue] kill pid %d on GPU %s failed: %v", p.Pid, p.Uuid, err)
+                } else {
+                    log.Printf("[Rogue] killed pid %d (%s) on GPU %s", p.Pid, p.Name, p.Uuid)
+                }
+            }
+        }
+        seen = current
+    }
+}
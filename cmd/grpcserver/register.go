@@ -0,0 +1,80 @@
+package main
+
+import (
+    "context"
+    "log"
+    "net"
+    "os"
+
+    "github.com/hiicl/GPU-over-IP-AC922/modules/netbalance"
+    "github.com/hiicl/GPU-over-IP-AC922/pkg/auth"
+    "github.com/hiicl/GPU-over-IP-AC922/pkg/query"
+    "github.com/hiicl/GPU-over-IP-AC922/pkg/registry"
+)
+
+// register.go 向控制器注册本节点并定期发送心跳，或在局域网内组播通告本节点
+
+// nodeInfo 返回生成本节点信息的函数，每次调用携带各GPU最新的利用率和显存占用
+func nodeInfo(shared *services, ip string) func() *netbalance.NodeInfo {
+    hostname, _ := os.Hostname()
+    var ports []int
+    var groups []netbalance.GroupInfo
+    var nics []string
+    for _, g := range shared.groups {
+        ports = append(ports, g.port)
+        groups = append(groups, netbalance.GroupInfo{NUMANode: g.node, Port: g.port, GPUs: g.gpus, NetIfs: g.nics})
+        nics = append(nics, g.nics...)
+    }
+    // 网卡速度启动后不再变化，只计算一次（ethtool 回退较慢）
+    bandwidth := netbalance.NetBandwidth(nics)
+
+    return func() *netbalance.NodeInfo {
+        node := &netbalance.NodeInfo{IP: ip, Hostname: hostname, Ports: ports, Groups: groups, NetBandwidth: bandwidth}
+        status := query.ListGPUStatus()
+        for _, g := range shared.groups {
+            for _, uuid := range g.gpus {
+                st := status[uuid]
+                node.GPUs = append(node.GPUs, netbalance.GPUInfo{ID: uuid, Utilization: int(st.Utilization), MemoryUsed: int(st.UsedMemory)})
+            }
+        }
+        return node
+    }
+}
+
+// advertisedIP 返回对外通告的本机IP：优先使用 -advertise-ip，否则为发往 dest 时使用的本机地址
+func advertisedIP(dest string) string {
+    if *advertiseIP != "" {
+        return *advertiseIP
+    }
+    conn, err := net.Dial("udp", dest) // UDP 不发送数据，只选择路由
+    if err != nil {
+        log.Fatalf("[Fatal] Failed to detect advertise IP, set -advertise-ip: %v", err)
+    }
+    defer conn.Close()
+    return conn.LocalAddr().(*net.UDPAddr).IP.String()
+}
+
+// announce 注册到控制器并持续发送心跳
+func announce(shared *services) {
+    client, err := registry.NewClient(*controllerAddr, auth.ClientOptions{
+        CAFile:    *controllerCA,
+        CertFile:  *controllerCert,
+        KeyFile:   *controllerKey,
+        TokenFile: *controllerToken,
+    })
+    if err != nil {
+        log.Fatalf("[Fatal] Failed to init controller client: %v", err)
+    }
+    ip := advertisedIP(*controllerAddr)
+    log.Printf("[Controller] announcing %s to %s every %s", ip, *controllerAddr, *heartbeatInterval)
+    client.Announce(context.Background(), nodeInfo(shared, ip), *heartbeatInterval)
+}
+
+// announceLAN 向组播或广播地址定期通告本节点，供无控制器的局域网客户端发现
+func announceLAN(shared *services) {
+    ip := advertisedIP(*announceAddr)
+    log.Printf("[Discovery] announcing %s to %s every %s", ip, *announceAddr, *heartbeatInterval)
+    if err := netbalance.Announce(context.Background(), *announceAddr, nodeInfo(shared, ip), *heartbeatInterval); err != nil {
+        log.Printf("[Warn] LAN announcements stopped: %v", err)
+    }
+}
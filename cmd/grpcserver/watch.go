@@ -0,0 +1,91 @@
+package main
+
+import (
+    "fmt"
+    "sort"
+    "time"
+
+    pb "github.com/hiicl/GPU-over-IP-AC922/proto"
+
+    "github.com/hiicl/GPU-over-IP-AC922/pkg/query"
+)
+
+// watch.go 实现 WatchGPUStatus 流式状态推送
+
+const (
+    defaultWatchInterval = time.Second            // 客户端未指定间隔时的采样间隔
+    minWatchInterval     = 200 * time.Millisecond // 允许的最小采样间隔，避免频繁查询GPU后端
+)
+
+// watchInterval 根据请求计算采样间隔
+func watchInterval(req *pb.GPURequest) time.Duration {
+    interval := time.Duration(req.IntervalMs) * time.Millisecond
+    if interval <= 0 {
+        return defaultWatchInterval
+    }
+    if interval < minWatchInterval {
+        return minWatchInterval
+    }
+    return interval
+}
+
+// watchTargets 返回需要推送状态的GPU列表（按UUID排序）
+// uuid 为空时返回本NUMA组绑定的全部GPU
+func (s *server) watchTargets(uuid string) []string {
+    if uuid != "" {
+        return []string{uuid}
+    }
+    var targets []string
+    for u := range s.boundGPUs {
+        targets = append(targets, u)
+    }
+    sort.Strings(targets)
+    return targets
+}
+
+// WatchGPUStatus 按间隔采样GPU状态并推送给客户端
+// 每次采样读取一次遥测快照；onChange=true 时只推送与上次不同的状态
+// 客户端断开后结束
+func (s *server) WatchGPUStatus(req *pb.GPURequest, stream pb.GPUService_WatchGPUStatusServer) error {
+    if req.Uuid != "" && !s.boundGPUs[req.Uuid] {
+        return fmt.Errorf("GPU %s not bound to this NUMA group", req.Uuid)
+    }
+    targets := s.watchTargets(req.Uuid)
+
+    ticker := time.NewTicker(watchInterval(req))
+    defer ticker.Stop()
+
+    last := make(map[string]query.GPUStatus) // 上次推送的状态
+    for {
+        smp := query.Latest()
+        ts, age := sampleTime(smp)
+        stale := smp.Stale()
+        for _, uuid := range targets {
+            st, ok := smp.Status[uuid]
+            if !ok {
+                continue // 本次采样未获取到该GPU
+            }
+            if prev, seen := last[uuid]; req.OnChange && seen && prev == st {
+                continue
+            }
+            last[uuid] = st
+            err := stream.Send(&pb.GPUStatus{
+                Uuid:        uuid,
+                UsedMemory:  st.UsedMemory,
+                Utilization: st.Utilization,
+                Timestamp:   ts,
+                AgeMs:       age,
+                Stale:       stale,
+            })
+            if err != nil {
+                return err
+            }
+        }
+
+        select {
+        case <-stream.Context().Done():
+            return nil
+        case <-ticker.C:
+        }
+    }
+}
@@ -1,45 +1,70 @@
-package netbalance
-
-import (
-    "fmt"
-    "sort"
-    "strings"
-    "sync"
-    "time"
-)
-
-// netbalance 包提供网络负载均衡功能
-// balance.go 文件实现节点选择和目标地址生成功能
-
-// GetBestDialTarget 获取最佳连接目标节点列表
-// 功能：筛选最近活跃的节点，按网络带宽降序排序，生成逗号分隔的目标地址列表
-// 参数：port - 目标节点的服务端口号
-// 返回值：逗号分隔的目标地址字符串（格式：IP1:PORT,IP2:PORT,...）
-func GetBestDialTarget(port string) string {
-    lock.Lock()         // 加锁确保并发安全
-    defer lock.Unlock() // 函数返回时解锁
-
-    now := time.Now().Unix() // 获取当前时间戳（秒）
-    var active []NodeInfo    // 存储活跃节点列表
-
-    // 遍历所有发现的节点，筛选最近15秒内活跃的节点
-    for _, node := range discovered {
-        if now-node.LastSeen <= 15 {
-            active = append(active, node)
-        }
-    }
-
-    // 按网络带宽降序排序（带宽大的节点优先）
-    sort.SliceStable(active, func(i, j int) bool {
-        return active[i].NetBandwidth > active[j].NetBandwidth
-    })
-
-    var targets []string
-    // 将节点信息转换为IP:PORT格式
-    for _, node := range active {
-        targets = append(targets, fmt.Sprintf("%s:%s", node.IP, port))
-    }
-
-    // 返回逗号分隔的目标地址列表
-    return strings.Join(targets, ",")
-}
+package netbalance
+
+import (
+    "net"
+    "sort"
+    "strconv"
+    "strings"
+)
+
+// netbalance 包提供网络负载均衡功能
+// balance.go 文件实现节点选择和目标地址生成功能
+
+// GetBestDialTarget 获取最佳连接目标节点列表
+// 功能：取注册表中的节点（离线节点由维护注册表的一方按其超时设置通过 ExpireNodes 移除），
+// 按网络带宽降序排序，为每个节点注册的各NUMA分组端口生成目标地址
+// 参数：defaultPort - 节点未注册端口时使用的服务端口号
+// 返回值：逗号分隔的目标地址字符串（格式：IP1:PORT1,IP1:PORT2,IP2:PORT,...），供展示使用；
+// 连接时使用 DialTargets 逐个尝试，不能作为单个地址拨号
+func GetBestDialTarget(defaultPort string) string {
+    return strings.Join(DialTargets(defaultPort), ",")
+}
+
+// DialTargets 按优先级返回目标地址列表：节点按网络带宽降序（带宽相同时按IP），
+// 每个节点依次列出各NUMA分组端口；节点未注册端口时使用 defaultPort
+// 每个端口只服务本分组绑定的GPU，操作指定GPU时应使用 GPUTarget 返回的地址
+func DialTargets(defaultPort string) []string {
+    var targets []string
+    for _, node := range activeNodes() {
+        if len(node.Ports) == 0 {
+            targets = append(targets, net.JoinHostPort(node.IP, defaultPort))
+            continue
+        }
+        for _, port := range node.Ports {
+            targets = append(targets, net.JoinHostPort(node.IP, strconv.Itoa(port)))
+        }
+    }
+    return targets
+}
+
+// GPUTarget 按节点注册的NUMA分组查找服务该GPU的地址（IP:PORT），未找到时返回空字符串
+func GPUTarget(uuid string) string {
+    for _, node := range activeNodes() {
+        for _, g := range node.Groups {
+            for _, gpu := range g.GPUs {
+                if gpu == uuid {
+                    return net.JoinHostPort(node.IP, strconv.Itoa(g.Port))
+                }
+            }
+        }
+    }
+    return ""
+}
+
+// activeNodes 返回注册表中的节点，按网络带宽降序排序（带宽大的节点优先），带宽相同时按IP排序保证结果稳定
+func activeNodes() []*NodeInfo {
+    lock.Lock()         // 加锁确保并发安全
+    defer lock.Unlock() // 函数返回时解锁
+
+    active := make([]*NodeInfo, 0, len(discovered))
+    for _, node := range discovered {
+        active = append(active, node)
+    }
+    sort.Slice(active, func(i, j int) bool {
+        if active[i].NetBandwidth != active[j].NetBandwidth {
+            return active[i].NetBandwidth > active[j].NetBandwidth
+        }
+        return active[i].IP < active[j].IP
+    })
+    return active
+}
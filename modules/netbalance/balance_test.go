@@ -0,0 +1,47 @@
+package netbalance
+
+import (
+    "reflect"
+    "testing"
+)
+
+// TestDialTargets 节点按带宽排序并列出各分组端口；GPU映射到服务其分组的单个端口
+func TestDialTargets(t *testing.T) {
+    nodes := []*NodeInfo{
+        {IP: "10.0.0.2", Ports: []int{50051, 50052}, NetBandwidth: 100000, Groups: []GroupInfo{
+            {NUMANode: 0, Port: 50051, GPUs: []string{"GPU-a", "GPU-b"}},
+            {NUMANode: 8, Port: 50052, GPUs: []string{"GPU-c", "GPU-d"}},
+        }},
+        {IP: "10.0.0.1", NetBandwidth: 25000},
+        {IP: "fd00::3", Ports: []int{50051}, NetBandwidth: 100000, Groups: []GroupInfo{
+            {NUMANode: 0, Port: 50051, GPUs: []string{"GPU-e"}},
+        }},
+    }
+    for _, n := range nodes {
+        UpdateNode(n)
+    }
+    defer func() {
+        lock.Lock()
+        defer lock.Unlock()
+        for _, n := range nodes {
+            delete(discovered, n.IP)
+        }
+    }()
+
+    want := []string{"10.0.0.2:50051", "10.0.0.2:50052", "[fd00::3]:50051", "10.0.0.1:50051"}
+    if got := DialTargets("50051"); !reflect.DeepEqual(got, want) {
+        t.Errorf("DialTargets = %v, want %v", got, want)
+    }
+
+    tests := map[string]string{
+        "GPU-a":       "10.0.0.2:50051",
+        "GPU-d":       "10.0.0.2:50052",
+        "GPU-e":       "[fd00::3]:50051",
+        "GPU-unknown": "",
+    }
+    for uuid, want := range tests {
+        if got := GPUTarget(uuid); got != want {
+            t.Errorf("GPUTarget(%s) = %q, want %q", uuid, got, want)
+        }
+    }
+}
@@ -0,0 +1,117 @@
+package netbalance
+
+import (
+    "context"
+    "fmt"
+    "net"
+    "time"
+)
+
+// multicast.go 实现无控制器的局域网节点发现
+// 节点定期向组播（或广播）地址发送 NodeInfo.ToJSON 生成的JSON，
+// 监听方收到后写入注册表，超过TTL未再收到通告的节点被移除
+
+// DefaultAnnounceAddr 默认通告地址（组织内组播地址段）
+const DefaultAnnounceAddr = "239.192.77.22:50050"
+
+// maxAnnounceSize 单条通告的最大长度（UDP 数据报上限）
+const maxAnnounceSize = 65507
+
+// Announce 每隔 interval 向 addr 发送一次节点通告，直到 ctx 结束
+// addr 可以是组播地址或广播地址（如 255.255.255.255:50050）；
+// 通告从节点IP所在的网卡发出，node 每次调用生成最新的节点信息
+func Announce(ctx context.Context, addr string, node func() *NodeInfo, interval time.Duration) error {
+    raddr, err := net.ResolveUDPAddr("udp4", addr)
+    if err != nil {
+        return err
+    }
+    var laddr *net.UDPAddr
+    if ip := net.ParseIP(node().IP); ip != nil {
+        laddr = &net.UDPAddr{IP: ip}
+    }
+    conn, err := net.DialUDP("udp4", laddr, raddr)
+    if err != nil {
+        return err
+    }
+    defer conn.Close()
+
+    ticker := time.NewTicker(interval)
+    defer ticker.Stop()
+    failing := false
+    for {
+        data, err := node().ToJSON()
+        if err == nil {
+            _, err = conn.Write([]byte(data))
+        }
+        // 连续失败只记录第一次
+        if err != nil && !failing {
+            fmt.Printf("[netbalance] announce to %s failed: %v\n", addr, err)
+        }
+        failing = err != nil
+
+        select {
+        case <-ctx.Done():
+            return nil
+        case <-ticker.C:
+        }
+    }
+}
+
+// Listen 监听 addr 上的节点通告并写入注册表，超过 ttl 未收到通告的节点被移除，直到 ctx 结束
+// addr 为组播地址时加入组播组（ifi 为nil时使用系统默认网卡），否则监听该端口上的广播
+// 通告未携带IP时使用发送方地址
+func Listen(ctx context.Context, addr string, ifi *net.Interface, ttl time.Duration) error {
+    gaddr, err := net.ResolveUDPAddr("udp4", addr)
+    if err != nil {
+        return err
+    }
+    var conn *net.UDPConn
+    if gaddr.IP.IsMulticast() {
+        conn, err = net.ListenMulticastUDP("udp4", ifi, gaddr)
+    } else {
+        conn, err = net.ListenUDP("udp4", &net.UDPAddr{Port: gaddr.Port})
+    }
+    if err != nil {
+        return err
+    }
+    defer conn.Close()
+
+    // ctx 结束时关闭连接以中断读取，同时定期移除超时节点
+    go func() {
+        ticker := time.NewTicker(time.Second)
+        defer ticker.Stop()
+        for {
+            select {
+            case <-ctx.Done():
+                conn.Close()
+                return
+            case <-ticker.C:
+                for _, ip := range ExpireNodes(ttl) {
+                    fmt.Printf("[netbalance] node %s expired, no announcement for %s\n", ip, ttl)
+                }
+            }
+        }
+    }()
+
+    buf := make([]byte, maxAnnounceSize)
+    for {
+        n, src, err := conn.ReadFromUDP(buf)
+        if err != nil {
+            if ctx.Err() != nil {
+                return nil
+            }
+            return err
+        }
+        node, err := FromJSON(string(buf[:n]))
+        if err != nil {
+            continue // 忽略同一端口上的无关数据报
+        }
+        if node.IP == "" {
+            node.IP = src.IP.String()
+        }
+        node.LastSeen = time.Now().Unix() // 以接收方时钟为准
+        if UpdateNode(node) {
+            fmt.Printf("[netbalance] discovered node %s (%s) from %s: %d GPUs, ports %v\n", node.IP, node.Hostname, src, len(node.GPUs), node.Ports)
+        }
+    }
+}
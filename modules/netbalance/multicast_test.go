@@ -0,0 +1,117 @@
+package netbalance
+
+import (
+    "context"
+    "net"
+    "reflect"
+    "testing"
+    "time"
+)
+
+// waitFor 轮询 cond 直到其返回 true 或超时
+func waitFor(t *testing.T, timeout time.Duration, cond func() bool) bool {
+    t.Helper()
+    deadline := time.Now().Add(timeout)
+    for time.Now().Before(deadline) {
+        if cond() {
+            return true
+        }
+        time.Sleep(50 * time.Millisecond)
+    }
+    return cond()
+}
+
+// loopbackGroup 返回回环网卡和一个回环网卡上可用的组播地址（239.255.x.x）
+// 回环网卡不存在或无法收发组播（如容器内禁用组播）时跳过测试
+func loopbackGroup(t *testing.T) (*net.Interface, string) {
+    t.Helper()
+    ifs, err := net.Interfaces()
+    if err != nil {
+        t.Skipf("list interfaces: %v", err)
+    }
+    var lo *net.Interface
+    for i := range ifs {
+        if ifs[i].Flags&net.FlagLoopback != 0 && ifs[i].Flags&net.FlagUp != 0 {
+            lo = &ifs[i]
+            break
+        }
+    }
+    if lo == nil {
+        t.Skip("no loopback interface")
+    }
+
+    // 先用一个探测数据报确认组播可以在回环网卡上收发
+    group := &net.UDPAddr{IP: net.IPv4(239, 255, 77, byte(1+time.Now().UnixNano()%250))}
+    conn, err := net.ListenMulticastUDP("udp4", lo, group)
+    if err != nil {
+        t.Skipf("multicast unavailable on %s: %v", lo.Name, err)
+    }
+    defer conn.Close()
+    group.Port = conn.LocalAddr().(*net.UDPAddr).Port
+    sender, err := net.DialUDP("udp4", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)}, group)
+    if err != nil {
+        t.Skipf("multicast unavailable on %s: %v", lo.Name, err)
+    }
+    defer sender.Close()
+    if _, err := sender.Write([]byte("probe")); err != nil {
+        t.Skipf("multicast unavailable on %s: %v", lo.Name, err)
+    }
+    conn.SetReadDeadline(time.Now().Add(time.Second))
+    if _, _, err := conn.ReadFromUDP(make([]byte, 16)); err != nil {
+        t.Skipf("multicast not delivered on %s: %v", lo.Name, err)
+    }
+    return lo, group.String()
+}
+
+// TestAnnounceListenLoopback 通过回环网卡上的组播组发送通告：监听方加入组播组并写入注册表，
+// 停止通告后节点在TTL后被移除
+func TestAnnounceListenLoopback(t *testing.T) {
+    const ip = "127.0.0.1"
+    lo, addr := loopbackGroup(t)
+    ttl := time.Second
+
+    listenCtx, stopListen := context.WithCancel(context.Background())
+    defer stopListen()
+    listenErr := make(chan error, 1)
+    go func() { listenErr <- Listen(listenCtx, addr, lo, ttl) }()
+
+    announceCtx, stopAnnounce := context.WithCancel(context.Background())
+    defer stopAnnounce()
+    node := func() *NodeInfo {
+        return &NodeInfo{IP: ip, Hostname: "loopback-node", Ports: []int{50051, 50052}, NetBandwidth: 100000, Groups: []GroupInfo{
+            {NUMANode: 0, Port: 50051, GPUs: []string{"GPU-0"}},
+            {NUMANode: 8, Port: 50052, GPUs: []string{"GPU-1"}},
+        }}
+    }
+    go Announce(announceCtx, addr, node, 100*time.Millisecond)
+
+    if !waitFor(t, 5*time.Second, func() bool { return LookupNode(ip) != nil }) {
+        t.Fatalf("node %s not discovered on %s", ip, addr)
+    }
+    got := LookupNode(ip)
+    if got.Hostname != "loopback-node" || len(got.Ports) != 2 || got.LastSeen == 0 {
+        t.Errorf("LookupNode(%s) = %+v, want hostname, ports and last-seen filled", ip, got)
+    }
+    // 客户端按通告逐个拨号，操作指定GPU时只连接服务该GPU的端口
+    if want := []string{"127.0.0.1:50051", "127.0.0.1:50052"}; !reflect.DeepEqual(DialTargets("50051"), want) {
+        t.Errorf("DialTargets = %v, want %v", DialTargets("50051"), want)
+    }
+    if want := "127.0.0.1:50052"; GPUTarget("GPU-1") != want {
+        t.Errorf("GPUTarget(GPU-1) = %q, want %q", GPUTarget("GPU-1"), want)
+    }
+
+    stopAnnounce()
+    if !waitFor(t, ttl+5*time.Second, func() bool { return LookupNode(ip) == nil }) {
+        t.Fatalf("node %s still registered %s after announcements stopped", ip, ttl+5*time.Second)
+    }
+
+    stopListen()
+    select {
+    case err := <-listenErr:
+        if err != nil {
+            t.Errorf("Listen returned %v", err)
+        }
+    case <-time.After(5 * time.Second):
+        t.Error("Listen did not return after its context was canceled")
+    }
+}
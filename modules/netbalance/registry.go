@@ -0,0 +1,68 @@
+package netbalance
+
+import (
+    "sort"
+    "sync"
+    "time"
+)
+
+// registry.go 维护已发现节点的注册表
+// 控制器根据节点注册和心跳更新注册表；客户端从控制器取得注册表或监听局域网组播通告后写入本地，
+// 供 DialTargets / GPUTarget 选择节点；离线节点只由维护注册表的一方调用 ExpireNodes 按其超时设置移除
+// 注册表中的 NodeInfo 写入后不再修改，更新时整体替换
+
+// NodeTimeout 默认节点超时：超过该时间未收到心跳的节点视为离线
+const NodeTimeout = 15 * time.Second
+
+var (
+    lock       sync.Mutex                   // 保护 discovered
+    discovered = make(map[string]*NodeInfo) // key: 节点IP
+)
+
+// UpdateNode 写入或替换节点信息，LastSeen 为0时使用当前时间
+// 返回该节点此前是否不在注册表中
+func UpdateNode(node *NodeInfo) bool {
+    if node.LastSeen == 0 {
+        node.LastSeen = time.Now().Unix()
+    }
+    lock.Lock()
+    defer lock.Unlock()
+    _, known := discovered[node.IP]
+    discovered[node.IP] = node
+    return !known
+}
+
+// LookupNode 返回节点信息，不存在时返回nil
+func LookupNode(ip string) *NodeInfo {
+    lock.Lock()
+    defer lock.Unlock()
+    return discovered[ip]
+}
+
+// Nodes 返回注册表中的所有节点，按IP排序
+func Nodes() []*NodeInfo {
+    lock.Lock()
+    defer lock.Unlock()
+    nodes := make([]*NodeInfo, 0, len(discovered))
+    for _, node := range discovered {
+        nodes = append(nodes, node)
+    }
+    sort.Slice(nodes, func(i, j int) bool { return nodes[i].IP < nodes[j].IP })
+    return nodes
+}
+
+// ExpireNodes 移除超过 timeout 未收到心跳的节点，返回被移除节点的IP
+func ExpireNodes(timeout time.Duration) []string {
+    deadline := time.Now().Add(-timeout).Unix()
+    lock.Lock()
+    defer lock.Unlock()
+    var expired []string
+    for ip, node := range discovered {
+        if node.LastSeen < deadline {
+            delete(discovered, ip)
+            expired = append(expired, ip)
+        }
+    }
+    sort.Strings(expired)
+    return expired
+}
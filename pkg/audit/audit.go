@@ -0,0 +1,252 @@
+package audit
+
+import (
+    "bufio"
+    "context"
+    "encoding/json"
+    "fmt"
+    "os"
+    "path/filepath"
+    "sort"
+    "strings"
+    "sync"
+    "time"
+)
+
+// audit 包提供只追加的审计日志：每条记录一行JSON（NDJSON）
+// 当前文件超过大小上限时重命名为 <name>.<时间戳> 并新建文件，超出保留数量的旧文件被删除
+
+// Record 一条审计记录
+type Record struct {
+    Time       time.Time `json:"time"`                // 调用开始时间
+    User       string    `json:"user,omitempty"`      // 调用者身份（未认证时为空）
+    Peer       string    `json:"peer,omitempty"`      // 客户端地址
+    RPC        string    `json:"rpc"`                 // RPC方法名或事件名
+    UUID       string    `json:"uuid,omitempty"`      // GPU UUID（多GPU时逗号分隔）
+    LeaseID    string    `json:"leaseId,omitempty"`   // 租约ID
+    JobID      string    `json:"jobId,omitempty"`     // 作业ID
+    Cmd        string    `json:"cmd,omitempty"`       // 命令行
+    Path       string    `json:"path,omitempty"`      // 文件沙箱内路径
+    ExitCode   *int      `json:"exitCode,omitempty"`  // 命令退出码（命令未执行时为空）
+    DurationMs int64     `json:"durationMs"`          // 耗时（毫秒）
+    Code       string    `json:"code"`                // gRPC状态码，成功为 OK；JobExit 事件为作业最终状态
+    Error      string    `json:"error,omitempty"`     // 错误信息
+}
+
+// SetGPU 记录GPU和租约，r 为nil时忽略（未启用审计）
+func (r *Record) SetGPU(uuid, leaseID string) {
+    if r == nil {
+        return
+    }
+    r.UUID, r.LeaseID = uuid, leaseID
+}
+
+// SetCommand 记录命令行
+func (r *Record) SetCommand(cmd string) {
+    if r == nil {
+        return
+    }
+    r.Cmd = cmd
+}
+
+// SetPath 记录文件路径
+func (r *Record) SetPath(path string) {
+    if r == nil {
+        return
+    }
+    r.Path = path
+}
+
+// SetJob 记录作业ID
+func (r *Record) SetJob(id string) {
+    if r == nil {
+        return
+    }
+    r.JobID = id
+}
+
+// SetExit 记录命令退出码
+func (r *Record) SetExit(code int) {
+    if r == nil {
+        return
+    }
+    r.ExitCode = &code
+}
+
+type recordKey struct{}
+
+// NewContext 返回附带审计记录的上下文，处理函数通过 FromContext 补充记录内容
+func NewContext(ctx context.Context, r *Record) context.Context {
+    return context.WithValue(ctx, recordKey{}, r)
+}
+
+// FromContext 返回上下文中的审计记录，未启用审计时返回 nil（Set* 方法可安全调用）
+func FromContext(ctx context.Context) *Record {
+    r, _ := ctx.Value(recordKey{}).(*Record)
+    return r
+}
+
+// Logger 审计日志写入器
+type Logger struct {
+    path     string // 当前日志文件路径
+    maxSize  int64  // 单个文件大小上限（字节）
+    maxFiles int    // 保留的轮转文件数量
+
+    mu   sync.Mutex
+    f    *os.File
+    size int64
+}
+
+// NewLogger 打开审计日志文件（追加模式）
+// maxSize<=0 时不轮转；maxFiles<=0 时保留全部轮转文件
+func NewLogger(path string, maxSize int64, maxFiles int) (*Logger, error) {
+    if err := os.MkdirAll(filepath.Dir(path), 0750); err != nil {
+        return nil, fmt.Errorf("create audit dir: %v", err)
+    }
+    l := &Logger{path: path, maxSize: maxSize, maxFiles: maxFiles}
+    if err := l.open(); err != nil {
+        return nil, err
+    }
+    return l, nil
+}
+
+// open 以追加模式打开当前日志文件，调用方需持有锁或处于初始化阶段
+func (l *Logger) open() error {
+    f, err := os.OpenFile(l.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0640)
+    if err != nil {
+        return fmt.Errorf("open audit log: %v", err)
+    }
+    info, err := f.Stat()
+    if err != nil {
+        f.Close()
+        return err
+    }
+    l.f, l.size = f, info.Size()
+    return nil
+}
+
+// Write 追加一条记录，必要时先轮转文件
+func (l *Logger) Write(r *Record) error {
+    line, err := json.Marshal(r)
+    if err != nil {
+        return err
+    }
+    line = append(line, '\n')
+
+    l.mu.Lock()
+    defer l.mu.Unlock()
+    if l.maxSize > 0 && l.size > 0 && l.size+int64(len(line)) > l.maxSize {
+        if err := l.rotate(); err != nil {
+            return err
+        }
+    }
+    n, err := l.f.Write(line)
+    l.size += int64(n)
+    return err
+}
+
+// rotate 将当前文件重命名为带时间戳的文件并新建当前文件，调用方需持有锁
+func (l *Logger) rotate() error {
+    l.f.Close()
+    rotated := l.path + "." + time.Now().UTC().Format("20060102T150405.000000000")
+    if err := os.Rename(l.path, rotated); err != nil {
+        return fmt.Errorf("rotate audit log: %v", err)
+    }
+    if err := l.open(); err != nil {
+        return err
+    }
+    if l.maxFiles > 0 {
+        old := rotatedFiles(l.path)
+        for len(old) > l.maxFiles {
+            os.Remove(old[0])
+            old = old[1:]
+        }
+    }
+    return nil
+}
+
+// Close 关闭日志文件
+func (l *Logger) Close() error {
+    l.mu.Lock()
+    defer l.mu.Unlock()
+    return l.f.Close()
+}
+
+// rotatedFiles 返回已轮转的文件，按时间从旧到新排序
+func rotatedFiles(path string) []string {
+    matches, _ := filepath.Glob(path + ".*")
+    sort.Strings(matches) // 时间戳格式固定，字典序即时间序
+    return matches
+}
+
+// Filter 查询条件，零值字段表示不限制
+type Filter struct {
+    Since time.Time
+    Until time.Time
+    User  string
+    UUID  string
+    RPC   string
+}
+
+// match 判断记录是否满足查询条件
+func (f Filter) match(r *Record) bool {
+    if !f.Since.IsZero() && r.Time.Before(f.Since) {
+        return false
+    }
+    if !f.Until.IsZero() && !r.Time.Before(f.Until) {
+        return false
+    }
+    if f.User != "" && r.User != f.User {
+        return false
+    }
+    if f.UUID != "" && !hasUUID(r.UUID, f.UUID) {
+        return false
+    }
+    if f.RPC != "" && !strings.EqualFold(r.RPC, f.RPC) {
+        return false
+    }
+    return true
+}
+
+// hasUUID 判断记录的GPU字段（多GPU占用时为逗号分隔列表）是否包含指定UUID
+func hasUUID(list, uuid string) bool {
+    for _, u := range strings.Split(list, ",") {
+        if u == uuid {
+            return true
+        }
+    }
+    return false
+}
+
+// Query 按时间顺序读取 path 及其轮转文件中满足条件的记录，对每条记录调用 fn
+// 无法解析的行被跳过（例如进程崩溃时写了一半的行）
+func Query(path string, f Filter, fn func(*Record)) error {
+    files := append(rotatedFiles(path), path)
+    for _, name := range files {
+        if err := scanFile(name, f, fn); err != nil && !os.IsNotExist(err) {
+            return err
+        }
+    }
+    return nil
+}
+
+// scanFile 读取单个日志文件
+func scanFile(name string, f Filter, fn func(*Record)) error {
+    file, err := os.Open(name)
+    if err != nil {
+        return err
+    }
+    defer file.Close()
+    sc := bufio.NewScanner(file)
+    sc.Buffer(make([]byte, 64*1024), 16*1024*1024) // 命令行可能很长
+    for sc.Scan() {
+        var r Record
+        if json.Unmarshal(sc.Bytes(), &r) != nil {
+            continue
+        }
+        if f.match(&r) {
+            fn(&r)
+        }
+    }
+    return sc.Err()
+}
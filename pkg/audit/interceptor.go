@@ -0,0 +1,98 @@
+package audit
+
+import (
+    "context"
+    "path"
+    "time"
+
+    "google.golang.org/grpc"
+    "google.golang.org/grpc/status"
+
+    "github.com/hiicl/GPU-over-IP-AC922/pkg/auth"
+    "github.com/hiicl/GPU-over-IP-AC922/pkg/util"
+)
+
+// interceptor.go 提供记录审计日志的gRPC拦截器
+// 需位于认证拦截器之后（以获取调用者身份）、授权拦截器之前（以记录被拒绝的调用）
+
+// Describer 根据请求消息填写审计记录（GPU、命令行等），流式调用时使用客户端发送的第一条消息
+type Describer func(r *Record, msg interface{})
+
+// Log 写入一条记录，写入失败只打印日志；l 为nil时忽略（未启用审计）
+func (l *Logger) Log(r *Record) {
+    if l == nil {
+        return
+    }
+    if err := l.Write(r); err != nil {
+        util.Log("[audit] write record failed: %v", err)
+    }
+}
+
+// begin 创建调用的审计记录
+func begin(ctx context.Context, method string) *Record {
+    r := &Record{Time: time.Now(), RPC: path.Base(method)}
+    if id := auth.FromContext(ctx); id != nil {
+        r.User = id.User
+    }
+    r.Peer = auth.PeerAddr(ctx)
+    return r
+}
+
+// finish 填写耗时和结果并写入记录
+func (l *Logger) finish(r *Record, err error) {
+    r.DurationMs = time.Since(r.Time).Milliseconds()
+    r.Code = status.Code(err).String()
+    if err != nil {
+        r.Error = status.Convert(err).Message()
+    }
+    l.Log(r)
+}
+
+// UnaryInterceptor 一元调用审计拦截器，只记录 methods 中的方法
+func (l *Logger) UnaryInterceptor(methods map[string]bool, describe Describer) grpc.UnaryServerInterceptor {
+    return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+        if !methods[info.FullMethod] {
+            return handler(ctx, req)
+        }
+        r := begin(ctx, info.FullMethod)
+        describe(r, req)
+        resp, err := handler(NewContext(ctx, r), req)
+        l.finish(r, err)
+        return resp, err
+    }
+}
+
+// StreamInterceptor 流式调用审计拦截器，只记录 methods 中的方法
+func (l *Logger) StreamInterceptor(methods map[string]bool, describe Describer) grpc.StreamServerInterceptor {
+    return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+        if !methods[info.FullMethod] {
+            return handler(srv, ss)
+        }
+        r := begin(ss.Context(), info.FullMethod)
+        err := handler(srv, &auditedStream{ServerStream: ss, ctx: NewContext(ss.Context(), r), record: r, describe: describe})
+        l.finish(r, err)
+        return err
+    }
+}
+
+// auditedStream 在上下文中附带审计记录，并用第一条客户端消息描述调用
+type auditedStream struct {
+    grpc.ServerStream
+    ctx      context.Context
+    record   *Record
+    describe Describer
+    received bool
+}
+
+func (s *auditedStream) Context() context.Context {
+    return s.ctx
+}
+
+func (s *auditedStream) RecvMsg(m interface{}) error {
+    err := s.ServerStream.RecvMsg(m)
+    if err == nil && !s.received {
+        s.received = true
+        s.describe(s.record, m)
+    }
+    return err
+}
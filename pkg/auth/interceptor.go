@@ -0,0 +1,149 @@
+package auth
+
+import (
+    "context"
+    "strings"
+
+    "google.golang.org/grpc"
+    "google.golang.org/grpc/codes"
+    "google.golang.org/grpc/credentials"
+    "google.golang.org/grpc/metadata"
+    "google.golang.org/grpc/peer"
+    "google.golang.org/grpc/status"
+)
+
+// interceptor.go 提供gRPC认证拦截器
+// 启用令牌认证时，每个调用都必须在 authorization 元数据中携带 "Bearer <token>"；
+// 仅启用mTLS时，以客户端证书的CN作为调用者身份（REST网关请求使用网关转发的证书身份）
+
+// publicMethods 无需认证的方法（负载均衡器/编排系统的健康检查）
+var publicMethods = map[string]bool{
+    "/grpc.health.v1.Health/Check": true,
+    "/grpc.health.v1.Health/Watch": true,
+}
+
+// Identity 已认证的调用者
+type Identity struct {
+    User   string // 用户名（令牌文件中的用户或客户端证书CN）
+    Method string // 认证方式：token 或 mtls
+    Role   string // 角色（启用访问控制策略时由授权拦截器填写）
+    Tenant string // 所属租户（同上，为空表示不属于任何租户）
+
+    policy *Policy // 授权时使用的策略，未启用访问控制时为nil
+}
+
+// Can 判断调用者是否拥有指定权限；未启用访问控制策略时始终返回 true
+func (id *Identity) Can(perm Permission) bool {
+    if id == nil || id.policy == nil {
+        return true
+    }
+    return id.policy.Allowed(id.Role, perm)
+}
+
+type identityKey struct{}
+
+// FromContext 返回请求上下文中的调用者身份，未认证时返回 nil
+func FromContext(ctx context.Context) *Identity {
+    id, _ := ctx.Value(identityKey{}).(*Identity)
+    return id
+}
+
+// Authenticator 校验调用者身份
+type Authenticator struct {
+    tokens *TokenStore // 为nil时不校验令牌
+}
+
+// NewAuthenticator 创建认证器，tokens 为nil时只使用mTLS证书识别身份
+func NewAuthenticator(tokens *TokenStore) *Authenticator {
+    return &Authenticator{tokens: tokens}
+}
+
+// authenticate 校验请求并返回附带身份的上下文
+func (a *Authenticator) authenticate(ctx context.Context, method string) (context.Context, error) {
+    if publicMethods[method] {
+        return ctx, nil
+    }
+    if a.tokens == nil {
+        cn := peerCommonName(ctx)
+        if cn == "" {
+            cn = forwarded(ctx, ForwardedUserKey)
+        }
+        if cn != "" {
+            return context.WithValue(ctx, identityKey{}, &Identity{User: cn, Method: "mtls"}), nil
+        }
+        return ctx, nil
+    }
+
+    token, err := bearerToken(ctx)
+    if err != nil {
+        return nil, err
+    }
+    user, ok := a.tokens.Lookup(token)
+    if !ok {
+        return nil, status.Error(codes.Unauthenticated, "invalid bearer token")
+    }
+    return context.WithValue(ctx, identityKey{}, &Identity{User: user, Method: "token"}), nil
+}
+
+// bearerToken 从 authorization 元数据中提取Bearer令牌
+func bearerToken(ctx context.Context) (string, error) {
+    md, ok := metadata.FromIncomingContext(ctx)
+    if !ok {
+        return "", status.Error(codes.Unauthenticated, "missing metadata")
+    }
+    values := md.Get("authorization")
+    if len(values) == 0 {
+        return "", status.Error(codes.Unauthenticated, "missing bearer token")
+    }
+    const prefix = "bearer "
+    v := values[0]
+    if len(v) <= len(prefix) || !strings.EqualFold(v[:len(prefix)], prefix) {
+        return "", status.Error(codes.Unauthenticated, "malformed authorization header, expected \"Bearer <token>\"")
+    }
+    return strings.TrimSpace(v[len(prefix):]), nil
+}
+
+// peerCommonName 返回已校验的客户端证书CN，非mTLS连接返回空字符串
+func peerCommonName(ctx context.Context) string {
+    p, ok := peer.FromContext(ctx)
+    if !ok {
+        return ""
+    }
+    info, ok := p.AuthInfo.(credentials.TLSInfo)
+    if !ok || len(info.State.VerifiedChains) == 0 || len(info.State.VerifiedChains[0]) == 0 {
+        return ""
+    }
+    return info.State.VerifiedChains[0][0].Subject.CommonName
+}
+
+// UnaryInterceptor 一元调用认证拦截器
+func (a *Authenticator) UnaryInterceptor() grpc.UnaryServerInterceptor {
+    return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+        ctx, err := a.authenticate(ctx, info.FullMethod)
+        if err != nil {
+            return nil, err
+        }
+        return handler(ctx, req)
+    }
+}
+
+// StreamInterceptor 流式调用认证拦截器
+func (a *Authenticator) StreamInterceptor() grpc.StreamServerInterceptor {
+    return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+        ctx, err := a.authenticate(ss.Context(), info.FullMethod)
+        if err != nil {
+            return err
+        }
+        return handler(srv, &authedStream{ServerStream: ss, ctx: ctx})
+    }
+}
+
+// authedStream 替换流的上下文，使处理函数能通过 stream.Context() 获取调用者身份
+type authedStream struct {
+    grpc.ServerStream
+    ctx context.Context
+}
+
+func (s *authedStream) Context() context.Context {
+    return s.ctx
+}
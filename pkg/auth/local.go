@@ -0,0 +1,121 @@
+package auth
+
+import (
+    "context"
+    "errors"
+    "net"
+    "sync"
+
+    "google.golang.org/grpc/metadata"
+    "google.golang.org/grpc/peer"
+)
+
+// local.go 提供进程内连接：REST网关经由 LocalListener 调用 GPUService，
+// 请求仍经过全部拦截器（认证、审计、授权、指标）
+// 网关已完成TLS客户端证书校验时，通过元数据转发证书身份和客户端地址，只有进程内连接上的转发元数据被信任
+
+// 网关转发的元数据
+const (
+    ForwardedUserKey = "x-aitherion-forwarded-user" // 网关校验客户端证书后得到的用户（证书CN）
+    ForwardedForKey  = "x-forwarded-for"            // HTTP客户端地址
+)
+
+// errListenerClosed 监听器已关闭
+var errListenerClosed = errors.New("local listener closed")
+
+// localAddr 进程内连接的地址
+type localAddr struct{}
+
+func (localAddr) Network() string { return "local" }
+func (localAddr) String() string  { return "gateway" }
+
+// localConn 进程内连接，远端地址固定为 localAddr，用于识别网关请求
+type localConn struct {
+    net.Conn
+}
+
+func (localConn) RemoteAddr() net.Addr { return localAddr{} }
+
+// LocalListener 进程内监听器，实现 net.Listener
+type LocalListener struct {
+    conns  chan net.Conn
+    closed chan struct{}
+    once   sync.Once
+}
+
+// NewLocalListener 创建进程内监听器
+func NewLocalListener() *LocalListener {
+    return &LocalListener{conns: make(chan net.Conn), closed: make(chan struct{})}
+}
+
+// Accept 实现 net.Listener
+func (l *LocalListener) Accept() (net.Conn, error) {
+    select {
+    case c := <-l.conns:
+        return c, nil
+    case <-l.closed:
+        return nil, errListenerClosed
+    }
+}
+
+// Close 实现 net.Listener
+func (l *LocalListener) Close() error {
+    l.once.Do(func() { close(l.closed) })
+    return nil
+}
+
+// Addr 实现 net.Listener
+func (l *LocalListener) Addr() net.Addr {
+    return localAddr{}
+}
+
+// DialContext 建立到监听器的进程内连接，用作 grpc.WithContextDialer
+func (l *LocalListener) DialContext(ctx context.Context, _ string) (net.Conn, error) {
+    client, server := net.Pipe()
+    select {
+    case l.conns <- localConn{Conn: server}:
+        return client, nil
+    case <-l.closed:
+        client.Close()
+        server.Close()
+        return nil, errListenerClosed
+    case <-ctx.Done():
+        client.Close()
+        server.Close()
+        return nil, ctx.Err()
+    }
+}
+
+// isLocal 判断请求是否来自进程内连接（REST网关）
+func isLocal(ctx context.Context) bool {
+    p, ok := peer.FromContext(ctx)
+    if !ok {
+        return false
+    }
+    _, local := p.Addr.(localAddr)
+    return local
+}
+
+// forwarded 返回网关转发的元数据值，非进程内连接返回空字符串
+func forwarded(ctx context.Context, key string) string {
+    if !isLocal(ctx) {
+        return ""
+    }
+    md, _ := metadata.FromIncomingContext(ctx)
+    if v := md.Get(key); len(v) > 0 {
+        return v[0]
+    }
+    return ""
+}
+
+// PeerAddr 返回客户端地址：网关请求返回 "gateway/<HTTP客户端地址>"，无法获取时返回"unknown"
+func PeerAddr(ctx context.Context) string {
+    p, ok := peer.FromContext(ctx)
+    if !ok {
+        return "unknown"
+    }
+    if client := forwarded(ctx, ForwardedForKey); client != "" {
+        return p.Addr.String() + "/" + client
+    }
+    return p.Addr.String()
+}
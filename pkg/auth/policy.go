@@ -0,0 +1,214 @@
+package auth
+
+import (
+    "context"
+    "fmt"
+    "os"
+
+    "google.golang.org/grpc"
+    "google.golang.org/grpc/codes"
+    "google.golang.org/grpc/status"
+    "gopkg.in/yaml.v3"
+)
+
+// policy.go 实现基于角色的访问控制（RBAC）
+// 策略文件示例：
+//   roles:                       # 可选，省略时使用 DefaultRoles
+//     viewer: [list]
+//     user:   [list, acquire, run]
+//     admin:  [list, acquire, run, admin]
+//   tenants:
+//     vision:
+//       role: user
+//       maxGPUs: 4               # 租户同时持有的GPU上限，0表示不限制
+//       users: [alice, bob]
+//   users:                       # 单个用户的角色，优先于租户角色
+//     root: admin
+//   defaultRole: viewer          # 未列出的用户（含匿名调用者）的角色，为空时拒绝
+
+// Permission 权限
+type Permission string
+
+// 权限列表
+const (
+    PermList    Permission = "list"    // 查询GPU、节点、作业信息
+    PermAcquire Permission = "acquire" // 占用、续期、释放GPU
+    PermRun     Permission = "run"     // 执行命令、提交作业、传输文件
+    PermAdmin   Permission = "admin"   // 管理操作
+)
+
+// DefaultRoles 策略文件未定义 roles 时使用的内置角色
+var DefaultRoles = map[string][]Permission{
+    "viewer": {PermList},
+    "user":   {PermList, PermAcquire, PermRun},
+    "admin":  {PermList, PermAcquire, PermRun, PermAdmin},
+}
+
+// Tenant 租户：一组共享角色和GPU配额的用户
+type Tenant struct {
+    Role    string   `yaml:"role"`
+    MaxGPUs int      `yaml:"maxGPUs"`
+    Users   []string `yaml:"users"`
+}
+
+// Policy 访问控制策略
+type Policy struct {
+    Roles       map[string][]Permission `yaml:"roles"`
+    Tenants     map[string]Tenant       `yaml:"tenants"`
+    Users       map[string]string       `yaml:"users"`
+    DefaultRole string                  `yaml:"defaultRole"`
+
+    tenantOf map[string]string // key: 用户，value: 所属租户
+}
+
+// LoadPolicy 读取并校验YAML策略文件
+func LoadPolicy(path string) (*Policy, error) {
+    data, err := os.ReadFile(path)
+    if err != nil {
+        return nil, fmt.Errorf("read policy file: %v", err)
+    }
+    p := &Policy{}
+    if err := yaml.Unmarshal(data, p); err != nil {
+        return nil, fmt.Errorf("parse policy file %s: %v", path, err)
+    }
+    if err := p.init(); err != nil {
+        return nil, fmt.Errorf("policy file %s: %v", path, err)
+    }
+    return p, nil
+}
+
+// init 填充默认值、建立用户到租户的索引并校验引用关系
+func (p *Policy) init() error {
+    if len(p.Roles) == 0 {
+        p.Roles = DefaultRoles
+    }
+    for role, perms := range p.Roles {
+        for _, perm := range perms {
+            switch perm {
+            case PermList, PermAcquire, PermRun, PermAdmin:
+            default:
+                return fmt.Errorf("role %s: unknown permission %q", role, perm)
+            }
+        }
+    }
+    checkRole := func(where, role string) error {
+        if _, ok := p.Roles[role]; role != "" && !ok {
+            return fmt.Errorf("%s: unknown role %q", where, role)
+        }
+        return nil
+    }
+    if err := checkRole("defaultRole", p.DefaultRole); err != nil {
+        return err
+    }
+    for user, role := range p.Users {
+        if err := checkRole("user "+user, role); err != nil {
+            return err
+        }
+    }
+
+    p.tenantOf = make(map[string]string)
+    for name, t := range p.Tenants {
+        if err := checkRole("tenant "+name, t.Role); err != nil {
+            return err
+        }
+        for _, user := range t.Users {
+            if other, ok := p.tenantOf[user]; ok {
+                return fmt.Errorf("user %s belongs to both tenant %s and %s", user, other, name)
+            }
+            p.tenantOf[user] = name
+        }
+    }
+    return nil
+}
+
+// Resolve 返回用户的角色和所属租户
+// 角色优先级：users 中的角色 > 所属租户的角色 > defaultRole
+func (p *Policy) Resolve(user string) (role, tenant string) {
+    tenant = p.tenantOf[user]
+    if r, ok := p.Users[user]; ok {
+        return r, tenant
+    }
+    if t, ok := p.Tenants[tenant]; ok && t.Role != "" {
+        return t.Role, tenant
+    }
+    return p.DefaultRole, tenant
+}
+
+// Allowed 判断角色是否拥有指定权限
+func (p *Policy) Allowed(role string, perm Permission) bool {
+    for _, have := range p.Roles[role] {
+        if have == perm {
+            return true
+        }
+    }
+    return false
+}
+
+// Authorizer 按策略校验每个调用所需的权限
+// methods 为完整方法名到所需权限的映射，未列出的方法需要 admin 权限
+type Authorizer struct {
+    policy  *Policy
+    methods map[string]Permission
+}
+
+// NewAuthorizer 创建授权器
+func NewAuthorizer(policy *Policy, methods map[string]Permission) *Authorizer {
+    return &Authorizer{policy: policy, methods: methods}
+}
+
+// authorize 校验调用者权限，并将角色和租户写入调用者身份（匿名调用者的身份中 User 为空）
+// 需位于认证拦截器之后
+func (z *Authorizer) authorize(ctx context.Context, method string) (context.Context, error) {
+    if publicMethods[method] {
+        return ctx, nil
+    }
+    need, ok := z.methods[method]
+    if !ok {
+        need = PermAdmin
+    }
+
+    user := ""
+    if id := FromContext(ctx); id != nil {
+        user = id.User
+    }
+    role, tenant := z.policy.Resolve(user)
+    if !z.policy.Allowed(role, need) {
+        who := "anonymous caller"
+        if user != "" {
+            who = fmt.Sprintf("user %s", user)
+        }
+        if role == "" {
+            return nil, status.Errorf(codes.PermissionDenied, "%s has no role and may not call %s", who, method)
+        }
+        return nil, status.Errorf(codes.PermissionDenied, "%s (role %s) lacks %q permission required by %s", who, role, need, method)
+    }
+
+    authed := &Identity{}
+    if id := FromContext(ctx); id != nil {
+        *authed = *id
+    }
+    authed.Role, authed.Tenant, authed.policy = role, tenant, z.policy
+    return context.WithValue(ctx, identityKey{}, authed), nil
+}
+
+// UnaryInterceptor 一元调用授权拦截器
+func (z *Authorizer) UnaryInterceptor() grpc.UnaryServerInterceptor {
+    return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+        ctx, err := z.authorize(ctx, info.FullMethod)
+        if err != nil {
+            return nil, err
+        }
+        return handler(ctx, req)
+    }
+}
+
+// StreamInterceptor 流式调用授权拦截器
+func (z *Authorizer) StreamInterceptor() grpc.StreamServerInterceptor {
+    return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+        ctx, err := z.authorize(ss.Context(), info.FullMethod)
+        if err != nil {
+            return err
+        }
+        return handler(srv, &authedStream{ServerStream: ss, ctx: ctx})
+    }
+}
@@ -0,0 +1,72 @@
+package auth
+
+import (
+    "crypto/tls"
+    "crypto/x509"
+    "fmt"
+    "os"
+)
+
+// tls.go 提供服务端/客户端TLS配置，支持双向认证（mTLS）
+
+// loadCAPool 读取PEM格式的CA证书包
+func loadCAPool(caFile string) (*x509.CertPool, error) {
+    data, err := os.ReadFile(caFile)
+    if err != nil {
+        return nil, fmt.Errorf("read CA bundle: %v", err)
+    }
+    pool := x509.NewCertPool()
+    if !pool.AppendCertsFromPEM(data) {
+        return nil, fmt.Errorf("no certificates found in CA bundle %s", caFile)
+    }
+    return pool, nil
+}
+
+// ServerTLSConfig 创建服务端TLS配置
+// certFile/keyFile: 服务端证书和私钥
+// caFile: 客户端CA证书包，非空时要求并校验客户端证书（mTLS）
+func ServerTLSConfig(certFile, keyFile, caFile string) (*tls.Config, error) {
+    cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+    if err != nil {
+        return nil, fmt.Errorf("load server certificate: %v", err)
+    }
+    cfg := &tls.Config{
+        Certificates: []tls.Certificate{cert},
+        MinVersion:   tls.VersionTLS12,
+    }
+    if caFile != "" {
+        pool, err := loadCAPool(caFile)
+        if err != nil {
+            return nil, err
+        }
+        cfg.ClientCAs = pool
+        cfg.ClientAuth = tls.RequireAndVerifyClientCert
+    }
+    return cfg, nil
+}
+
+// ClientTLSConfig 创建客户端TLS配置
+// caFile: 校验服务端证书的CA证书包，为空时使用系统根证书
+// certFile/keyFile: 客户端证书和私钥，服务端启用mTLS时必须提供
+// serverName: 覆盖证书校验使用的主机名（按IP连接时使用）
+func ClientTLSConfig(caFile, certFile, keyFile, serverName string) (*tls.Config, error) {
+    cfg := &tls.Config{
+        ServerName: serverName,
+        MinVersion: tls.VersionTLS12,
+    }
+    if caFile != "" {
+        pool, err := loadCAPool(caFile)
+        if err != nil {
+            return nil, err
+        }
+        cfg.RootCAs = pool
+    }
+    if certFile != "" || keyFile != "" {
+        cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+        if err != nil {
+            return nil, fmt.Errorf("load client certificate: %v", err)
+        }
+        cfg.Certificates = []tls.Certificate{cert}
+    }
+    return cfg, nil
+}
@@ -0,0 +1,102 @@
+package auth
+
+import (
+    "bufio"
+    "crypto/sha256"
+    "fmt"
+    "os"
+    "strings"
+    "sync"
+    "time"
+
+    "github.com/hiicl/GPU-over-IP-AC922/pkg/util"
+)
+
+// token.go 提供基于本地令牌文件的Bearer令牌校验
+// 令牌文件每行一个令牌：
+//   <token> <user>
+// 以 # 开头的行和空行被忽略；文件修改后自动重新加载
+
+// reloadInterval 检查令牌文件是否修改的最小间隔
+const reloadInterval = 5 * time.Second
+
+// TokenStore 保存令牌到用户的映射
+// 令牌以SHA-256摘要为键存储，内存中不保留令牌明文
+type TokenStore struct {
+    path string
+
+    mu        sync.Mutex
+    users     map[[sha256.Size]byte]string // key: 令牌摘要，value: 用户名
+    modTime   time.Time                    // 已加载文件的修改时间
+    checkedAt time.Time                    // 上次检查文件修改的时间
+}
+
+// NewTokenStore 从令牌文件创建令牌库
+func NewTokenStore(path string) (*TokenStore, error) {
+    s := &TokenStore{path: path}
+    if err := s.load(); err != nil {
+        return nil, err
+    }
+    return s, nil
+}
+
+// load 读取并解析令牌文件，调用方需持有锁或处于初始化阶段
+func (s *TokenStore) load() error {
+    f, err := os.Open(s.path)
+    if err != nil {
+        return fmt.Errorf("open token file: %v", err)
+    }
+    defer f.Close()
+    info, err := f.Stat()
+    if err != nil {
+        return err
+    }
+
+    users := make(map[[sha256.Size]byte]string)
+    sc := bufio.NewScanner(f)
+    for lineNo := 1; sc.Scan(); lineNo++ {
+        line := strings.TrimSpace(sc.Text())
+        if line == "" || strings.HasPrefix(line, "#") {
+            continue
+        }
+        fields := strings.Fields(line)
+        if len(fields) < 2 {
+            return fmt.Errorf("%s:%d: expected \"<token> <user>\"", s.path, lineNo)
+        }
+        users[sha256.Sum256([]byte(fields[0]))] = fields[1]
+    }
+    if err := sc.Err(); err != nil {
+        return err
+    }
+
+    s.users = users
+    s.modTime = info.ModTime()
+    s.checkedAt = time.Now()
+    return nil
+}
+
+// reloadIfChanged 文件修改时重新加载；加载失败时保留旧令牌，调用方需持有锁
+func (s *TokenStore) reloadIfChanged() {
+    if time.Since(s.checkedAt) < reloadInterval {
+        return
+    }
+    s.checkedAt = time.Now()
+    info, err := os.Stat(s.path)
+    if err != nil || info.ModTime().Equal(s.modTime) {
+        return
+    }
+    if err := s.load(); err != nil {
+        util.Log("[auth] reload token file failed, keeping previous tokens: %v", err)
+        return
+    }
+    util.Log("[auth] token file %s reloaded", s.path)
+}
+
+// Lookup 校验令牌，返回对应的用户名
+func (s *TokenStore) Lookup(token string) (string, bool) {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    s.reloadIfChanged()
+    user, ok := s.users[sha256.Sum256([]byte(token))]
+    return user, ok
+}
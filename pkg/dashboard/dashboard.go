@@ -0,0 +1,23 @@
+package dashboard
+
+import (
+    "embed"
+    "io/fs"
+    "net/http"
+)
+
+// dashboard 包提供嵌入式Web控制台
+// 页面为静态文件，全部数据通过同一地址上的 REST 网关（/v1/）从 GPUService 获取：
+// NUMA分组与网卡拓扑、GPU实时利用率和显存、租约持有者、运行中的作业及其日志
+
+//go:embed static
+var static embed.FS
+
+// Handler 返回控制台静态文件的HTTP处理函数，应挂载在网关所在地址的根路径
+func Handler() http.Handler {
+    root, err := fs.Sub(static, "static")
+    if err != nil {
+        panic(err) // 嵌入目录在编译期确定，不会失败
+    }
+    return http.FileServer(http.FS(root))
+}
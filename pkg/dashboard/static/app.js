@@ -0,0 +1,260 @@
+'use strict';
+
+// Aitherion 控制台：所有数据来自同一地址上的 REST 网关（/v1/）
+// 流式接口使用 fetch 读取 SSE（EventSource 无法携带 Authorization 头）
+
+const REFRESH_MS = 5000;   // 租约和作业刷新间隔
+const WATCH_MS = 2000;     // GPU状态推送间隔
+const RETRY_MS = 3000;     // 状态流断开后的重连间隔
+
+const $ = (id) => document.getElementById(id);
+
+// 页面状态
+const state = {
+  node: null,     // GetNodeInfo
+  gpus: {},       // uuid -> GPUInfo
+  status: {},     // uuid -> GPUStatus
+  leases: {},     // uuid -> Lease
+  logAbort: null, // 当前日志流的 AbortController
+};
+
+function headers() {
+  const h = {};
+  const token = localStorage.getItem('aitherion.token');
+  if (token) h['Authorization'] = 'Bearer ' + token;
+  return h;
+}
+
+async function api(path) {
+  const resp = await fetch('/v1/' + path, { headers: headers() });
+  const body = await resp.json().catch(() => ({}));
+  if (!resp.ok) throw new Error(body.message || resp.statusText);
+  return body;
+}
+
+// stream 读取SSE响应，每个事件调用 onEvent(name, data)；连接结束时返回
+async function stream(path, onEvent, signal) {
+  const resp = await fetch('/v1/' + path, { headers: headers(), signal });
+  if (!resp.ok) {
+    const body = await resp.json().catch(() => ({}));
+    throw new Error(body.message || resp.statusText);
+  }
+  const reader = resp.body.getReader();
+  const decoder = new TextDecoder();
+  let buf = '';
+  for (;;) {
+    const { value, done } = await reader.read();
+    if (done) return;
+    buf += decoder.decode(value, { stream: true });
+    let i;
+    while ((i = buf.indexOf('\n\n')) >= 0) {
+      const block = buf.slice(0, i);
+      buf = buf.slice(i + 2);
+      let name = 'message';
+      let data = '';
+      for (const line of block.split('\n')) {
+        if (line.startsWith('event: ')) name = line.slice(7);
+        else if (line.startsWith('data: ')) data += line.slice(6);
+      }
+      onEvent(name, JSON.parse(data));
+    }
+  }
+}
+
+function showError(err) {
+  const box = $('error');
+  box.hidden = !err;
+  box.textContent = err ? String(err.message || err) : '';
+}
+
+function setConn(text, cls) {
+  const badge = $('conn');
+  badge.textContent = text;
+  badge.className = 'badge ' + (cls || '');
+}
+
+function el(tag, text, cls) {
+  const e = document.createElement(tag);
+  if (text !== undefined) e.textContent = text;
+  if (cls) e.className = cls;
+  return e;
+}
+
+function bytes(n) {
+  const units = ['B', 'KiB', 'MiB', 'GiB', 'TiB'];
+  let i = 0;
+  for (n = Number(n); n >= 1024 && i < units.length - 1; i++) n /= 1024;
+  return n.toFixed(i ? 1 : 0) + ' ' + units[i];
+}
+
+function clock(ms) {
+  return ms && Number(ms) ? new Date(Number(ms)).toLocaleTimeString() : '';
+}
+
+function bar(ratio, label) {
+  const b = el('div', undefined, 'bar' + (ratio > 0.9 ? ' high' : ''));
+  const fill = el('span');
+  fill.style.width = Math.min(100, Math.max(0, ratio * 100)).toFixed(0) + '%';
+  b.append(fill, el('em', label));
+  return b;
+}
+
+// renderGroups 按NUMA分组绘制拓扑和GPU表格
+function renderGroups() {
+  const root = $('groups');
+  root.replaceChildren();
+  if (!state.node) return;
+  for (const g of state.node.groups) {
+    const card = $('group-tpl').content.cloneNode(true);
+    card.querySelector('h3').textContent = `NUMA ${g.numaNode} · port ${g.port}`;
+    card.querySelector('.topology').textContent =
+      `Memory ${bytes(g.memTotal)} · NICs ${g.nics.length ? g.nics.join(', ') : 'none'}`;
+    const body = card.querySelector('tbody');
+    for (const uuid of g.gpus) {
+      const tr = el('tr');
+      tr.dataset.uuid = uuid;
+      body.append(tr);
+    }
+    root.append(card);
+  }
+  for (const uuid of Object.keys(state.gpus)) renderGPU(uuid);
+}
+
+// renderGPU 更新一行GPU数据
+function renderGPU(uuid) {
+  const tr = document.querySelector(`tr[data-uuid="${CSS.escape(uuid)}"]`);
+  if (!tr) return;
+  const info = state.gpus[uuid] || {};
+  const st = state.status[uuid];
+  const lease = state.leases[uuid];
+  const total = Number(info.totalMemory || 0);
+
+  const name = el('td');
+  name.append(el('div', info.name || ''), el('div', uuid, 'uuid muted'));
+  if (info.mig) name.append(el('div', `MIG GI ${info.mig.gpuInstanceId} / CI ${info.mig.computeInstanceId} on ${info.mig.parentUuid}`, 'uuid muted'));
+  if (info.unhealthy) name.append(el('div', `Unhealthy: ${info.healthReason}`, 'unhealthy'));
+  const util = el('td');
+  const mem = el('td');
+  if (st) {
+    util.append(bar(st.utilization / 100, st.utilization + '%'));
+    const used = Number(st.usedMemory);
+    mem.append(bar(total ? used / total : 0, `${used} / ${total} MiB`));
+  }
+  const holder = el('td', lease ? `${lease.owner} (until ${clock(lease.expiresAt)})` : 'free',
+    lease ? '' : 'muted');
+  tr.replaceChildren(el('td', info.index ?? ''), name, util, mem, holder);
+}
+
+function renderJobs(jobs) {
+  const body = $('jobs');
+  body.replaceChildren();
+  if (!jobs.length) {
+    const td = el('td', 'No running jobs', 'muted');
+    td.colSpan = 6;
+    const tr = el('tr');
+    tr.append(td);
+    body.append(tr);
+    return;
+  }
+  for (const job of jobs) {
+    const btn = el('button', 'Tail log');
+    btn.type = 'button';
+    btn.onclick = () => tailLog(job);
+    const action = el('td');
+    action.append(btn);
+    const tr = el('tr');
+    tr.append(el('td', job.id, 'uuid'), el('td', job.uuid, 'uuid'), el('td', job.owner),
+      el('td', job.cmd), el('td', clock(job.startTime)), action);
+    body.append(tr);
+  }
+}
+
+// tailLog 流式显示作业日志，作业结束后显示最终状态
+async function tailLog(job) {
+  if (state.logAbort) state.logAbort.abort();
+  const abort = new AbortController();
+  state.logAbort = abort;
+  $('log-panel').hidden = false;
+  $('log-title').textContent = `${job.id} · ${job.cmd}`;
+  const log = $('log');
+  log.textContent = '';
+  try {
+    await stream(`jobs/${encodeURIComponent(job.id)}/output`, (name, data) => {
+      const follow = log.scrollTop + log.clientHeight >= log.scrollHeight - 4;
+      if (name === 'output') log.textContent += data.data;
+      else if (name === 'done') log.textContent += `\n[${data.state}, exit code ${data.exitCode}]\n`;
+      else if (name === 'error') log.textContent += `\n[error: ${data.message}]\n`;
+      if (follow) log.scrollTop = log.scrollHeight;
+    }, abort.signal);
+  } catch (err) {
+    if (!abort.signal.aborted) log.textContent += `\n[error: ${err.message}]\n`;
+  }
+}
+
+// refresh 刷新租约和运行中的作业
+async function refresh() {
+  try {
+    const [leases, jobs] = await Promise.all([api('leases'), api('jobs?state=running')]);
+    state.leases = {};
+    for (const l of leases.leases) state.leases[l.uuid] = l;
+    for (const uuid of Object.keys(state.gpus)) renderGPU(uuid);
+    renderJobs(jobs.jobs);
+    showError(null);
+  } catch (err) {
+    showError(err);
+  }
+}
+
+// watch 订阅所有GPU的状态推送，断开后自动重连
+async function watch() {
+  for (;;) {
+    try {
+      setConn('connecting');
+      await stream(`gpus:watch?intervalMs=${WATCH_MS}`, (name, data) => {
+        if (name === 'status') {
+          setConn('live', 'live');
+          state.status[data.uuid] = data;
+          renderGPU(data.uuid);
+        } else if (name === 'error') {
+          showError(new Error(data.message));
+        }
+      });
+    } catch (err) {
+      showError(err);
+    }
+    setConn('disconnected', 'down');
+    await new Promise((r) => setTimeout(r, RETRY_MS));
+  }
+}
+
+async function init() {
+  try {
+    const [info, node, gpus] = await Promise.all([api('info'), api('node'), api('gpus')]);
+    state.node = node;
+    for (const g of gpus.gpus) state.gpus[g.uuid] = g;
+    $('node').textContent = `${node.hostname} · ${node.arch} · server ${info.version}` +
+      (Number(node.memextPoolSize) ? ` · memext ${bytes(node.memextPoolSize)} on NUMA ${node.memextNumaNode}` : '');
+    renderGroups();
+    showError(null);
+  } catch (err) {
+    showError(err);
+    setConn('offline', 'down');
+    return;
+  }
+  refresh();
+  setInterval(refresh, REFRESH_MS);
+  watch();
+}
+
+$('token').value = localStorage.getItem('aitherion.token') || '';
+$('auth').onsubmit = (e) => {
+  e.preventDefault();
+  localStorage.setItem('aitherion.token', $('token').value.trim());
+  location.reload();
+};
+$('log-close').onclick = () => {
+  if (state.logAbort) state.logAbort.abort();
+  $('log-panel').hidden = true;
+};
+
+init();
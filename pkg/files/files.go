@@ -0,0 +1,166 @@
+package files
+
+import (
+    "crypto/sha256"
+    "encoding/hex"
+    "errors"
+    "fmt"
+    "io"
+    "os"
+    "path/filepath"
+    "strings"
+)
+
+// files 包提供节点文件沙箱：所有上传/下载路径都限定在沙箱根目录内
+// 用于通过 GPUService 暂存训练脚本、配置，以及取回检查点和作业输出
+
+// ErrOutsideRoot 路径超出沙箱根目录
+var ErrOutsideRoot = errors.New("path escapes sandbox root")
+
+// ErrOffset 续传偏移超出文件大小
+var ErrOffset = errors.New("offset out of range")
+
+// Sandbox 表示以 Root 为根目录的文件沙箱
+type Sandbox struct {
+    Root string // 沙箱根目录（绝对路径）
+}
+
+// NewSandbox 创建沙箱，根目录不存在时自动创建
+func NewSandbox(root string) (*Sandbox, error) {
+    abs, err := filepath.Abs(root)
+    if err != nil {
+        return nil, err
+    }
+    if err := os.MkdirAll(abs, 0755); err != nil {
+        return nil, fmt.Errorf("create sandbox root: %v", err)
+    }
+    // 根目录本身可能是符号链接，统一解析为真实路径便于前缀比较
+    if real, err := filepath.EvalSymlinks(abs); err == nil {
+        abs = real
+    }
+    return &Sandbox{Root: abs}, nil
+}
+
+// Resolve 将沙箱内相对路径转换为绝对路径
+// 拒绝空路径、绝对路径以及通过 ".." 或符号链接逃逸出根目录的路径
+func (s *Sandbox) Resolve(rel string) (string, error) {
+    if rel == "" || filepath.IsAbs(rel) {
+        return "", fmt.Errorf("%s: %w", rel, ErrOutsideRoot)
+    }
+    p := filepath.Join(s.Root, filepath.Clean("/"+rel))
+    if !s.contains(p) {
+        return "", fmt.Errorf("%s: %w", rel, ErrOutsideRoot)
+    }
+
+    // 检查已存在的最深一级父目录（含自身）解析符号链接后仍在根目录内
+    existing := p
+    for {
+        if real, err := filepath.EvalSymlinks(existing); err == nil {
+            if !s.contains(real) {
+                return "", fmt.Errorf("%s: %w", rel, ErrOutsideRoot)
+            }
+            break
+        }
+        parent := filepath.Dir(existing)
+        if parent == existing {
+            break
+        }
+        existing = parent
+    }
+    return p, nil
+}
+
+// contains 判断绝对路径是否位于根目录内
+func (s *Sandbox) contains(p string) bool {
+    return p == s.Root || strings.HasPrefix(p, s.Root+string(filepath.Separator))
+}
+
+// OpenWrite 打开沙箱内文件用于从 offset 处写入
+// offset 为0时创建或清空文件；offset>0 用于断点续传，文件当前大小必须不小于 offset，超出部分被截断
+func (s *Sandbox) OpenWrite(rel string, offset int64) (*os.File, error) {
+    p, err := s.Resolve(rel)
+    if err != nil {
+        return nil, err
+    }
+    if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+        return nil, err
+    }
+    f, err := os.OpenFile(p, os.O_CREATE|os.O_WRONLY, 0644)
+    if err != nil {
+        return nil, err
+    }
+    if offset > 0 {
+        info, err := f.Stat()
+        if err != nil {
+            f.Close()
+            return nil, err
+        }
+        if info.Size() < offset {
+            f.Close()
+            return nil, fmt.Errorf("%s: resume %w: %d beyond file size %d", rel, ErrOffset, offset, info.Size())
+        }
+    }
+    if err := f.Truncate(offset); err != nil {
+        f.Close()
+        return nil, err
+    }
+    if _, err := f.Seek(offset, io.SeekStart); err != nil {
+        f.Close()
+        return nil, err
+    }
+    return f, nil
+}
+
+// OpenRead 打开沙箱内文件并定位到 offset 处
+func (s *Sandbox) OpenRead(rel string, offset int64) (*os.File, error) {
+    p, err := s.Resolve(rel)
+    if err != nil {
+        return nil, err
+    }
+    f, err := os.Open(p)
+    if err != nil {
+        return nil, err
+    }
+    if _, err := f.Seek(offset, io.SeekStart); err != nil {
+        f.Close()
+        return nil, err
+    }
+    return f, nil
+}
+
+// Stat 返回沙箱内文件的大小和SHA-256（十六进制）
+func (s *Sandbox) Stat(rel string) (int64, string, error) {
+    p, err := s.Resolve(rel)
+    if err != nil {
+        return 0, "", err
+    }
+    return HashFile(p)
+}
+
+// Size 返回沙箱内文件的大小，不计算SHA-256
+func (s *Sandbox) Size(rel string) (int64, error) {
+    p, err := s.Resolve(rel)
+    if err != nil {
+        return 0, err
+    }
+    info, err := os.Stat(p)
+    if err != nil {
+        return 0, err
+    }
+    return info.Size(), nil
+}
+
+// HashFile 计算文件的大小和SHA-256（十六进制）
+func HashFile(path string) (int64, string, error) {
+    f, err := os.Open(path)
+    if err != nil {
+        return 0, "", err
+    }
+    defer f.Close()
+    h := sha256.New()
+    n, err := io.Copy(h, f)
+    if err != nil {
+        return 0, "", err
+    }
+    return n, hex.EncodeToString(h.Sum(nil)), nil
+}
@@ -0,0 +1,110 @@
+package gateway
+
+import (
+    "context"
+    "io"
+    "net/http"
+    "strconv"
+
+    "google.golang.org/grpc/codes"
+    "google.golang.org/grpc/status"
+    pb "github.com/hiicl/GPU-over-IP-AC922/proto"
+)
+
+// files.go 实现文件沙箱路由：请求体和响应体为文件原始内容，而非JSON
+
+// uploadChunkSize 上传时单个数据片段的大小
+const uploadChunkSize = 256 * 1024
+
+// checksumHeader 完整文件的SHA-256（十六进制）
+const checksumHeader = "X-Checksum-Sha256"
+
+// fileRoute 处理 /v1/files/{path} 路由
+func (g *Gateway) fileRoute(ctx context.Context, w http.ResponseWriter, r *http.Request, path string) {
+    var offset int64
+    if v := r.URL.Query().Get("offset"); v != "" {
+        var err error
+        if offset, err = strconv.ParseInt(v, 10, 64); err != nil || offset < 0 {
+            writeError(w, status.Errorf(codes.InvalidArgument, "invalid offset %q", v))
+            return
+        }
+    }
+    switch r.Method {
+    case http.MethodPut:
+        g.upload(ctx, w, r, path, offset)
+    case http.MethodGet:
+        if stat, _ := strconv.ParseBool(r.URL.Query().Get("stat")); stat {
+            resp, err := g.any().StatFile(ctx, &pb.FileRequest{Path: path})
+            writeProto(w, resp, err)
+            return
+        }
+        g.download(ctx, w, path, offset)
+    default:
+        allow(w, r, http.MethodGet, http.MethodPut)
+    }
+}
+
+// upload 将请求体分片写入 UploadFile 流
+// 请求带 Content-Length 时据此校验文件总大小，带 X-Checksum-Sha256 头时校验完整文件
+func (g *Gateway) upload(ctx context.Context, w http.ResponseWriter, r *http.Request, path string, offset int64) {
+    stream, err := g.any().UploadFile(ctx)
+    if err != nil {
+        writeError(w, err)
+        return
+    }
+    hdr := &pb.FileHeader{Path: path, Offset: offset, Sha256: r.Header.Get(checksumHeader)}
+    if r.ContentLength > 0 {
+        hdr.Size = offset + r.ContentLength
+    }
+    err = stream.Send(&pb.FileChunk{Msg: &pb.FileChunk_Header{Header: hdr}})
+    buf := make([]byte, uploadChunkSize)
+    for err == nil {
+        n, rerr := io.ReadFull(r.Body, buf)
+        if n > 0 {
+            err = stream.Send(&pb.FileChunk{Msg: &pb.FileChunk_Data{Data: buf[:n]}})
+        }
+        if rerr == io.EOF || rerr == io.ErrUnexpectedEOF {
+            break
+        }
+        if rerr != nil && err == nil {
+            err = status.Errorf(codes.Aborted, "read request body: %v", rerr)
+        }
+    }
+    // Send 失败时真正的错误由 CloseAndRecv 返回
+    if err != nil && err != io.EOF {
+        writeError(w, err)
+        return
+    }
+    resp, err := stream.CloseAndRecv()
+    writeResult(w, resp, resp.GetOk(), err)
+}
+
+// download 将 DownloadFile 流写入响应体，Content-Length 为本次传输的字节数
+func (g *Gateway) download(ctx context.Context, w http.ResponseWriter, path string, offset int64) {
+    stream, err := g.any().DownloadFile(ctx, &pb.FileRequest{Path: path, Offset: offset})
+    if err != nil {
+        writeError(w, err)
+        return
+    }
+    // 第一条消息为 header，之前的错误（文件不存在、偏移越界）仍可返回HTTP错误码
+    first, err := stream.Recv()
+    if err != nil {
+        writeError(w, err)
+        return
+    }
+    if hdr := first.GetHeader(); hdr != nil {
+        h := w.Header()
+        h.Set("Content-Type", "application/octet-stream")
+        h.Set("Content-Length", strconv.FormatInt(hdr.Size-hdr.Offset, 10))
+        h.Set(checksumHeader, hdr.Sha256)
+    }
+    for {
+        chunk, err := stream.Recv()
+        if err != nil {
+            return // 响应已开始，无法再返回错误码；客户端可按 Content-Length 判断并续传
+        }
+        if _, err := w.Write(chunk.GetData()); err != nil {
+            return
+        }
+    }
+}
@@ -0,0 +1,291 @@
+package gateway
+
+import (
+    "context"
+    "encoding/json"
+    "io"
+    "net"
+    "net/http"
+    "strings"
+
+    "google.golang.org/grpc"
+    "google.golang.org/grpc/codes"
+    "google.golang.org/grpc/credentials/insecure"
+    "google.golang.org/grpc/metadata"
+    "google.golang.org/grpc/status"
+    "google.golang.org/protobuf/encoding/protojson"
+    "google.golang.org/protobuf/proto"
+    pb "github.com/hiicl/GPU-over-IP-AC922/proto"
+
+    "github.com/hiicl/GPU-over-IP-AC922/pkg/auth"
+)
+
+// gateway 包提供 GPUService 的 HTTP/JSON 网关
+// 网关通过进程内连接调用各NUMA分组的 GPUService，认证、审计、授权与指标拦截器全部生效；
+// 请求体和响应体使用 protobuf 的标准JSON映射（字段名为 proto 中的 camelCase 名称）
+//
+// 路由：
+//   GET  /v1/info                     GetServerInfo
+//   GET  /v1/node                     GetNodeInfo
+//   GET  /v1/gpus                     ListGPUs（合并所有NUMA分组）
+//   GET  /v1/gpus:watch               WatchGPUStatus（SSE，所有GPU）
+//   POST /v1/gpus:acquire             AcquireGPUs（依次尝试各NUMA分组）
+//   GET  /v1/processes                ListGPUProcesses（合并所有NUMA分组）
+//   GET  /v1/gpus/{uuid}/status       GetGPUStatus
+//   GET  /v1/gpus/{uuid}/details      GetGPUDetails
+//   GET  /v1/gpus/{uuid}/processes    ListGPUProcesses
+//   GET  /v1/gpus/{uuid}:watch        WatchGPUStatus（SSE）
+//   POST /v1/gpus/{uuid}:acquire      AcquireGPU
+//   POST /v1/gpus/{uuid}:release      ReleaseGPU
+//   POST /v1/gpus/{uuid}:uncordon     UncordonGPU
+//   POST /v1/gpus/{uuid}:run          RunCommand；Accept: text/event-stream 或 ?stream=true 时为 RunCommandStream（SSE）
+//   GET  /v1/leases                   ListLeases（合并所有NUMA分组）
+//   POST /v1/leases/{leaseId}:renew   RenewLease
+//   GET  /v1/jobs                     ListJobs（?state=running&uuid=&owner=）
+//   POST /v1/jobs                     SubmitJob
+//   GET  /v1/jobs/{id}                GetJob
+//   POST /v1/jobs/{id}:cancel         CancelJob
+//   GET  /v1/jobs/{id}/output         作业输出（SSE，?offset= 起始偏移，作业结束后发送 done 事件）
+//   PUT  /v1/files/{path}             UploadFile（请求体为文件内容，?offset= 续传，X-Checksum-Sha256 校验）
+//   GET  /v1/files/{path}             DownloadFile（?offset= 续传）；?stat=true 时为 StatFile
+// {uuid} 中的 "/"（旧版驱动的MIG实例UUID）需转义为 %2F
+// Exec 为交互式双向流，无法映射为普通HTTP请求，不在网关中提供
+
+// Backend 一个NUMA分组的 GPUService 客户端
+type Backend struct {
+    Node   int                   // NUMA节点编号
+    GPUs   []string              // 分组内GPU的UUID
+    Client pb.GPUServiceClient   // 经进程内连接的客户端
+}
+
+// Dial 通过进程内监听器连接 GPUService
+func Dial(l *auth.LocalListener) (pb.GPUServiceClient, error) {
+    conn, err := grpc.Dial("gateway",
+        grpc.WithContextDialer(l.DialContext),
+        grpc.WithTransportCredentials(insecure.NewCredentials()),
+    )
+    if err != nil {
+        return nil, err
+    }
+    return pb.NewGPUServiceClient(conn), nil
+}
+
+// Gateway HTTP/JSON 网关，实现 http.Handler
+type Gateway struct {
+    backends []Backend
+    byGPU    map[string]pb.GPUServiceClient // key: GPU UUID，value: 所属分组的客户端
+}
+
+// New 创建网关，backends 至少包含一个分组
+func New(backends []Backend) *Gateway {
+    g := &Gateway{backends: backends, byGPU: make(map[string]pb.GPUServiceClient)}
+    for _, b := range backends {
+        for _, uuid := range b.GPUs {
+            g.byGPU[uuid] = b.Client
+        }
+    }
+    return g
+}
+
+// any 返回任一分组的客户端，用于与GPU无关的调用（调度器、作业管理器和文件沙箱在分组间共享）
+func (g *Gateway) any() pb.GPUServiceClient {
+    return g.backends[0].Client
+}
+
+// gpu 返回GPU所属分组的客户端，未知GPU时写入404并返回nil
+func (g *Gateway) gpu(w http.ResponseWriter, uuid string) pb.GPUServiceClient {
+    c, ok := g.byGPU[uuid]
+    if !ok {
+        writeError(w, status.Errorf(codes.NotFound, "GPU %s not found on this node", uuid))
+        return nil
+    }
+    return c
+}
+
+// ServeHTTP 按路径分发请求
+func (g *Gateway) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+    path := strings.TrimPrefix(r.URL.Path, "/v1/")
+    if path == r.URL.Path {
+        http.NotFound(w, r)
+        return
+    }
+    ctx := outgoingContext(r)
+
+    switch {
+    case path == "info":
+        if allow(w, r, http.MethodGet) {
+            g.serverInfo(ctx, w)
+        }
+    case path == "node":
+        if allow(w, r, http.MethodGet) {
+            g.nodeInfo(ctx, w)
+        }
+    case path == "gpus":
+        if allow(w, r, http.MethodGet) {
+            g.listGPUs(ctx, w)
+        }
+    case path == "gpus:watch":
+        if allow(w, r, http.MethodGet) {
+            g.watch(ctx, w, r, "")
+        }
+    case path == "gpus:acquire":
+        if allow(w, r, http.MethodPost) {
+            g.acquireGPUs(ctx, w, r)
+        }
+    case path == "processes":
+        if allow(w, r, http.MethodGet) {
+            g.listProcesses(ctx, w)
+        }
+    case strings.HasPrefix(path, "gpus/"):
+        g.gpuRoute(ctx, w, r, strings.TrimPrefix(path, "gpus/"))
+    case path == "leases":
+        if allow(w, r, http.MethodGet) {
+            g.listLeases(ctx, w)
+        }
+    case strings.HasPrefix(path, "leases/") && strings.HasSuffix(path, ":renew"):
+        if allow(w, r, http.MethodPost) {
+            g.renewLease(ctx, w, r, strings.TrimSuffix(strings.TrimPrefix(path, "leases/"), ":renew"))
+        }
+    case path == "jobs":
+        switch r.Method {
+        case http.MethodGet:
+            g.listJobs(ctx, w, r)
+        case http.MethodPost:
+            g.submitJob(ctx, w, r)
+        default:
+            allow(w, r, http.MethodGet, http.MethodPost)
+        }
+    case strings.HasPrefix(path, "jobs/"):
+        g.jobRoute(ctx, w, r, strings.TrimPrefix(path, "jobs/"))
+    case strings.HasPrefix(path, "files/"):
+        g.fileRoute(ctx, w, r, strings.TrimPrefix(path, "files/"))
+    default:
+        http.NotFound(w, r)
+    }
+}
+
+// outgoingContext 将HTTP请求的认证信息转换为gRPC元数据：
+// Authorization 头原样转发；TLS客户端证书已校验时转发证书CN；同时转发客户端地址用于审计
+func outgoingContext(r *http.Request) context.Context {
+    md := metadata.Pairs(auth.ForwardedForKey, clientIP(r))
+    if v := r.Header.Get("Authorization"); v != "" {
+        md.Set("authorization", v)
+    }
+    if r.TLS != nil && len(r.TLS.VerifiedChains) > 0 && len(r.TLS.VerifiedChains[0]) > 0 {
+        md.Set(auth.ForwardedUserKey, r.TLS.VerifiedChains[0][0].Subject.CommonName)
+    }
+    return metadata.NewOutgoingContext(r.Context(), md)
+}
+
+// clientIP 返回HTTP客户端地址（不信任 X-Forwarded-For 头）
+func clientIP(r *http.Request) string {
+    if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+        return host
+    }
+    return r.RemoteAddr
+}
+
+// allow 检查请求方法，不允许时写入405
+func allow(w http.ResponseWriter, r *http.Request, methods ...string) bool {
+    for _, m := range methods {
+        if r.Method == m {
+            return true
+        }
+    }
+    w.Header().Set("Allow", strings.Join(methods, ", "))
+    writeJSON(w, http.StatusMethodNotAllowed, errorBody{Code: "MethodNotAllowed", Message: r.Method + " not allowed"})
+    return false
+}
+
+var (
+    marshaler   = protojson.MarshalOptions{EmitUnpopulated: true}
+    unmarshaler = protojson.UnmarshalOptions{DiscardUnknown: true}
+)
+
+// readBody 将JSON请求体解析到 msg，空请求体保持零值
+func readBody(w http.ResponseWriter, r *http.Request, msg proto.Message) bool {
+    data, err := io.ReadAll(io.LimitReader(r.Body, 1<<20))
+    if err != nil {
+        writeError(w, status.Errorf(codes.InvalidArgument, "read request body: %v", err))
+        return false
+    }
+    if len(strings.TrimSpace(string(data))) == 0 {
+        return true
+    }
+    if err := unmarshaler.Unmarshal(data, msg); err != nil {
+        writeError(w, status.Errorf(codes.InvalidArgument, "invalid JSON body: %v", err))
+        return false
+    }
+    return true
+}
+
+// writeProto 以JSON写入响应消息；err 非nil时写入错误
+func writeProto(w http.ResponseWriter, msg proto.Message, err error) {
+    writeResult(w, msg, true, err)
+}
+
+// writeResult 写入带 ok 字段的响应消息；ok=false 时使用409，HTTP客户端可直接按状态码判断
+func writeResult(w http.ResponseWriter, msg proto.Message, ok bool, err error) {
+    if err != nil {
+        writeError(w, err)
+        return
+    }
+    data, err := marshaler.Marshal(msg)
+    if err != nil {
+        writeError(w, err)
+        return
+    }
+    w.Header().Set("Content-Type", "application/json")
+    if !ok {
+        w.WriteHeader(http.StatusConflict)
+    }
+    w.Write(data)
+}
+
+// writeJSON 写入普通JSON响应
+func writeJSON(w http.ResponseWriter, code int, v interface{}) {
+    w.Header().Set("Content-Type", "application/json")
+    w.WriteHeader(code)
+    json.NewEncoder(w).Encode(v)
+}
+
+// errorBody 错误响应
+type errorBody struct {
+    Code    string `json:"code"`    // gRPC状态码名称
+    Message string `json:"message"` // 错误信息
+}
+
+// writeError 将gRPC错误转换为对应的HTTP状态码
+func writeError(w http.ResponseWriter, err error) {
+    st := status.Convert(err)
+    writeJSON(w, httpStatus(st.Code()), errorBody{Code: st.Code().String(), Message: st.Message()})
+}
+
+// httpStatus gRPC状态码到HTTP状态码的映射
+func httpStatus(c codes.Code) int {
+    switch c {
+    case codes.OK:
+        return http.StatusOK
+    case codes.InvalidArgument, codes.OutOfRange:
+        return http.StatusBadRequest
+    case codes.Unauthenticated:
+        return http.StatusUnauthorized
+    case codes.PermissionDenied:
+        return http.StatusForbidden
+    case codes.NotFound:
+        return http.StatusNotFound
+    case codes.AlreadyExists, codes.Aborted, codes.FailedPrecondition:
+        return http.StatusConflict
+    case codes.ResourceExhausted:
+        return http.StatusTooManyRequests
+    case codes.Unimplemented:
+        return http.StatusNotImplemented
+    case codes.Unavailable:
+        return http.StatusServiceUnavailable
+    case codes.DeadlineExceeded:
+        return http.StatusGatewayTimeout
+    case codes.Canceled:
+        return 499 // 客户端关闭连接
+    }
+    return http.StatusInternalServerError
+}
@@ -0,0 +1,181 @@
+package gateway
+
+import (
+    "context"
+    "encoding/json"
+    "net/http"
+    "net/http/httptest"
+    "strings"
+    "testing"
+
+    "google.golang.org/grpc"
+    "google.golang.org/grpc/codes"
+    "google.golang.org/grpc/status"
+    pb "github.com/hiicl/GPU-over-IP-AC922/proto"
+)
+
+// recordingClient 记录网关发出的调用；未实现的方法调用时 panic
+type recordingClient struct {
+    pb.GPUServiceClient
+    calls []string // "方法 UUID"
+    err   error    // 非nil时所有调用返回该错误
+    ok    bool     // 租约和确认消息的 ok 字段
+}
+
+func (c *recordingClient) record(method, uuid string) {
+    c.calls = append(c.calls, method+" "+uuid)
+}
+
+func (c *recordingClient) GetGPUStatus(_ context.Context, in *pb.GPURequest, _ ...grpc.CallOption) (*pb.GPUStatus, error) {
+    c.record("GetGPUStatus", in.Uuid)
+    return &pb.GPUStatus{Uuid: in.Uuid}, c.err
+}
+
+func (c *recordingClient) GetGPUDetails(_ context.Context, in *pb.GPURequest, _ ...grpc.CallOption) (*pb.GPUDetails, error) {
+    c.record("GetGPUDetails", in.Uuid)
+    return &pb.GPUDetails{Uuid: in.Uuid}, c.err
+}
+
+func (c *recordingClient) ListGPUProcesses(_ context.Context, in *pb.GPURequest, _ ...grpc.CallOption) (*pb.GPUProcessList, error) {
+    c.record("ListGPUProcesses", in.Uuid)
+    return &pb.GPUProcessList{}, c.err
+}
+
+func (c *recordingClient) AcquireGPU(_ context.Context, in *pb.GPURequest, _ ...grpc.CallOption) (*pb.Lease, error) {
+    c.record("AcquireGPU", in.Uuid)
+    return &pb.Lease{Ok: c.ok, Uuid: in.Uuid}, c.err
+}
+
+func (c *recordingClient) ReleaseGPU(_ context.Context, in *pb.GPURequest, _ ...grpc.CallOption) (*pb.Ack, error) {
+    c.record("ReleaseGPU", in.Uuid)
+    return &pb.Ack{Ok: c.ok}, c.err
+}
+
+func (c *recordingClient) UncordonGPU(_ context.Context, in *pb.GPURequest, _ ...grpc.CallOption) (*pb.Ack, error) {
+    c.record("UncordonGPU", in.Uuid)
+    return &pb.Ack{Ok: c.ok}, c.err
+}
+
+func (c *recordingClient) RunCommand(_ context.Context, in *pb.RunRequest, _ ...grpc.CallOption) (*pb.RunResponse, error) {
+    c.record("RunCommand", in.Uuid)
+    return &pb.RunResponse{}, c.err
+}
+
+// newTestGateway 创建两个NUMA分组的网关：分组0包含 GPU-a 和旧版驱动格式的MIG实例，分组8包含 GPU-b
+func newTestGateway() (*Gateway, *recordingClient, *recordingClient) {
+    c0, c8 := &recordingClient{ok: true}, &recordingClient{ok: true}
+    g := New([]Backend{
+        {Node: 0, GPUs: []string{"GPU-a", "MIG-GPU-a/1/0"}, Client: c0},
+        {Node: 8, GPUs: []string{"GPU-b"}, Client: c8},
+    })
+    return g, c0, c8
+}
+
+func TestGPURoute(t *testing.T) {
+    tests := []struct {
+        method, path string
+        code         int
+        node         int    // 期望处理调用的分组
+        call         string // 期望的调用，为空表示不应调用后端
+    }{
+        {method: "GET", path: "/v1/gpus/GPU-a/status", code: 200, call: "GetGPUStatus GPU-a"},
+        {method: "GET", path: "/v1/gpus/GPU-a/details", code: 200, call: "GetGPUDetails GPU-a"},
+        {method: "GET", path: "/v1/gpus/GPU-b/processes", code: 200, node: 8, call: "ListGPUProcesses GPU-b"},
+        {method: "POST", path: "/v1/gpus/GPU-a:acquire", code: 200, call: "AcquireGPU GPU-a"},
+        {method: "POST", path: "/v1/gpus/GPU-b:release", code: 200, node: 8, call: "ReleaseGPU GPU-b"},
+        {method: "POST", path: "/v1/gpus/GPU-b:uncordon", code: 200, node: 8, call: "UncordonGPU GPU-b"},
+        {method: "POST", path: "/v1/gpus/GPU-a:run", code: 200, call: "RunCommand GPU-a"},
+
+        // 转义的MIG实例UUID：拆分动作和子路径后再还原 "/"
+        {method: "GET", path: "/v1/gpus/MIG-GPU-a%2F1%2F0/status", code: 200, call: "GetGPUStatus MIG-GPU-a/1/0"},
+        {method: "POST", path: "/v1/gpus/MIG-GPU-a%2F1%2F0:acquire", code: 200, call: "AcquireGPU MIG-GPU-a/1/0"},
+        {method: "POST", path: "/v1/gpus/MIG-GPU-a%2f1%2f0:release", code: 200, call: "ReleaseGPU MIG-GPU-a/1/0"},
+        {method: "GET", path: "/v1/gpus/MIG-GPU-a/1/0/status", code: 404}, // 未转义
+
+        {method: "GET", path: "/v1/gpus/GPU-a:acquire", code: 405},
+        {method: "POST", path: "/v1/gpus/GPU-a/status", code: 405},
+        {method: "GET", path: "/v1/gpus/GPU-a/bogus", code: 404},
+        {method: "POST", path: "/v1/gpus/GPU-a:bogus", code: 404},
+        {method: "GET", path: "/v1/gpus/GPU-a/status:watch", code: 404},
+        {method: "GET", path: "/v1/gpus/GPU-c/status", code: 404},
+    }
+    for _, tt := range tests {
+        t.Run(tt.method+" "+tt.path, func(t *testing.T) {
+            g, c0, c8 := newTestGateway()
+            w := httptest.NewRecorder()
+            g.ServeHTTP(w, httptest.NewRequest(tt.method, tt.path, nil))
+            if w.Code != tt.code {
+                t.Errorf("status = %d, want %d; body %s", w.Code, tt.code, w.Body.String())
+            }
+            calls := append(append([]string(nil), c0.calls...), c8.calls...)
+            if tt.call == "" {
+                if len(calls) != 0 {
+                    t.Errorf("calls = %v, want none", calls)
+                }
+                return
+            }
+            want := c0
+            if tt.node == 8 {
+                want = c8
+            }
+            if len(calls) != 1 || len(want.calls) != 1 || want.calls[0] != tt.call {
+                t.Errorf("calls = %v on NUMA 0 and %v on NUMA 8, want %q on NUMA %d", c0.calls, c8.calls, tt.call, tt.node)
+            }
+        })
+    }
+}
+
+// TestGPURouteErrors gRPC错误转换为对应的HTTP状态码和错误体，ok=false 的结果返回409
+func TestGPURouteErrors(t *testing.T) {
+    g, c0, _ := newTestGateway()
+    c0.err = status.Error(codes.PermissionDenied, "lease held by another user")
+    w := httptest.NewRecorder()
+    g.ServeHTTP(w, httptest.NewRequest("POST", "/v1/gpus/GPU-a:release", strings.NewReader(`{"leaseId": "x"}`)))
+    var body errorBody
+    if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+        t.Fatalf("error body %q: %v", w.Body.String(), err)
+    }
+    if w.Code != http.StatusForbidden || body.Code != "PermissionDenied" || body.Message != "lease held by another user" {
+        t.Errorf("response = %d %+v, want 403 PermissionDenied", w.Code, body)
+    }
+
+    c0.err, c0.ok = nil, false
+    w = httptest.NewRecorder()
+    g.ServeHTTP(w, httptest.NewRequest("POST", "/v1/gpus/GPU-a:acquire", nil))
+    if w.Code != http.StatusConflict {
+        t.Errorf("acquire with ok=false = %d, want 409", w.Code)
+    }
+
+    w = httptest.NewRecorder()
+    g.ServeHTTP(w, httptest.NewRequest("POST", "/v1/gpus/GPU-a:acquire", strings.NewReader(`{"uuid": `)))
+    if w.Code != http.StatusBadRequest {
+        t.Errorf("acquire with invalid JSON = %d, want 400", w.Code)
+    }
+}
+
+func TestHTTPStatus(t *testing.T) {
+    tests := map[codes.Code]int{
+        codes.OK:                 200,
+        codes.InvalidArgument:    400,
+        codes.OutOfRange:         400,
+        codes.Unauthenticated:    401,
+        codes.PermissionDenied:   403,
+        codes.NotFound:           404,
+        codes.AlreadyExists:      409,
+        codes.Aborted:            409,
+        codes.FailedPrecondition: 409,
+        codes.ResourceExhausted:  429,
+        codes.Canceled:           499,
+        codes.Unknown:            500,
+        codes.Internal:           500,
+        codes.DataLoss:           500,
+        codes.Unimplemented:      501,
+        codes.Unavailable:        503,
+        codes.DeadlineExceeded:   504,
+    }
+    for c, want := range tests {
+        if got := httpStatus(c); got != want {
+            t.Errorf("httpStatus(%s) = %d, want %d", c, got, want)
+        }
+    }
+}
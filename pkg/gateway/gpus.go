@@ -0,0 +1,305 @@
+package gateway
+
+import (
+    "context"
+    "io"
+    "net/http"
+    "net/url"
+    "strconv"
+    "strings"
+
+    "google.golang.org/grpc/codes"
+    "google.golang.org/grpc/status"
+    pb "github.com/hiicl/GPU-over-IP-AC922/proto"
+)
+
+// gpus.go 实现节点信息、GPU查询、租约和命令执行路由
+
+func (g *Gateway) serverInfo(ctx context.Context, w http.ResponseWriter) {
+    resp, err := g.any().GetServerInfo(ctx, &pb.Void{})
+    writeProto(w, resp, err)
+}
+
+func (g *Gateway) nodeInfo(ctx context.Context, w http.ResponseWriter) {
+    resp, err := g.any().GetNodeInfo(ctx, &pb.Void{})
+    writeProto(w, resp, err)
+}
+
+// listGPUs 合并所有NUMA分组的GPU列表，采样时间取最旧的分组
+func (g *Gateway) listGPUs(ctx context.Context, w http.ResponseWriter) {
+    list := &pb.GPUList{}
+    for i, b := range g.backends {
+        resp, err := b.Client.ListGPUs(ctx, &pb.Void{})
+        if err != nil {
+            writeError(w, err)
+            return
+        }
+        list.Gpus = append(list.Gpus, resp.Gpus...)
+        if i == 0 || resp.Timestamp < list.Timestamp {
+            list.Timestamp = resp.Timestamp
+        }
+        if resp.AgeMs > list.AgeMs {
+            list.AgeMs = resp.AgeMs
+        }
+        list.Stale = list.Stale || resp.Stale
+    }
+    writeProto(w, list, nil)
+}
+
+// listProcesses 合并所有NUMA分组GPU上的进程
+func (g *Gateway) listProcesses(ctx context.Context, w http.ResponseWriter) {
+    list := &pb.GPUProcessList{}
+    for _, b := range g.backends {
+        resp, err := b.Client.ListGPUProcesses(ctx, &pb.GPURequest{})
+        if err != nil {
+            writeError(w, err)
+            return
+        }
+        list.Processes = append(list.Processes, resp.Processes...)
+    }
+    writeProto(w, list, nil)
+}
+
+// gpuRoute 处理 /v1/gpus/{uuid}... 路由
+// 路由按转义后的路径拆分，旧版驱动的MIG实例UUID（MIG-GPU-<uuid>/<gi>/<ci>）
+// 需由客户端将 "/" 转义为 %2F，拆分后再还原
+func (g *Gateway) gpuRoute(ctx context.Context, w http.ResponseWriter, r *http.Request, rest string) {
+    if escaped := strings.TrimPrefix(r.URL.EscapedPath(), "/v1/gpus/"); escaped != r.URL.EscapedPath() {
+        rest = escaped
+    }
+    uuid, action := rest, ""
+    if i := strings.LastIndex(rest, ":"); i >= 0 {
+        uuid, action = rest[:i], rest[i+1:]
+    }
+    sub := ""
+    if i := strings.Index(uuid, "/"); i >= 0 {
+        uuid, sub = uuid[:i], uuid[i+1:]
+    }
+    uuid, err := url.PathUnescape(uuid)
+    if err != nil {
+        writeError(w, status.Errorf(codes.InvalidArgument, "invalid GPU UUID in path: %v", err))
+        return
+    }
+    client := g.gpu(w, uuid)
+    if client == nil {
+        return
+    }
+
+    switch {
+    case sub == "status" && action == "":
+        if allow(w, r, http.MethodGet) {
+            resp, err := client.GetGPUStatus(ctx, &pb.GPURequest{Uuid: uuid})
+            writeProto(w, resp, err)
+        }
+    case sub == "details" && action == "":
+        if allow(w, r, http.MethodGet) {
+            resp, err := client.GetGPUDetails(ctx, &pb.GPURequest{Uuid: uuid})
+            writeProto(w, resp, err)
+        }
+    case sub == "processes" && action == "":
+        if allow(w, r, http.MethodGet) {
+            resp, err := client.ListGPUProcesses(ctx, &pb.GPURequest{Uuid: uuid})
+            writeProto(w, resp, err)
+        }
+    case sub == "" && action == "watch":
+        if allow(w, r, http.MethodGet) {
+            g.watch(ctx, w, r, uuid)
+        }
+    case sub == "" && action == "acquire":
+        if allow(w, r, http.MethodPost) {
+            req := &pb.GPURequest{}
+            if readBody(w, r, req) {
+                req.Uuid = uuid
+                resp, err := client.AcquireGPU(ctx, req)
+                writeResult(w, resp, resp.GetOk(), err)
+            }
+        }
+    case sub == "" && action == "release":
+        if allow(w, r, http.MethodPost) {
+            req := &pb.GPURequest{}
+            if readBody(w, r, req) {
+                req.Uuid = uuid
+                resp, err := client.ReleaseGPU(ctx, req)
+                writeResult(w, resp, resp.GetOk(), err)
+            }
+        }
+    case sub == "" && action == "uncordon":
+        if allow(w, r, http.MethodPost) {
+            resp, err := client.UncordonGPU(ctx, &pb.GPURequest{Uuid: uuid})
+            writeResult(w, resp, resp.GetOk(), err)
+        }
+    case sub == "" && action == "run":
+        if allow(w, r, http.MethodPost) {
+            req := &pb.RunRequest{}
+            if readBody(w, r, req) {
+                req.Uuid = uuid
+                if wantsStream(r) {
+                    runStream(ctx, w, client, req)
+                } else {
+                    resp, err := client.RunCommand(ctx, req)
+                    writeProto(w, resp, err)
+                }
+            }
+        }
+    default:
+        http.NotFound(w, r)
+    }
+}
+
+// wantsStream 判断客户端是否请求SSE流
+func wantsStream(r *http.Request) bool {
+    if v, err := strconv.ParseBool(r.URL.Query().Get("stream")); err == nil {
+        return v
+    }
+    return strings.Contains(r.Header.Get("Accept"), "text/event-stream")
+}
+
+// acquireGPUs 依次在各NUMA分组尝试多GPU占用，返回第一个成功的结果
+// 占用不跨NUMA分组：每个分组只能分配其绑定的GPU
+func (g *Gateway) acquireGPUs(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+    req := &pb.MultiGPURequest{}
+    if !readBody(w, r, req) {
+        return
+    }
+    var last *pb.LeaseList
+    for _, b := range g.backends {
+        resp, err := b.Client.AcquireGPUs(ctx, req)
+        if err != nil {
+            writeError(w, err)
+            return
+        }
+        if resp.Ok {
+            writeProto(w, resp, nil)
+            return
+        }
+        last = resp
+    }
+    writeResult(w, last, false, nil)
+}
+
+// listLeases 合并所有NUMA分组的有效租约
+func (g *Gateway) listLeases(ctx context.Context, w http.ResponseWriter) {
+    list := &pb.LeaseList{Ok: true}
+    for _, b := range g.backends {
+        resp, err := b.Client.ListLeases(ctx, &pb.Void{})
+        if err != nil {
+            writeError(w, err)
+            return
+        }
+        list.Leases = append(list.Leases, resp.Leases...)
+    }
+    writeProto(w, list, nil)
+}
+
+// renewLease 续期租约（调度器在分组间共享，任一分组均可处理）
+func (g *Gateway) renewLease(ctx context.Context, w http.ResponseWriter, r *http.Request, leaseID string) {
+    req := &pb.LeaseRequest{}
+    if !readBody(w, r, req) {
+        return
+    }
+    req.LeaseId = leaseID
+    resp, err := g.any().RenewLease(ctx, req)
+    writeResult(w, resp, resp.GetOk(), err)
+}
+
+// runStream 通过SSE转发 RunCommandStream：output 事件为输出片段，exit 事件携带退出码
+func runStream(ctx context.Context, w http.ResponseWriter, client pb.GPUServiceClient, req *pb.RunRequest) {
+    stream, err := client.RunCommandStream(ctx, req)
+    if err != nil {
+        writeError(w, err)
+        return
+    }
+    // 第一条消息到达前的错误（如租约无效）仍可返回HTTP错误码
+    first, err := stream.Recv()
+    if err != nil {
+        writeError(w, err)
+        return
+    }
+    sse := newSSE(w)
+    if sse == nil {
+        return
+    }
+    for msg := first; ; {
+        if msg.Done {
+            sse.send("exit", exitEvent{ExitCode: msg.ExitCode})
+            return
+        }
+        name := "stdout"
+        if msg.Stream == pb.OutputStream_STDERR {
+            name = "stderr"
+        }
+        if sse.send("output", outputEvent{Stream: name, Data: string(msg.Data), Timestamp: msg.Timestamp}) != nil {
+            return // 客户端已断开，ctx 取消后命令随之终止
+        }
+        if msg, err = stream.Recv(); err != nil {
+            if err != io.EOF {
+                sse.fail(err)
+            }
+            return
+        }
+    }
+}
+
+// watch 通过SSE推送GPU状态；uuid 为空时合并所有NUMA分组的全部GPU
+func (g *Gateway) watch(ctx context.Context, w http.ResponseWriter, r *http.Request, uuid string) {
+    q := r.URL.Query()
+    req := &pb.GPURequest{Uuid: uuid}
+    if v, err := strconv.Atoi(q.Get("intervalMs")); err == nil {
+        req.IntervalMs = int32(v)
+    }
+    req.OnChange, _ = strconv.ParseBool(q.Get("onChange"))
+
+    clients := []pb.GPUServiceClient{g.byGPU[uuid]}
+    if uuid == "" {
+        clients = clients[:0]
+        for _, b := range g.backends {
+            clients = append(clients, b.Client)
+        }
+    }
+
+    ctx, cancel := context.WithCancel(ctx)
+    defer cancel()
+    updates := make(chan *pb.GPUStatus)
+    errs := make(chan error, len(clients))
+    for _, c := range clients {
+        stream, err := c.WatchGPUStatus(ctx, req)
+        if err != nil {
+            writeError(w, err)
+            return
+        }
+        go func(stream pb.GPUService_WatchGPUStatusClient) {
+            for {
+                st, err := stream.Recv()
+                if err != nil {
+                    errs <- err
+                    return
+                }
+                select {
+                case updates <- st:
+                case <-ctx.Done():
+                    return
+                }
+            }
+        }(stream)
+    }
+
+    sse := newSSE(w)
+    if sse == nil {
+        return
+    }
+    for {
+        select {
+        case st := <-updates:
+            if sse.send("status", st) != nil {
+                return
+            }
+        case err := <-errs:
+            if status.Code(err) != codes.Canceled {
+                sse.fail(err)
+            }
+            return
+        case <-ctx.Done():
+            return
+        }
+    }
+}
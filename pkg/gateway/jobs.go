@@ -0,0 +1,185 @@
+package gateway
+
+import (
+    "context"
+    "io"
+    "net/http"
+    "path/filepath"
+    "strconv"
+    "strings"
+    "time"
+
+    "google.golang.org/grpc/codes"
+    "google.golang.org/grpc/status"
+    pb "github.com/hiicl/GPU-over-IP-AC922/proto"
+)
+
+// jobs.go 实现异步作业路由；作业按GPU归属于NUMA分组，查询时依次尝试各分组
+
+// jobPollInterval 作业输出轮询间隔
+const jobPollInterval = 500 * time.Millisecond
+
+// listJobs 合并所有NUMA分组的作业列表
+// 查询参数：state（running/succeeded/failed/canceled，可重复或逗号分隔）、uuid、owner
+func (g *Gateway) listJobs(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+    q := r.URL.Query()
+    filter := &pb.JobFilter{Uuid: q.Get("uuid"), Owner: q.Get("owner")}
+    for _, v := range q["state"] {
+        for _, name := range strings.Split(v, ",") {
+            st, ok := pb.JobState_value["JOB_"+strings.ToUpper(strings.TrimSpace(name))]
+            if !ok {
+                writeError(w, status.Errorf(codes.InvalidArgument, "unknown job state %q", name))
+                return
+            }
+            filter.States = append(filter.States, pb.JobState(st))
+        }
+    }
+    list := &pb.JobList{}
+    for _, b := range g.backends {
+        resp, err := b.Client.ListJobs(ctx, filter)
+        if err != nil {
+            writeError(w, err)
+            return
+        }
+        list.Jobs = append(list.Jobs, resp.Jobs...)
+    }
+    writeProto(w, list, nil)
+}
+
+// submitJob 将作业提交到目标GPU所属的NUMA分组
+func (g *Gateway) submitJob(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+    req := &pb.JobRequest{}
+    if !readBody(w, r, req) {
+        return
+    }
+    client := g.gpu(w, req.Uuid)
+    if client == nil {
+        return
+    }
+    resp, err := client.SubmitJob(ctx, req)
+    if err == nil {
+        w.Header().Set("Location", "/v1/jobs/"+resp.Id)
+    }
+    writeProto(w, resp, err)
+}
+
+// findJob 在各NUMA分组中查找作业，返回作业及其所属分组的客户端
+func (g *Gateway) findJob(ctx context.Context, id string) (*pb.Job, pb.GPUServiceClient, error) {
+    for _, b := range g.backends {
+        job, err := b.Client.GetJob(ctx, &pb.JobID{Id: id})
+        if status.Code(err) == codes.NotFound {
+            continue
+        }
+        return job, b.Client, err
+    }
+    return nil, nil, status.Errorf(codes.NotFound, "job %s not found", id)
+}
+
+// jobRoute 处理 /v1/jobs/{id}... 路由
+func (g *Gateway) jobRoute(ctx context.Context, w http.ResponseWriter, r *http.Request, rest string) {
+    switch {
+    case strings.HasSuffix(rest, ":cancel"):
+        if !allow(w, r, http.MethodPost) {
+            return
+        }
+        req := &pb.CancelJobRequest{}
+        if !readBody(w, r, req) {
+            return
+        }
+        req.Id = strings.TrimSuffix(rest, ":cancel")
+        _, client, err := g.findJob(ctx, req.Id)
+        if err != nil {
+            writeError(w, err)
+            return
+        }
+        resp, err := client.CancelJob(ctx, req)
+        writeProto(w, resp, err)
+    case strings.HasSuffix(rest, "/output"):
+        if allow(w, r, http.MethodGet) {
+            g.jobOutput(ctx, w, r, strings.TrimSuffix(rest, "/output"))
+        }
+    case !strings.ContainsAny(rest, "/:"):
+        if allow(w, r, http.MethodGet) {
+            job, _, err := g.findJob(ctx, rest)
+            writeProto(w, job, err)
+        }
+    default:
+        http.NotFound(w, r)
+    }
+}
+
+// jobOutput 通过SSE推送作业日志：轮询下载日志新增部分并发送 output 事件，
+// 作业结束且日志读完后发送 done 事件（携带作业最终状态）
+func (g *Gateway) jobOutput(ctx context.Context, w http.ResponseWriter, r *http.Request, id string) {
+    job, client, err := g.findJob(ctx, id)
+    if err != nil {
+        writeError(w, err)
+        return
+    }
+    if filepath.IsAbs(job.LogPath) {
+        writeError(w, status.Errorf(codes.FailedPrecondition, "log of job %s is outside the file sandbox", id))
+        return
+    }
+    var offset int64
+    if v := r.URL.Query().Get("offset"); v != "" {
+        if offset, err = strconv.ParseInt(v, 10, 64); err != nil || offset < 0 {
+            writeError(w, status.Errorf(codes.InvalidArgument, "invalid offset %q", v))
+            return
+        }
+    }
+
+    sse := newSSE(w)
+    if sse == nil {
+        return
+    }
+    ticker := time.NewTicker(jobPollInterval)
+    defer ticker.Stop()
+    for {
+        // 先取作业状态再读日志：作业已结束时，本轮读到的就是完整日志
+        running := job.State == pb.JobState_JOB_RUNNING
+        if offset, err = tailLog(ctx, client, sse, job.LogPath, offset); err != nil {
+            sse.fail(err)
+            return
+        }
+        if !running {
+            sse.send("done", job)
+            return
+        }
+        select {
+        case <-ctx.Done():
+            return
+        case <-ticker.C:
+        }
+        if job, err = client.GetJob(ctx, &pb.JobID{Id: id}); err != nil {
+            sse.fail(err)
+            return
+        }
+    }
+}
+
+// tailLog 从 offset 开始下载日志并逐块发送，返回新的偏移
+// 每次轮询都会调用，因此跳过服务端对整个日志的SHA-256计算
+func tailLog(ctx context.Context, client pb.GPUServiceClient, sse *sseWriter, path string, offset int64) (int64, error) {
+    stream, err := client.DownloadFile(ctx, &pb.FileRequest{Path: path, Offset: offset, SkipChecksum: true})
+    if err != nil {
+        return offset, err
+    }
+    for {
+        chunk, err := stream.Recv()
+        if err == io.EOF {
+            return offset, nil
+        }
+        if err != nil {
+            return offset, err
+        }
+        data := chunk.GetData()
+        if len(data) == 0 {
+            continue // header
+        }
+        offset += int64(len(data))
+        ev := outputEvent{Stream: "log", Data: string(data), Offset: offset, Timestamp: time.Now().UnixMilli()}
+        if err := sse.send("output", ev); err != nil {
+            return offset, err
+        }
+    }
+}
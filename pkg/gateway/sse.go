@@ -0,0 +1,73 @@
+package gateway
+
+import (
+    "encoding/json"
+    "fmt"
+    "net/http"
+
+    "google.golang.org/grpc/status"
+    "google.golang.org/protobuf/proto"
+)
+
+// sse.go 实现 Server-Sent Events 输出：每个事件为 "event: <名称>" 加一行JSON数据
+
+// sseWriter SSE事件写入器
+type sseWriter struct {
+    w http.ResponseWriter
+    f http.Flusher
+}
+
+// newSSE 写入SSE响应头；ResponseWriter 不支持刷新时写入500并返回nil
+func newSSE(w http.ResponseWriter) *sseWriter {
+    f, ok := w.(http.Flusher)
+    if !ok {
+        http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+        return nil
+    }
+    h := w.Header()
+    h.Set("Content-Type", "text/event-stream")
+    h.Set("Cache-Control", "no-cache")
+    h.Set("Connection", "keep-alive")
+    h.Set("X-Accel-Buffering", "no") // 关闭反向代理缓冲
+    w.WriteHeader(http.StatusOK)
+    f.Flush()
+    return &sseWriter{w: w, f: f}
+}
+
+// send 发送一个事件，proto 消息使用 protojson 编码，其他值使用 encoding/json
+func (s *sseWriter) send(event string, v interface{}) error {
+    var data []byte
+    var err error
+    if msg, ok := v.(proto.Message); ok {
+        data, err = marshaler.Marshal(msg)
+    } else {
+        data, err = json.Marshal(v)
+    }
+    if err != nil {
+        return err
+    }
+    if _, err := fmt.Fprintf(s.w, "event: %s\ndata: %s\n\n", event, data); err != nil {
+        return err
+    }
+    s.f.Flush()
+    return nil
+}
+
+// fail 发送 error 事件（响应头已发出，无法再返回HTTP错误码）
+func (s *sseWriter) fail(err error) {
+    st := status.Convert(err)
+    s.send("error", errorBody{Code: st.Code().String(), Message: st.Message()})
+}
+
+// outputEvent 命令或作业输出片段
+type outputEvent struct {
+    Stream    string `json:"stream"`              // stdout / stderr / log
+    Data      string `json:"data"`                // 输出内容（UTF-8，无效字节被替换）
+    Offset    int64  `json:"offset,omitempty"`    // 作业日志中该片段之后的偏移，可用于断线续传
+    Timestamp int64  `json:"timestamp,omitempty"` // 读取时间（Unix 毫秒）
+}
+
+// exitEvent 命令结束
+type exitEvent struct {
+    ExitCode int32 `json:"exitCode"`
+}
@@ -0,0 +1,106 @@
+package gpu
+
+import (
+    "context"
+    "io"
+    "os"
+    "os/exec"
+
+    "github.com/creack/pty"
+)
+
+// exec.go 提供交互式命令会话：可选分配PTY，支持写入标准输入和调整窗口大小
+// 会话中的命令同样绑定到指定GPU（CUDA_VISIBLE_DEVICES）
+
+// eot TTY模式下表示输入结束的控制字符（Ctrl-D）
+const eot = "\x04"
+
+// Session 表示一个交互式命令会话
+// TTY模式下输入输出都经过PTY主端；否则标准输入使用管道，标准输出和标准错误合并到同一管道
+type Session struct {
+    cmd    *exec.Cmd
+    tty    *os.File       // PTY主端（仅TTY模式）
+    stdin  io.WriteCloser // 标准输入（非TTY模式）
+    output io.ReadCloser  // 合并后的输出
+    untrack func()        // 注销已登记的命令
+}
+
+// StartSession 在指定GPU上启动交互式命令
+// ctx: 取消后终止命令进程
+// tty: 是否分配PTY；rows/cols 为初始窗口大小（为0时使用默认大小）
+// term: TTY模式下的TERM环境变量（为空时不设置）
+func StartSession(ctx context.Context, uuid, cmdline string, tty bool, rows, cols uint16, term string) (*Session, error) {
+    cmd := newCommand(ctx, uuid, cmdline)
+    if tty {
+        if term != "" {
+            cmd.Env = append(cmd.Env, "TERM="+term)
+        }
+        // pty会以setsid启动新会话，会话首进程本身即进程组首进程，不能再调用setpgid
+        cmd.SysProcAttr.Setpgid = false
+        var size *pty.Winsize
+        if rows > 0 && cols > 0 {
+            size = &pty.Winsize{Rows: rows, Cols: cols}
+        }
+        f, err := pty.StartWithSize(cmd, size)
+        if err != nil {
+            return nil, err
+        }
+        return &Session{cmd: cmd, tty: f, output: f, untrack: track(cmd, uuid, SourceExec)}, nil
+    }
+
+    stdin, err := cmd.StdinPipe()
+    if err != nil {
+        return nil, err
+    }
+    r, w, err := os.Pipe()
+    if err != nil {
+        return nil, err
+    }
+    cmd.Stdout = w
+    cmd.Stderr = w
+    if err := cmd.Start(); err != nil {
+        r.Close()
+        w.Close()
+        return nil, err
+    }
+    w.Close() // 写端已由子进程持有，父进程关闭后子进程退出时读端可读到EOF
+    return &Session{cmd: cmd, stdin: stdin, output: r, untrack: track(cmd, uuid, SourceExec)}, nil
+}
+
+// Read 读取命令输出；命令退出且输出读完后返回错误（EOF或PTY的EIO）
+func (s *Session) Read(p []byte) (int, error) {
+    return s.output.Read(p)
+}
+
+// Write 写入命令标准输入
+func (s *Session) Write(p []byte) (int, error) {
+    if s.tty != nil {
+        return s.tty.Write(p)
+    }
+    return s.stdin.Write(p)
+}
+
+// CloseStdin 结束标准输入；TTY模式下发送Ctrl-D
+func (s *Session) CloseStdin() error {
+    if s.tty != nil {
+        _, err := s.tty.Write([]byte(eot))
+        return err
+    }
+    return s.stdin.Close()
+}
+
+// Resize 调整PTY窗口大小，非TTY模式下忽略
+func (s *Session) Resize(rows, cols uint16) error {
+    if s.tty == nil {
+        return nil
+    }
+    return pty.Setsize(s.tty, &pty.Winsize{Rows: rows, Cols: cols})
+}
+
+// Wait 等待命令退出并释放会话资源，返回退出码
+func (s *Session) Wait() int {
+    err := s.cmd.Wait()
+    s.untrack()
+    s.output.Close()
+    return exitCode(err)
+}
@@ -0,0 +1,80 @@
+package gpu
+
+import (
+    "fmt"
+    "os"
+    "os/exec"
+    "strconv"
+    "strings"
+    "sync"
+)
+
+// procs.go 登记服务端在GPU上启动的命令进程，并读取 /proc 中的父进程和进程组，
+// 用于把GPU上的计算进程沿进程树归属到启动它的命令
+
+// 命令来源
+const (
+    SourceRun  = "run"  // RunCommand / RunCommandStream
+    SourceExec = "exec" // 交互式会话
+)
+
+// Spawned 服务端启动的、仍在运行的命令
+type Spawned struct {
+    PID    int    // bash 进程ID
+    UUID   string // 启动时指定的GPU
+    Source string // 命令来源
+}
+
+var (
+    spawnedMu sync.Mutex
+    spawned   = make(map[int]Spawned)
+)
+
+// track 登记已启动的命令，返回命令退出后调用的注销函数
+func track(cmd *exec.Cmd, uuid, source string) func() {
+    pid := cmd.Process.Pid
+    spawnedMu.Lock()
+    spawned[pid] = Spawned{PID: pid, UUID: uuid, Source: source}
+    spawnedMu.Unlock()
+    return func() {
+        spawnedMu.Lock()
+        delete(spawned, pid)
+        spawnedMu.Unlock()
+    }
+}
+
+// SpawnedCommands 返回服务端启动的、仍在运行的命令，key: 进程ID
+func SpawnedCommands() map[int]Spawned {
+    spawnedMu.Lock()
+    defer spawnedMu.Unlock()
+    result := make(map[int]Spawned, len(spawned))
+    for pid, s := range spawned {
+        result[pid] = s
+    }
+    return result
+}
+
+// ProcParent 读取 /proc/<pid>/stat，返回父进程ID和进程组ID
+func ProcParent(pid int) (ppid, pgid int, err error) {
+    data, err := os.ReadFile("/proc/" + strconv.Itoa(pid) + "/stat")
+    if err != nil {
+        return 0, 0, err
+    }
+    // 格式为 "pid (comm) state ppid pgrp ..."，comm 中可能含空格和括号，从最后一个 ')' 之后解析
+    s := string(data)
+    i := strings.LastIndexByte(s, ')')
+    if i < 0 {
+        return 0, 0, fmt.Errorf("malformed /proc/%d/stat", pid)
+    }
+    fields := strings.Fields(s[i+1:])
+    if len(fields) < 3 {
+        return 0, 0, fmt.Errorf("malformed /proc/%d/stat", pid)
+    }
+    if ppid, err = strconv.Atoi(fields[1]); err != nil {
+        return 0, 0, fmt.Errorf("malformed /proc/%d/stat: %v", pid, err)
+    }
+    if pgid, err = strconv.Atoi(fields[2]); err != nil {
+        return 0, 0, fmt.Errorf("malformed /proc/%d/stat: %v", pid, err)
+    }
+    return ppid, pgid, nil
+}
@@ -0,0 +1,139 @@
+package gpu
+
+import (
+    "bytes"
+    "context"
+    "errors"
+    "os"
+    "os/exec"
+    "syscall"
+    "time"
+)
+
+// run.go 提供在指定GPU上执行命令的功能
+// 命令通过 bash -c 执行，并设置 CUDA_VISIBLE_DEVICES 使进程只能看到目标GPU
+
+// 输出流类型
+const (
+    StreamStdout = "stdout" // 标准输出
+    StreamStderr = "stderr" // 标准错误
+)
+
+// waitDelay 命令进程退出（或进程组被终止）后等待输出管道关闭的最长时间
+// 超时后强制关闭管道，避免脱离进程组的后代进程占用管道导致 Wait 和输出复制协程一直阻塞
+const waitDelay = 5 * time.Second
+
+// OutputChunk 表示命令输出中的一个片段
+// Stream: 输出来源（stdout 或 stderr）
+// Data: 输出内容
+// Timestamp: 读取到该片段的时间
+type OutputChunk struct {
+    Stream    string
+    Data      []byte
+    Timestamp time.Time
+}
+
+// newCommand 创建绑定到指定GPU的命令对象
+// 命令在独立进程组中运行，ctx取消时终止整个进程组（包括bash派生的子进程）
+// uuid: 目标GPU的UUID（写入CUDA_VISIBLE_DEVICES）
+// cmdline: 要执行的shell命令
+func newCommand(ctx context.Context, uuid, cmdline string) *exec.Cmd {
+    cmd := exec.CommandContext(ctx, "bash", "-c", cmdline)
+    cmd.Env = append(os.Environ(), "CUDA_VISIBLE_DEVICES="+uuid)
+    cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+    cmd.Cancel = func() error {
+        return syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+    }
+    cmd.WaitDelay = waitDelay
+    return cmd
+}
+
+// exitCode 从命令执行错误中提取退出码
+// 无法获取退出码时（如命令无法启动）返回-1
+func exitCode(err error) int {
+    if err == nil || errors.Is(err, exec.ErrWaitDelay) {
+        return 0 // ErrWaitDelay: 命令本身已成功退出，只是后代进程仍占用输出管道
+    }
+    var exitErr *exec.ExitError
+    if errors.As(err, &exitErr) {
+        return exitErr.ExitCode()
+    }
+    return -1
+}
+
+// RunCommand 在指定GPU上同步执行命令
+// 返回合并后的标准输出/标准错误以及命令退出码
+func RunCommand(uuid, cmdline string) (string, int) {
+    cmd := newCommand(context.Background(), uuid, cmdline)
+    var out bytes.Buffer
+    cmd.Stdout = &out
+    cmd.Stderr = &out
+    err := cmd.Start()
+    if err == nil {
+        untrack := track(cmd, uuid, SourceRun)
+        err = cmd.Wait()
+        untrack()
+    }
+    if err != nil && exitCode(err) < 0 {
+        // 命令无法启动时把错误信息作为输出返回
+        out.WriteString(err.Error())
+    }
+    return out.String(), exitCode(err)
+}
+
+// StreamCommand 在指定GPU上执行命令，并以片段形式实时回调输出
+// ctx: 取消上下文，取消后命令所在进程组会被终止
+// onChunk: 每读取到一段输出时调用（串行调用，无需额外加锁）；返回错误将终止命令
+// 返回命令退出码和可能的错误（命令启动失败或回调失败）
+func StreamCommand(ctx context.Context, uuid, cmdline string, onChunk func(OutputChunk) error) (int, error) {
+    ctx, cancel := context.WithCancel(ctx)
+    defer cancel()
+
+    // 输出由 exec 的复制协程写入 chunkWriter，Wait 与读取同时进行：
+    // 命令进程退出后最多再等待 waitDelay，仍占用输出的后代进程不会使调用方一直阻塞
+    chunks := make(chan OutputChunk)
+    cmd := newCommand(ctx, uuid, cmdline)
+    cmd.Stdout = chunkWriter{stream: StreamStdout, out: chunks}
+    cmd.Stderr = chunkWriter{stream: StreamStderr, out: chunks}
+    if err := cmd.Start(); err != nil {
+        return -1, err
+    }
+    defer track(cmd, uuid, SourceRun)()
+
+    // Wait 返回时两个复制协程都已结束，此后不会再有输出片段
+    var waitErr error
+    go func() {
+        waitErr = cmd.Wait()
+        close(chunks)
+    }()
+
+    // 输出片段由当前协程串行回调
+    var sendErr error
+    for chunk := range chunks {
+        if sendErr != nil {
+            continue // 回调已失败，只需排空通道
+        }
+        if sendErr = onChunk(chunk); sendErr != nil {
+            cancel() // 终止命令进程
+        }
+    }
+
+    if sendErr != nil {
+        return -1, sendErr
+    }
+    return exitCode(waitErr), nil
+}
+
+// chunkWriter 将写入的数据作为输出片段发送到通道，由 exec 的输出复制协程调用
+// 复制协程复用读取缓冲区，因此发送前先复制数据
+type chunkWriter struct {
+    stream string
+    out    chan<- OutputChunk
+}
+
+func (w chunkWriter) Write(p []byte) (int, error) {
+    data := make([]byte, len(p))
+    copy(data, p)
+    w.out <- OutputChunk{Stream: w.stream, Data: data, Timestamp: time.Now()}
+    return len(p), nil
+}
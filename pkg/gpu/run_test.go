@@ -0,0 +1,54 @@
+package gpu
+
+import (
+    "context"
+    "os/exec"
+    "strings"
+    "testing"
+    "time"
+)
+
+func TestStreamCommand(t *testing.T) {
+    var stdout, stderr strings.Builder
+    code, err := StreamCommand(context.Background(), "GPU-test", `echo out; echo err >&2; echo $CUDA_VISIBLE_DEVICES; exit 3`, func(c OutputChunk) error {
+        if c.Stream == StreamStderr {
+            stderr.Write(c.Data)
+        } else {
+            stdout.Write(c.Data)
+        }
+        return nil
+    })
+    if err != nil || code != 3 {
+        t.Fatalf("StreamCommand = %d, %v; want 3, nil", code, err)
+    }
+    if stdout.String() != "out\nGPU-test\n" || stderr.String() != "err\n" {
+        t.Errorf("stdout = %q, stderr = %q", stdout.String(), stderr.String())
+    }
+}
+
+// TestStreamCommandEscapedDescendant 脱离进程组的后代进程继续占用stdout时，
+// 命令进程退出后最多等待 waitDelay 即返回
+func TestStreamCommandEscapedDescendant(t *testing.T) {
+    if _, err := exec.LookPath("setsid"); err != nil {
+        t.Skip("setsid not available")
+    }
+    start := time.Now()
+    done := make(chan struct{})
+    var code int
+    var err error
+    go func() {
+        defer close(done)
+        code, err = StreamCommand(context.Background(), "GPU-test", `setsid sleep 20 & echo started`, func(OutputChunk) error { return nil })
+    }()
+    select {
+    case <-done:
+    case <-time.After(waitDelay + 10*time.Second):
+        t.Fatal("StreamCommand did not return while a descendant kept stdout open")
+    }
+    if err != nil || code != 0 {
+        t.Errorf("StreamCommand = %d, %v; want 0, nil", code, err)
+    }
+    if elapsed := time.Since(start); elapsed < waitDelay {
+        t.Errorf("returned after %v, before waitDelay %v expired", elapsed, waitDelay)
+    }
+}
@@ -0,0 +1,168 @@
+package gpuhealth
+
+import (
+    "fmt"
+    "os"
+    "sync"
+
+    "gopkg.in/yaml.v3"
+
+    "github.com/hiicl/GPU-over-IP-AC922/pkg/query"
+    pb "github.com/hiicl/GPU-over-IP-AC922/proto"
+)
+
+// gpuhealth 包按可配置的规则评估GPU后端上报的健康信号，判定为不健康的GPU交由调度器隔离
+// 支持的信号：
+//   - XID错误事件：XID在规则列表中时立即隔离（如 79 表示GPU已从总线上掉线）
+//     事件来源因后端而异：nvml 使用NVML事件集，nvidia-smi 解析内核日志（需读取权限），sim 来自场景中的故障
+//   - 不可纠正（双比特）ECC错误：本次开机以来的错误数达到阈值时隔离
+//   - 温度降频：连续若干次检查都处于温度降频时隔离
+//   - 后端超时：连续若干次探测失败（如 nvidia-smi 挂起）时隔离全部GPU
+// 隔离不会自动解除，需管理员排查后通过 UncordonGPU 解除
+// 易失ECC计数在驱动重新加载前不会清零，解除隔离时以当时的计数为基准，之后新增的错误数达到阈值才再次隔离；
+// 温度降频同样在解除隔离后重新计数
+//
+// 规则文件示例（YAML，未出现的字段使用默认值，阈值为0表示不启用该规则）：
+//   xids: [48, 62, 64, 74, 79, 94, 95, 119, 120]
+//   eccDoubleBit: 1      # 双比特ECC错误数阈值
+//   thermalThrottle: 3   # 连续处于温度降频的检查次数
+//   timeouts: 3          # 后端连续探测失败的次数
+
+// Rules 健康规则
+type Rules struct {
+    XIDs            []int `yaml:"xids"`
+    ECCDoubleBit    int64 `yaml:"eccDoubleBit"`
+    ThermalThrottle int   `yaml:"thermalThrottle"`
+    Timeouts        int   `yaml:"timeouts"`
+}
+
+// DefaultRules 返回默认规则
+func DefaultRules() Rules {
+    return Rules{
+        XIDs:            []int{48, 62, 64, 74, 79, 94, 95, 119, 120},
+        ECCDoubleBit:    1,
+        ThermalThrottle: 3,
+        Timeouts:        3,
+    }
+}
+
+// LoadRules 读取YAML规则文件
+func LoadRules(path string) (Rules, error) {
+    data, err := os.ReadFile(path)
+    if err != nil {
+        return Rules{}, fmt.Errorf("read health rules file: %v", err)
+    }
+    r := DefaultRules()
+    if err := yaml.Unmarshal(data, &r); err != nil {
+        return Rules{}, fmt.Errorf("parse health rules file %s: %v", path, err)
+    }
+    if r.ECCDoubleBit < 0 || r.ThermalThrottle < 0 || r.Timeouts < 0 {
+        return Rules{}, fmt.Errorf("health rules file %s: thresholds must not be negative", path)
+    }
+    return r, nil
+}
+
+// Quarantiner 隔离不健康的GPU，由 scheduler.Scheduler 实现
+type Quarantiner interface {
+    Cordon(uuid, reason string) bool
+}
+
+// Monitor 健康信号评估器，Observe* 由健康检查协程串行调用，Uncordoned 可由其他协程调用
+type Monitor struct {
+    rules    Rules
+    q        Quarantiner
+    xids     map[int]bool
+    mu       sync.Mutex
+    ecc      map[string]int64 // key: GPU UUID，value: 最近一次检查的易失双比特ECC错误数
+    eccBase  map[string]int64 // key: GPU UUID，value: 解除隔离时的易失双比特ECC错误数
+    thermal  map[string]int   // key: GPU UUID，value: 连续处于温度降频的检查次数
+    failures int              // 后端连续探测失败次数
+}
+
+// NewMonitor 创建评估器，判定为不健康的GPU交给 q 隔离
+func NewMonitor(rules Rules, q Quarantiner) *Monitor {
+    m := &Monitor{
+        rules:   rules,
+        q:       q,
+        xids:    make(map[int]bool),
+        ecc:     make(map[string]int64),
+        eccBase: make(map[string]int64),
+        thermal: make(map[string]int),
+    }
+    for _, x := range rules.XIDs {
+        m.xids[x] = true
+    }
+    return m
+}
+
+// NeedDetails 是否需要GPU详细信息（ECC或温度降频规则已启用）
+func (m *Monitor) NeedDetails() bool {
+    return m.rules.ECCDoubleBit > 0 || m.rules.ThermalThrottle > 0
+}
+
+// ObserveXID 评估XID错误事件
+func (m *Monitor) ObserveXID(events []query.XIDEvent) {
+    for _, e := range events {
+        if m.xids[e.XID] {
+            m.q.Cordon(e.UUID, fmt.Sprintf("XID %d at %s", e.XID, e.Time.Format("2006-01-02 15:04:05")))
+        }
+    }
+}
+
+// ObserveDetails 评估ECC错误和降频原因
+func (m *Monitor) ObserveDetails(details []*pb.GPUDetails) {
+    m.mu.Lock()
+    defer m.mu.Unlock()
+    for _, d := range details {
+        n := d.Ecc.GetVolatileDoubleBit()
+        if n < m.ecc[d.Uuid] {
+            // 计数变小说明驱动已重新加载，计数从0重新开始
+            delete(m.eccBase, d.Uuid)
+        }
+        m.ecc[d.Uuid] = n
+        if n -= m.eccBase[d.Uuid]; m.rules.ECCDoubleBit > 0 && n >= m.rules.ECCDoubleBit {
+            m.q.Cordon(d.Uuid, fmt.Sprintf("%d uncorrectable ECC errors", n))
+        }
+        if !thermalThrottled(d) {
+            delete(m.thermal, d.Uuid)
+            continue
+        }
+        m.thermal[d.Uuid]++
+        if n := m.thermal[d.Uuid]; m.rules.ThermalThrottle > 0 && n >= m.rules.ThermalThrottle {
+            m.q.Cordon(d.Uuid, fmt.Sprintf("thermal throttling for %d consecutive checks (%d C)", n, d.Temperature))
+        }
+    }
+}
+
+// Uncordoned 在管理员解除GPU隔离后调用：以最近一次检查的ECC错误数为新基准，并重新开始温度降频计数
+func (m *Monitor) Uncordoned(uuid string) {
+    m.mu.Lock()
+    defer m.mu.Unlock()
+    m.eccBase[uuid] = m.ecc[uuid]
+    delete(m.thermal, uuid)
+}
+
+// ObserveProbe 评估一次后端探测结果，连续失败达到阈值时隔离 gpus 中的全部GPU
+func (m *Monitor) ObserveProbe(err error, gpus []string) {
+    if err == nil {
+        m.failures = 0
+        return
+    }
+    m.failures++
+    if m.rules.Timeouts == 0 || m.failures < m.rules.Timeouts {
+        return
+    }
+    for _, uuid := range gpus {
+        m.q.Cordon(uuid, fmt.Sprintf("GPU backend failed %d consecutive health checks: %v", m.failures, err))
+    }
+}
+
+// thermalThrottled 判断GPU是否因温度过高而降频
+func thermalThrottled(d *pb.GPUDetails) bool {
+    for _, r := range d.ThrottleReasons {
+        if r == query.ThrottleHWThermalSlowdown || r == query.ThrottleSWThermalSlowdown {
+            return true
+        }
+    }
+    return false
+}
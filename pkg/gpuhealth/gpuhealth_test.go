@@ -0,0 +1,91 @@
+package gpuhealth
+
+import (
+    "testing"
+
+    "github.com/hiicl/GPU-over-IP-AC922/pkg/query"
+    pb "github.com/hiicl/GPU-over-IP-AC922/proto"
+)
+
+// cordons 记录隔离请求的 Quarantiner，行为与调度器一致：已隔离的GPU不重复隔离
+type cordons map[string]string
+
+func (c cordons) Cordon(uuid, reason string) bool {
+    if _, ok := c[uuid]; ok {
+        return false
+    }
+    c[uuid] = reason
+    return true
+}
+
+func eccDetails(uuid string, doubleBit int64, throttled bool) []*pb.GPUDetails {
+    d := &pb.GPUDetails{Uuid: uuid, Ecc: &pb.ECCErrors{Enabled: true, VolatileDoubleBit: doubleBit}}
+    if throttled {
+        d.ThrottleReasons = []string{query.ThrottleHWThermalSlowdown}
+    }
+    return []*pb.GPUDetails{d}
+}
+
+// TestUncordonedECCBaseline 解除隔离后，驱动未重新加载时保留的ECC计数不会再次触发隔离
+func TestUncordonedECCBaseline(t *testing.T) {
+    const gpu = "GPU-0"
+    q := cordons{}
+    m := NewMonitor(Rules{ECCDoubleBit: 2}, q)
+
+    m.ObserveDetails(eccDetails(gpu, 1, false))
+    if len(q) != 0 {
+        t.Fatalf("cordoned below threshold: %v", q)
+    }
+    m.ObserveDetails(eccDetails(gpu, 2, false))
+    if _, ok := q[gpu]; !ok {
+        t.Fatalf("not cordoned at threshold")
+    }
+
+    delete(q, gpu)
+    m.Uncordoned(gpu)
+    m.ObserveDetails(eccDetails(gpu, 2, false))
+    m.ObserveDetails(eccDetails(gpu, 3, false))
+    if len(q) != 0 {
+        t.Fatalf("re-cordoned by errors counted before uncordon: %v", q)
+    }
+    m.ObserveDetails(eccDetails(gpu, 4, false))
+    if _, ok := q[gpu]; !ok {
+        t.Fatalf("not cordoned after 2 new errors")
+    }
+
+    // 驱动重新加载后计数从0开始，基准随之清除
+    delete(q, gpu)
+    m.Uncordoned(gpu)
+    m.ObserveDetails(eccDetails(gpu, 0, false))
+    m.ObserveDetails(eccDetails(gpu, 2, false))
+    if _, ok := q[gpu]; !ok {
+        t.Fatalf("not cordoned after driver reload and 2 new errors")
+    }
+}
+
+// TestUncordonedThermal 解除隔离后需重新连续降频达到阈值才再次隔离
+func TestUncordonedThermal(t *testing.T) {
+    const gpu = "GPU-0"
+    q := cordons{}
+    m := NewMonitor(Rules{ThermalThrottle: 3}, q)
+
+    for i := 0; i < 3; i++ {
+        m.ObserveDetails(eccDetails(gpu, 0, true))
+    }
+    if _, ok := q[gpu]; !ok {
+        t.Fatalf("not cordoned after 3 throttled checks")
+    }
+
+    delete(q, gpu)
+    m.Uncordoned(gpu)
+    for i := 0; i < 2; i++ {
+        m.ObserveDetails(eccDetails(gpu, 0, true))
+    }
+    if len(q) != 0 {
+        t.Fatalf("re-cordoned after %d throttled checks: %v", 2, q)
+    }
+    m.ObserveDetails(eccDetails(gpu, 0, true))
+    if _, ok := q[gpu]; !ok {
+        t.Fatalf("not cordoned after 3 new throttled checks")
+    }
+}
@@ -0,0 +1,330 @@
+package jobs
+
+import (
+    "crypto/rand"
+    "encoding/hex"
+    "errors"
+    "fmt"
+    "os"
+    "os/exec"
+    "path/filepath"
+    "sort"
+    "sync"
+    "syscall"
+    "time"
+
+    "github.com/hiicl/GPU-over-IP-AC922/pkg/util"
+)
+
+// jobs 包提供异步作业管理功能
+// 作业在独立的进程组中运行，生命周期与提交作业的gRPC连接无关
+// 主要功能：
+//   - 提交作业并返回作业ID，输出写入作业日志文件
+//   - 查询作业状态、退出码及开始/结束时间
+//   - 取消作业：先发送SIGTERM，宽限期后发送SIGKILL
+//   - 按条件列出作业
+//   - 清理超过保留期的已结束作业及其日志
+
+// State 表示作业状态
+type State int
+
+// 作业状态
+const (
+    Running   State = iota // 运行中
+    Succeeded              // 正常结束（退出码为0）
+    Failed                 // 异常结束（退出码非0或无法启动）
+    Canceled               // 已取消
+)
+
+// String 返回作业状态名称
+func (s State) String() string {
+    switch s {
+    case Running:
+        return "running"
+    case Succeeded:
+        return "succeeded"
+    case Failed:
+        return "failed"
+    case Canceled:
+        return "canceled"
+    }
+    return "unknown"
+}
+
+// ErrNotFound 作业不存在
+var ErrNotFound = errors.New("job not found")
+
+// Job 表示一个异步作业
+// 导出字段为提交时确定的只读信息，运行状态通过 Snapshot 获取
+type Job struct {
+    ID      string // 作业ID
+    UUID    string // 目标GPU的UUID
+    Cmd     string // 执行的命令
+    Owner   string // 提交者
+    LeaseID string // 提交时使用的GPU租约
+    LogPath string // 作业输出日志文件
+
+    mu        sync.Mutex
+    state     State
+    exitCode  int
+    startTime time.Time
+    endTime   time.Time
+    canceled  bool
+    cmd       *exec.Cmd
+    done      chan struct{} // 作业结束时关闭
+}
+
+// Status 是作业运行状态的只读快照
+type Status struct {
+    State     State
+    ExitCode  int
+    StartTime time.Time
+    EndTime   time.Time // 作业未结束时为零值
+}
+
+// Snapshot 返回作业当前状态
+func (j *Job) Snapshot() Status {
+    j.mu.Lock()
+    defer j.mu.Unlock()
+    return Status{State: j.state, ExitCode: j.exitCode, StartTime: j.startTime, EndTime: j.endTime}
+}
+
+// Done 返回作业结束时关闭的通道
+func (j *Job) Done() <-chan struct{} {
+    return j.done
+}
+
+// PID 返回作业进程ID，作业在独立进程组中运行，它同时也是进程组ID
+func (j *Job) PID() int {
+    return j.cmd.Process.Pid
+}
+
+// Filter 描述 List 的过滤条件，零值字段表示不过滤
+type Filter struct {
+    States []State // 作业状态（任一匹配）
+    UUID   string  // 目标GPU
+    Owner  string  // 提交者
+}
+
+// match 判断作业是否满足过滤条件
+func (f Filter) match(j *Job, st State) bool {
+    if f.UUID != "" && j.UUID != f.UUID {
+        return false
+    }
+    if f.Owner != "" && j.Owner != f.Owner {
+        return false
+    }
+    if len(f.States) == 0 {
+        return true
+    }
+    for _, s := range f.States {
+        if s == st {
+            return true
+        }
+    }
+    return false
+}
+
+// Manager 管理本进程提交的所有作业
+// mu: 互斥锁，保护jobs映射的并发访问
+// jobs: 作业表（key: 作业ID）
+// logDir: 作业日志目录
+// workDir: 作业的工作目录（为空时继承服务端工作目录）
+// retention: 已结束作业的保留时长，超过后从作业表中移除并删除日志（<=0 表示永久保留）
+type Manager struct {
+    mu        sync.Mutex
+    jobs      map[string]*Job
+    logDir    string
+    workDir   string
+    retention time.Duration
+}
+
+// NewManager 创建作业管理器
+// logDir: 作业输出日志目录（不存在时自动创建）
+// workDir: 作业的工作目录，通常为节点文件沙箱根目录，便于暂存输入和取回输出
+// retention: 已结束作业及其日志的保留时长（<=0 表示永久保留）
+func NewManager(logDir, workDir string, retention time.Duration) (*Manager, error) {
+    if err := os.MkdirAll(logDir, 0755); err != nil {
+        return nil, fmt.Errorf("create job log dir: %v", err)
+    }
+    return &Manager{jobs: make(map[string]*Job), logDir: logDir, workDir: workDir, retention: retention}, nil
+}
+
+// Submit 在指定GPU上异步启动作业
+// 作业通过 bash -c 执行，设置 CUDA_VISIBLE_DEVICES，并在独立进程组中运行
+// 返回已启动的作业；命令无法启动时返回错误
+func (m *Manager) Submit(uuid, cmdline, owner, leaseID string) (*Job, error) {
+    m.prune()
+    id := newJobID()
+    logPath := filepath.Join(m.logDir, id+".log")
+    logFile, err := os.OpenFile(logPath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+    if err != nil {
+        return nil, fmt.Errorf("open job log: %v", err)
+    }
+
+    cmd := exec.Command("bash", "-c", cmdline)
+    cmd.Env = append(os.Environ(), "CUDA_VISIBLE_DEVICES="+uuid)
+    cmd.Dir = m.workDir
+    cmd.Stdout = logFile
+    cmd.Stderr = logFile
+    // 独立进程组：取消时可终止整个进程树，且不受服务端信号影响
+    cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+
+    if err := cmd.Start(); err != nil {
+        logFile.Close()
+        return nil, fmt.Errorf("start job: %v", err)
+    }
+
+    job := &Job{
+        ID:        id,
+        UUID:      uuid,
+        Cmd:       cmdline,
+        Owner:     owner,
+        LeaseID:   leaseID,
+        LogPath:   logPath,
+        state:     Running,
+        startTime: time.Now(),
+        cmd:       cmd,
+        done:      make(chan struct{}),
+    }
+
+    m.mu.Lock()
+    m.jobs[id] = job
+    m.mu.Unlock()
+
+    go job.wait(logFile)
+
+    util.Log("job %s started on GPU %s by %s: %s", id, uuid, owner, cmdline)
+    return job, nil
+}
+
+// wait 等待作业进程退出并记录结果
+func (j *Job) wait(logFile *os.File) {
+    err := j.cmd.Wait()
+    logFile.Close()
+
+    j.mu.Lock()
+    j.endTime = time.Now()
+    j.exitCode = 0
+    if err != nil {
+        j.exitCode = -1
+        var exitErr *exec.ExitError
+        if errors.As(err, &exitErr) {
+            j.exitCode = exitErr.ExitCode()
+        }
+    }
+    switch {
+    case j.canceled:
+        j.state = Canceled
+    case j.exitCode == 0:
+        j.state = Succeeded
+    default:
+        j.state = Failed
+    }
+    state, code := j.state, j.exitCode
+    j.mu.Unlock()
+
+    close(j.done)
+    util.Log("job %s %s (exit code %d)", j.ID, state, code)
+}
+
+// Get 返回指定ID的作业
+func (m *Manager) Get(id string) (*Job, error) {
+    m.mu.Lock()
+    defer m.mu.Unlock()
+    job, ok := m.jobs[id]
+    if !ok {
+        return nil, ErrNotFound
+    }
+    return job, nil
+}
+
+// Cancel 取消作业：向进程组发送SIGTERM，宽限期内未退出则发送SIGKILL
+// grace: 宽限期，<=0 时立即发送SIGKILL
+// 作业已结束时直接返回；不等待作业退出
+func (m *Manager) Cancel(id string, grace time.Duration) (*Job, error) {
+    job, err := m.Get(id)
+    if err != nil {
+        return nil, err
+    }
+
+    job.mu.Lock()
+    if job.state != Running {
+        job.mu.Unlock()
+        return job, nil
+    }
+    job.canceled = true
+    pgid := job.cmd.Process.Pid
+    job.mu.Unlock()
+
+    if grace <= 0 {
+        syscall.Kill(-pgid, syscall.SIGKILL)
+        return job, nil
+    }
+    syscall.Kill(-pgid, syscall.SIGTERM)
+    go func() {
+        select {
+        case <-job.done:
+        case <-time.After(grace):
+            util.Log("job %s did not exit after SIGTERM, sending SIGKILL", id)
+            syscall.Kill(-pgid, syscall.SIGKILL)
+        }
+    }()
+    return job, nil
+}
+
+// List 返回满足过滤条件的作业，按开始时间排序
+// 列出前先清理超过保留期的已结束作业
+func (m *Manager) List(f Filter) []*Job {
+    m.prune()
+    m.mu.Lock()
+    all := make([]*Job, 0, len(m.jobs))
+    for _, job := range m.jobs {
+        all = append(all, job)
+    }
+    m.mu.Unlock()
+
+    var result []*Job
+    for _, job := range all {
+        if f.match(job, job.Snapshot().State) {
+            result = append(result, job)
+        }
+    }
+    sort.Slice(result, func(i, k int) bool {
+        return result[i].startTime.Before(result[k].startTime)
+    })
+    return result
+}
+
+// prune 从作业表中移除结束时间早于保留期的作业，并删除其日志文件
+func (m *Manager) prune() {
+    if m.retention <= 0 {
+        return
+    }
+    cutoff := time.Now().Add(-m.retention)
+    var expired []*Job
+    m.mu.Lock()
+    for id, job := range m.jobs {
+        st := job.Snapshot()
+        if st.State != Running && st.EndTime.Before(cutoff) {
+            delete(m.jobs, id)
+            expired = append(expired, job)
+        }
+    }
+    m.mu.Unlock()
+
+    for _, job := range expired {
+        if err := os.Remove(job.LogPath); err != nil && !os.IsNotExist(err) {
+            util.Log("job %s: remove log: %v", job.ID, err)
+        }
+    }
+}
+
+// newJobID 生成随机作业ID（64位，十六进制）
+func newJobID() string {
+    b := make([]byte, 8)
+    if _, err := rand.Read(b); err != nil {
+        util.Fatal("generate job id: " + err.Error())
+    }
+    return hex.EncodeToString(b)
+}
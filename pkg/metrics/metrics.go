@@ -0,0 +1,275 @@
+package metrics
+
+import (
+    "context"
+    "errors"
+    "net/http"
+    "path"
+    "strconv"
+    "time"
+
+    "github.com/prometheus/client_golang/prometheus"
+    "github.com/prometheus/client_golang/prometheus/collectors"
+    "github.com/prometheus/client_golang/prometheus/promhttp"
+    "google.golang.org/grpc"
+    "google.golang.org/grpc/status"
+
+    "github.com/hiicl/GPU-over-IP-AC922/modules/memext"
+    "github.com/hiicl/GPU-over-IP-AC922/pkg/query"
+    "github.com/hiicl/GPU-over-IP-AC922/pkg/scheduler"
+)
+
+// metrics 包导出 Prometheus 监控指标：GPU状态、租约、RPC延迟与错误、memext内存池
+// GPU相关序列均带 numa（NUMA分组）和 uuid（GPU UUID）标签
+
+const namespace = "aitherion"
+
+// Group 一个NUMA分组的监控信息
+type Group struct {
+    Node     int      // NUMA节点编号
+    Port     int      // gRPC端口
+    GPUs     []string // 分组内GPU的UUID
+    MemTotal uint64   // NUMA节点内存总量（字节）
+}
+
+// Metrics 进程内所有指标
+type Metrics struct {
+    registry *prometheus.Registry
+    sched    *scheduler.Scheduler
+    groups   []Group
+    numaOf   map[string]string // key: GPU UUID，value: NUMA节点标签值
+
+    acquireWait  *prometheus.HistogramVec
+    acquisitions *prometheus.CounterVec
+    releases     *prometheus.CounterVec
+    rpcDuration  *prometheus.HistogramVec
+    rpcRequests  *prometheus.CounterVec
+}
+
+// GPU状态与拓扑指标描述（抓取时由 Collect 读取）
+var (
+    gpuMemUsedDesc = prometheus.NewDesc(namespace+"_gpu_memory_used_bytes",
+        "GPU memory in use.", []string{"numa", "uuid"}, nil)
+    gpuMemTotalDesc = prometheus.NewDesc(namespace+"_gpu_memory_total_bytes",
+        "Total GPU memory.", []string{"numa", "uuid"}, nil)
+    gpuUtilDesc = prometheus.NewDesc(namespace+"_gpu_utilization_ratio",
+        "GPU utilization (0-1).", []string{"numa", "uuid"}, nil)
+    gpuLeasedDesc = prometheus.NewDesc(namespace+"_gpu_leased",
+        "Whether the GPU is currently held by a lease (1) or free (0).", []string{"numa", "uuid"}, nil)
+    gpuUnhealthyDesc = prometheus.NewDesc(namespace+"_gpu_unhealthy",
+        "Whether the GPU is cordoned by the health monitor (1) and no longer handed out.", []string{"numa", "uuid"}, nil)
+    leasesDesc = prometheus.NewDesc(namespace+"_leases_active",
+        "Number of active GPU leases in the NUMA group.", []string{"numa"}, nil)
+    groupInfoDesc = prometheus.NewDesc(namespace+"_numa_group_info",
+        "NUMA group served by this process, value is always 1.", []string{"numa", "port"}, nil)
+    groupMemDesc = prometheus.NewDesc(namespace+"_numa_memory_total_bytes",
+        "Total memory of the NUMA node.", []string{"numa"}, nil)
+    telemetryAgeDesc = prometheus.NewDesc(namespace+"_telemetry_age_seconds",
+        "Age of the GPU telemetry snapshot the GPU series are read from.", nil, nil)
+    memextPoolDesc = prometheus.NewDesc(namespace+"_memext_pool_bytes",
+        "Size of the memext shared memory pool, labeled by the NUMA node it is bound to (-1 if unbound).", []string{"numa"}, nil)
+)
+
+// New 创建并注册所有指标
+func New(sched *scheduler.Scheduler, groups []Group) *Metrics {
+    m := &Metrics{
+        registry: prometheus.NewRegistry(),
+        sched:    sched,
+        groups:   groups,
+        numaOf:   make(map[string]string),
+        acquireWait: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+            Namespace: namespace,
+            Name:      "lease_acquire_wait_seconds",
+            Help:      "Time from an acquire request to the scheduler's decision.",
+            Buckets:   prometheus.ExponentialBuckets(0.00001, 4, 10),
+        }, []string{"numa", "uuid"}),
+        acquisitions: prometheus.NewCounterVec(prometheus.CounterOpts{
+            Namespace: namespace,
+            Name:      "lease_acquisitions_total",
+            Help:      "GPU acquire attempts by result (granted, in_use, unhealthy, quota_exceeded, insufficient, error).",
+        }, []string{"numa", "uuid", "result"}),
+        releases: prometheus.NewCounterVec(prometheus.CounterOpts{
+            Namespace: namespace,
+            Name:      "lease_releases_total",
+            Help:      "Ended GPU leases by reason (released, expired).",
+        }, []string{"numa", "uuid", "reason"}),
+        rpcDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+            Namespace: namespace,
+            Name:      "grpc_request_duration_seconds",
+            Help:      "GPUService RPC latency; streaming RPCs are measured until the stream ends.",
+            Buckets:   prometheus.DefBuckets,
+        }, []string{"numa", "uuid", "method"}),
+        rpcRequests: prometheus.NewCounterVec(prometheus.CounterOpts{
+            Namespace: namespace,
+            Name:      "grpc_requests_total",
+            Help:      "GPUService RPCs by status code.",
+        }, []string{"numa", "uuid", "method", "code"}),
+    }
+    for _, g := range groups {
+        for _, uuid := range g.GPUs {
+            m.numaOf[uuid] = strconv.Itoa(g.Node)
+        }
+    }
+
+    m.registry.MustRegister(
+        collectors.NewGoCollector(),
+        collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+        m.acquireWait, m.acquisitions, m.releases, m.rpcDuration, m.rpcRequests,
+        m,
+    )
+    sched.SetObserver(m)
+    return m
+}
+
+// Handler 返回 /metrics 的HTTP处理函数
+func (m *Metrics) Handler() http.Handler {
+    return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{})
+}
+
+// Serve 在 addr 上启动HTTP监听并提供 /metrics
+func (m *Metrics) Serve(addr string) error {
+    mux := http.NewServeMux()
+    mux.Handle("/metrics", m.Handler())
+    return http.ListenAndServe(addr, mux)
+}
+
+// numa 返回GPU的NUMA标签值，未知GPU返回空字符串
+func (m *Metrics) numa(uuid string) string {
+    return m.numaOf[uuid]
+}
+
+// Describe 实现 prometheus.Collector
+func (m *Metrics) Describe(ch chan<- *prometheus.Desc) {
+    for _, d := range []*prometheus.Desc{gpuMemUsedDesc, gpuMemTotalDesc, gpuUtilDesc, gpuLeasedDesc,
+        gpuUnhealthyDesc, leasesDesc, groupInfoDesc, groupMemDesc, telemetryAgeDesc, memextPoolDesc} {
+        ch <- d
+    }
+}
+
+// Collect 实现 prometheus.Collector：每次抓取时读取GPU遥测快照和租约
+func (m *Metrics) Collect(ch chan<- prometheus.Metric) {
+    const mib = 1 << 20
+    smp := query.Latest()
+    ch <- prometheus.MustNewConstMetric(telemetryAgeDesc, prometheus.GaugeValue, smp.Age().Seconds())
+    for _, info := range smp.GPUs {
+        numa, ok := m.numaOf[info.Uuid]
+        if !ok {
+            continue // 不属于本进程服务的GPU
+        }
+        ch <- prometheus.MustNewConstMetric(gpuMemTotalDesc, prometheus.GaugeValue, float64(info.TotalMemory)*mib, numa, info.Uuid)
+    }
+    for uuid, st := range smp.Status {
+        numa, ok := m.numaOf[uuid]
+        if !ok {
+            continue
+        }
+        ch <- prometheus.MustNewConstMetric(gpuMemUsedDesc, prometheus.GaugeValue, float64(st.UsedMemory)*mib, numa, uuid)
+        ch <- prometheus.MustNewConstMetric(gpuUtilDesc, prometheus.GaugeValue, float64(st.Utilization)/100, numa, uuid)
+    }
+
+    leased := m.sched.Leased()
+    cordoned := m.sched.Cordoned()
+    for _, g := range m.groups {
+        numa := strconv.Itoa(g.Node)
+        active := 0
+        for _, uuid := range g.GPUs {
+            v := 0.0
+            if _, ok := leased[uuid]; ok {
+                v = 1
+                active++
+            }
+            ch <- prometheus.MustNewConstMetric(gpuLeasedDesc, prometheus.GaugeValue, v, numa, uuid)
+            unhealthy := 0.0
+            if _, ok := cordoned[uuid]; ok {
+                unhealthy = 1
+            }
+            ch <- prometheus.MustNewConstMetric(gpuUnhealthyDesc, prometheus.GaugeValue, unhealthy, numa, uuid)
+        }
+        ch <- prometheus.MustNewConstMetric(leasesDesc, prometheus.GaugeValue, float64(active), numa)
+        ch <- prometheus.MustNewConstMetric(groupInfoDesc, prometheus.GaugeValue, 1, numa, strconv.Itoa(g.Port))
+        ch <- prometheus.MustNewConstMetric(groupMemDesc, prometheus.GaugeValue, float64(g.MemTotal), numa)
+    }
+    ch <- prometheus.MustNewConstMetric(memextPoolDesc, prometheus.GaugeValue, float64(len(memext.Pool())), strconv.Itoa(memext.NumaNode))
+}
+
+// Acquired 实现 scheduler.Observer
+func (m *Metrics) Acquired(uuid string, wait time.Duration, err error) {
+    result := "granted"
+    switch {
+    case err == nil:
+    case errors.Is(err, scheduler.ErrInUse):
+        result = "in_use"
+    case errors.Is(err, scheduler.ErrQuotaExceeded):
+        result = "quota_exceeded"
+    case errors.Is(err, scheduler.ErrInsufficient):
+        result = "insufficient"
+    case errors.Is(err, scheduler.ErrUnhealthy):
+        result = "unhealthy"
+    default:
+        result = "error"
+    }
+    numa := m.numa(uuid)
+    m.acquireWait.WithLabelValues(numa, uuid).Observe(wait.Seconds())
+    m.acquisitions.WithLabelValues(numa, uuid, result).Inc()
+}
+
+// Released 实现 scheduler.Observer
+func (m *Metrics) Released(uuid string, expired bool) {
+    reason := "released"
+    if expired {
+        reason = "expired"
+    }
+    m.releases.WithLabelValues(m.numa(uuid), uuid, reason).Inc()
+}
+
+// observeRPC 记录一次RPC的耗时和状态码
+// uuid 来自客户端请求，只保留本进程服务的GPU，避免任意字符串导致序列数量失控
+func (m *Metrics) observeRPC(numa, uuid, method string, start time.Time, err error) {
+    if _, ok := m.numaOf[uuid]; !ok {
+        uuid = ""
+    }
+    name := path.Base(method)
+    m.rpcDuration.WithLabelValues(numa, uuid, name).Observe(time.Since(start).Seconds())
+    m.rpcRequests.WithLabelValues(numa, uuid, name, status.Code(err).String()).Inc()
+}
+
+// UnaryInterceptor 一元调用指标拦截器
+// numa: 该gRPC服务所属的NUMA分组；uuidOf: 从请求消息中提取GPU UUID（无GPU时返回空字符串）
+func (m *Metrics) UnaryInterceptor(numa int, uuidOf func(msg interface{}) string) grpc.UnaryServerInterceptor {
+    node := strconv.Itoa(numa)
+    return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+        start := time.Now()
+        resp, err := handler(ctx, req)
+        m.observeRPC(node, uuidOf(req), info.FullMethod, start, err)
+        return resp, err
+    }
+}
+
+// StreamInterceptor 流式调用指标拦截器，GPU UUID 取自客户端发送的第一条消息
+func (m *Metrics) StreamInterceptor(numa int, uuidOf func(msg interface{}) string) grpc.StreamServerInterceptor {
+    node := strconv.Itoa(numa)
+    return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+        start := time.Now()
+        s := &observedStream{ServerStream: ss, uuidOf: uuidOf}
+        err := handler(srv, s)
+        m.observeRPC(node, s.uuid, info.FullMethod, start, err)
+        return err
+    }
+}
+
+// observedStream 记录流中第一条客户端消息对应的GPU UUID
+type observedStream struct {
+    grpc.ServerStream
+    uuidOf   func(msg interface{}) string
+    uuid     string
+    received bool
+}
+
+func (s *observedStream) RecvMsg(msg interface{}) error {
+    err := s.ServerStream.RecvMsg(msg)
+    if err == nil && !s.received {
+        s.received = true
+        s.uuid = s.uuidOf(msg)
+    }
+    return err
+}
@@ -0,0 +1,107 @@
+package query
+
+import (
+    "errors"
+    "fmt"
+    "sort"
+    "strings"
+    "sync"
+    "time"
+
+    pb "github.com/hiicl/GPU-over-IP-AC922/proto"
+)
+
+// backend.go 定义GPU查询后端接口及后端选择
+// 包级查询函数（ListGPUs、ListGPUStatus 等）均转发到当前后端，默认使用 nvidia-smi
+
+// Backend GPU查询后端
+type Backend interface {
+    // Name 后端名称（与 NewBackend 的参数一致）
+    Name() string
+    // ListGPUs 列出所有GPU的静态信息
+    ListGPUs() ([]*pb.GPUInfo, error)
+    // ListStatus 查询所有GPU的当前状态，key 为GPU UUID
+    ListStatus() (map[string]GPUStatus, error)
+    // Processes 列出所有GPU上的计算进程
+    Processes() ([]Process, error)
+    // Probe 在超时时间内检查后端是否可用且至少有一个GPU，返回nil表示正常
+    Probe(timeout time.Duration) error
+}
+
+// Process 表示GPU上的一个计算进程
+type Process struct {
+    PID        int    // 进程ID
+    UUID       string // 所在GPU的UUID
+    Name       string // 进程名（可执行文件路径）
+    UsedMemory int64  // 占用显存（MB）
+}
+
+// XIDEvent GPU驱动报告的XID错误事件
+type XIDEvent struct {
+    UUID string    // 发生错误的GPU的UUID
+    XID  int       // XID错误码（如 79 表示GPU从总线上掉线）
+    Time time.Time // 事件时间
+}
+
+// XIDReporter 可选接口：能够报告XID错误事件的后端
+type XIDReporter interface {
+    // XIDEvents 返回 since 之后产生的XID事件，按时间排序
+    XIDEvents(since time.Time) ([]XIDEvent, error)
+}
+
+// DetailReporter 可选接口：能够报告GPU详细信息的后端
+type DetailReporter interface {
+    // Details 返回所有GPU的详细信息
+    Details() ([]*pb.GPUDetails, error)
+}
+
+// 查询错误
+var (
+    ErrUnsupported = errors.New("not supported by the GPU backend") // 当前后端不支持该查询
+    ErrNotFound    = errors.New("GPU not found")                    // 后端未报告该GPU
+)
+
+// backends 可选后端的构造函数，key 为后端名称
+var backends = map[string]func() (Backend, error){
+    "nvidia-smi": func() (Backend, error) { return smiBackend{}, nil },
+    "nvml":       newNVMLBackend,
+    "fake":       func() (Backend, error) { return NewFakeBackend(DefaultFakeGPUs), nil },
+}
+
+// BackendNames 返回所有可选后端名称
+func BackendNames() []string {
+    var names []string
+    for name := range backends {
+        names = append(names, name)
+    }
+    sort.Strings(names)
+    return names
+}
+
+// NewBackend 按名称创建后端
+func NewBackend(name string) (Backend, error) {
+    newBackend, ok := backends[name]
+    if !ok {
+        return nil, fmt.Errorf("unknown GPU backend %q (available: %s)", name, strings.Join(BackendNames(), ", "))
+    }
+    return newBackend()
+}
+
+var (
+    backendMu sync.RWMutex
+    backend   Backend = smiBackend{}
+)
+
+// SetBackend 设置包级查询函数使用的后端，需在开始查询前调用
+func SetBackend(b Backend) {
+    backendMu.Lock()
+    defer backendMu.Unlock()
+    backend = b
+}
+
+// Current 返回当前后端
+func Current() Backend {
+    backendMu.RLock()
+    defer backendMu.RUnlock()
+    return backend
+}
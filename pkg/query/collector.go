@@ -0,0 +1,202 @@
+package query
+
+import (
+    "sync"
+    "time"
+
+    "google.golang.org/protobuf/proto"
+
+    "github.com/hiicl/GPU-over-IP-AC922/pkg/util"
+    pb "github.com/hiicl/GPU-over-IP-AC922/proto"
+)
+
+// collector.go 实现后台遥测采集：按固定间隔对所有GPU采样一次，查询从最近一次快照返回
+// 多个 NUMA 服务和大量轮询客户端共享同一份快照，不再为每次调用启动 nvidia-smi
+// 快照超过 maxAge 时读取方触发一次刷新（并发读取共享同一次刷新），最多等待 wait，超时则返回旧快照
+// 每次采样只查询状态（显存、利用率）；名称、PCI、驱动/CUDA版本等静态信息缓存下来，
+// 状态中的GPU集合发生变化（GPU掉线、MIG重新划分）或缓存超过 inventoryRefresh 时才重新查询
+
+// inventoryRefresh GPU静态信息的最长缓存时间
+const inventoryRefresh = 5 * time.Minute
+
+// Sample 一次采样的结果
+type Sample struct {
+    GPUs   []*pb.GPUInfo        // GPU静态信息
+    Status map[string]GPUStatus // key: GPU UUID
+    Time   time.Time            // 采样时间，零值表示尚未成功采样
+    Err    error                // 最近一次采样的错误，成功时为nil（失败时保留上次成功的数据）
+}
+
+// Age 返回快照距采样时刻的时长
+func (s *Sample) Age() time.Duration {
+    if s.Time.IsZero() {
+        return 0
+    }
+    return time.Since(s.Time)
+}
+
+// Stale 判断快照是否过旧：采集器启用时超过其 maxAge，或最近一次采样失败
+func (s *Sample) Stale() bool {
+    if s.Err != nil || s.Time.IsZero() {
+        return true
+    }
+    c := activeCollector()
+    return c != nil && s.Age() > c.MaxAge()
+}
+
+// CloneGPUs 返回快照中GPU信息的副本，调用方可以修改
+func (s *Sample) CloneGPUs() []*pb.GPUInfo {
+    var result []*pb.GPUInfo
+    for _, g := range s.GPUs {
+        result = append(result, proto.Clone(g).(*pb.GPUInfo))
+    }
+    return result
+}
+
+// Collector 后台遥测采集器
+type Collector struct {
+    backend  Backend
+    interval time.Duration // 后台采样间隔
+    maxAge   time.Duration // 读取时允许的最大快照时长，超过则触发刷新
+    wait     time.Duration // 读取时等待刷新的上限
+
+    mu      sync.Mutex
+    sample  *Sample
+    listed  time.Time     // 最近一次查询GPU静态信息的时间
+    pending chan struct{} // 正在进行的采样，完成时关闭；没有采样时为nil
+}
+
+// NewCollector 创建采集器并开始第一次采样，最多等待 wait
+func NewCollector(b Backend, interval, maxAge, wait time.Duration) *Collector {
+    c := &Collector{backend: b, interval: interval, maxAge: maxAge, wait: wait, sample: &Sample{}}
+    select {
+    case <-c.refresh():
+    case <-time.After(wait):
+    }
+    return c
+}
+
+// Run 按间隔持续采样，不会返回
+func (c *Collector) Run() {
+    ticker := time.NewTicker(c.interval)
+    defer ticker.Stop()
+    for range ticker.C {
+        <-c.refresh()
+    }
+}
+
+// refresh 启动一次采样并返回其完成通知；已有采样进行中时复用该采样
+func (c *Collector) refresh() <-chan struct{} {
+    c.mu.Lock()
+    defer c.mu.Unlock()
+    if c.pending != nil {
+        return c.pending
+    }
+    done := make(chan struct{})
+    c.pending = done
+    prev, listed := c.sample, c.listed
+    go func() {
+        defer close(done)
+        status, err := c.backend.ListStatus()
+        gpus := prev.GPUs
+        relisted := false
+        if err == nil && (time.Since(listed) > inventoryRefresh || !sameGPUs(gpus, status)) {
+            gpus, err = c.backend.ListGPUs()
+            relisted = err == nil
+        }
+
+        c.mu.Lock()
+        defer c.mu.Unlock()
+        c.pending = nil
+        if relisted {
+            c.listed = time.Now()
+        }
+        if err != nil {
+            if prev.Err == nil {
+                util.Log("[telemetry] %s sampling failed, serving data from %s: %v", c.backend.Name(), prev.Time.Format(time.RFC3339), err)
+            }
+            c.sample = &Sample{GPUs: prev.GPUs, Status: prev.Status, Time: prev.Time, Err: err}
+            return
+        }
+        if prev.Err != nil {
+            util.Log("[telemetry] %s sampling recovered", c.backend.Name())
+        }
+        c.sample = &Sample{GPUs: gpus, Status: status, Time: time.Now()}
+    }()
+    return done
+}
+
+// sameGPUs 判断状态中的GPU集合是否与静态信息中的一致
+func sameGPUs(gpus []*pb.GPUInfo, status map[string]GPUStatus) bool {
+    if len(gpus) == 0 || len(gpus) != len(status) {
+        return false
+    }
+    for _, g := range gpus {
+        if _, ok := status[g.Uuid]; !ok {
+            return false
+        }
+    }
+    return true
+}
+
+// Latest 返回最近的快照；快照过旧时等待一次刷新，最多等待 wait
+func (c *Collector) Latest() *Sample {
+    c.mu.Lock()
+    s := c.sample
+    c.mu.Unlock()
+    if !s.Time.IsZero() && s.Age() <= c.maxAge {
+        return s
+    }
+    select {
+    case <-c.refresh():
+    case <-time.After(c.wait):
+    }
+    c.mu.Lock()
+    defer c.mu.Unlock()
+    return c.sample
+}
+
+// MaxAge 返回允许的最大快照时长
+func (c *Collector) MaxAge() time.Duration {
+    return c.maxAge
+}
+
+var (
+    collectorMu sync.RWMutex
+    collector   *Collector
+)
+
+// SetCollector 设置包级查询函数使用的采集器，nil 表示每次查询直接访问后端
+func SetCollector(c *Collector) {
+    collectorMu.Lock()
+    defer collectorMu.Unlock()
+    collector = c
+}
+
+// activeCollector 返回当前采集器，未启用时返回nil
+func activeCollector() *Collector {
+    collectorMu.RLock()
+    defer collectorMu.RUnlock()
+    return collector
+}
+
+// Latest 返回当前GPU信息和状态的快照
+// 启用采集器时返回采集器的快照，否则直接查询后端一次
+func Latest() *Sample {
+    if c := activeCollector(); c != nil {
+        return c.Latest()
+    }
+
+    b := Current()
+    s := &Sample{}
+    if s.GPUs, s.Err = b.ListGPUs(); s.Err != nil {
+        util.Log("%s failed: %v", b.Name(), s.Err)
+        return s
+    }
+    if s.Status, s.Err = b.ListStatus(); s.Err != nil {
+        util.Log("%s status failed: %v", b.Name(), s.Err)
+        return s
+    }
+    s.Time = time.Now()
+    return s
+}
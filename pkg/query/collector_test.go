@@ -0,0 +1,54 @@
+package query
+
+import (
+    "testing"
+    "time"
+
+    pb "github.com/hiicl/GPU-over-IP-AC922/proto"
+)
+
+// countingBackend 统计静态信息和状态的查询次数
+type countingBackend struct {
+    *FakeBackend
+    lists, statuses int
+}
+
+func (b *countingBackend) ListGPUs() ([]*pb.GPUInfo, error) {
+    b.lists++
+    return b.FakeBackend.ListGPUs()
+}
+
+func (b *countingBackend) ListStatus() (map[string]GPUStatus, error) {
+    b.statuses++
+    return b.FakeBackend.ListStatus()
+}
+
+// TestCollectorCachesInventory 每次采样只查询状态，GPU集合变化时才重新查询静态信息
+func TestCollectorCachesInventory(t *testing.T) {
+    b := &countingBackend{FakeBackend: NewFakeBackend(2)}
+    c := NewCollector(b, time.Hour, time.Hour, 5*time.Second)
+    for i := 0; i < 3; i++ {
+        <-c.refresh()
+    }
+    if b.lists != 1 || b.statuses != 4 {
+        t.Fatalf("after 4 samples: %d inventory and %d status queries, want 1 and 4", b.lists, b.statuses)
+    }
+    b.SetStatus(FakeUUID(1), GPUStatus{UsedMemory: 1024, Utilization: 50})
+    <-c.refresh()
+    if st := c.Latest().Status[FakeUUID(1)]; st.UsedMemory != 1024 || st.Utilization != 50 {
+        t.Errorf("status = %+v, want the updated status", st)
+    }
+
+    // 重新划分MIG后状态中出现新的实例，静态信息随之刷新
+    if err := b.EnableMIG(0, "3g.20gb", "3g.20gb"); err != nil {
+        t.Fatalf("EnableMIG: %v", err)
+    }
+    <-c.refresh()
+    <-c.refresh()
+    if b.lists != 2 {
+        t.Errorf("inventory queried %d times after MIG change, want 2", b.lists)
+    }
+    if gpus := c.Latest().GPUs; len(gpus) != 4 || gpus[1].Uuid != FakeMIGUUID(0, 1) {
+        t.Errorf("inventory after MIG change = %d GPUs, want parent, 2 instances and GPU 1", len(gpus))
+    }
+}
@@ -0,0 +1,58 @@
+package query
+
+import (
+    "os"
+    "path/filepath"
+    "regexp"
+    "strconv"
+    "strings"
+)
+
+// device.go 提供nvidia-smi查询字段之外的设备信息：NUMA节点（sysfs）、CUDA版本
+
+// cudaVersionRe 匹配nvidia-smi输出头部的CUDA版本，如 "CUDA Version: 10.2"
+var cudaVersionRe = regexp.MustCompile(`CUDA Version:\s*([0-9.]+)`)
+
+// CUDAVersion 返回驱动支持的CUDA版本，解析失败时返回空字符串
+func CUDAVersion() string {
+    out, err := smiOutput(smiTimeout)
+    if err != nil {
+        return ""
+    }
+    if m := cudaVersionRe.FindSubmatch(out); m != nil {
+        return string(m[1])
+    }
+    return ""
+}
+
+// PCINUMANode 根据nvidia-smi的PCI总线ID读取设备所在NUMA节点
+// busID: nvidia-smi格式的总线ID（如 "00000004:04:00.0"，域为8位）
+// sysfs 使用4位域（如 "0004:04:00.0"），读取失败时返回-1
+func PCINUMANode(busID string) int {
+    addr := sysfsPCIAddr(busID)
+    if addr == "" {
+        return -1
+    }
+    data, err := os.ReadFile(filepath.Join("/sys/bus/pci/devices", addr, "numa_node"))
+    if err != nil {
+        return -1
+    }
+    node, err := strconv.Atoi(strings.TrimSpace(string(data)))
+    if err != nil {
+        return -1
+    }
+    return node
+}
+
+// sysfsPCIAddr 将nvidia-smi总线ID转换为sysfs设备目录名（小写、4位域）
+func sysfsPCIAddr(busID string) string {
+    parts := strings.SplitN(strings.ToLower(busID), ":", 2)
+    if len(parts) != 2 {
+        return ""
+    }
+    domain := parts[0]
+    if len(domain) > 4 {
+        domain = domain[len(domain)-4:]
+    }
+    return domain + ":" + parts[1]
+}
@@ -0,0 +1,233 @@
+package query
+
+import (
+    _ "embed"
+    "fmt"
+    "sync"
+    "time"
+
+    "google.golang.org/protobuf/proto"
+
+    pb "github.com/hiicl/GPU-over-IP-AC922/proto"
+)
+
+// fake.go 实现确定性的模拟后端，用于没有GPU的开发和CI环境
+// GPU 按 AC922 的布局模拟：V100-SXM2-16GB，前一半位于 NUMA 0，后一半位于 NUMA 8
+// 所有字段由GPU序号推导，多次创建结果一致；状态和进程可通过 Set* 方法修改
+// EnableMIG 把指定GPU改为启用MIG的 A100-SXM4-40GB，并按配置划分MIG实例
+// 详细信息以录制的 nvidia-smi -q -x 输出（fixtures/v100-440.xml）为模板，经 XML 解析器生成
+
+// DefaultFakeGPUs 后端名称 "fake" 创建的模拟GPU数量
+const DefaultFakeGPUs = 4
+
+// fakeTotalMemory 模拟GPU的显存总量（MB）
+const fakeTotalMemory = 16160
+
+// fixtureXML 录制的单块 V100 的 nvidia-smi -q -x 输出
+//go:embed fixtures/v100-440.xml
+var fixtureXML []byte
+
+// fixtureDetails 按模拟GPU的信息、状态和进程生成详细信息：身份、显存、利用率和进程
+// 取自模拟数据，温度、功耗、时钟、ECC等取自录制的模板
+func fixtureDetails(info *pb.GPUInfo, st GPUStatus, procs []Process) (*pb.GPUDetails, error) {
+    tmpl, err := ParseSMIXML(fixtureXML)
+    if err != nil {
+        return nil, err
+    }
+    if len(tmpl) == 0 {
+        return nil, fmt.Errorf("fixture has no GPUs")
+    }
+    d := tmpl[0]
+    d.Uuid = info.Uuid
+    d.ProductName = info.Name
+    d.Serial = fmt.Sprintf("%013d", 323218104562+int64(info.Index))
+    d.TotalMemory = info.TotalMemory
+    d.UsedMemory = st.UsedMemory
+    d.Utilization = st.Utilization
+    d.Processes = nil
+    for _, p := range procs {
+        if p.UUID == info.Uuid {
+            d.Processes = append(d.Processes, &pb.GPUProcess{Pid: int32(p.PID), Name: p.Name, Type: "C", UsedMemory: p.UsedMemory})
+        }
+    }
+    return d, nil
+}
+
+// FakeBackend 模拟后端
+type FakeBackend struct {
+    gpus []*pb.GPUInfo
+
+    mu       sync.Mutex
+    status   map[string]GPUStatus // key: GPU UUID
+    procs    []Process
+    probeErr error
+    edits    map[string]func(*pb.GPUDetails) // key: GPU UUID，修改该GPU的详细信息
+}
+
+// NewFakeBackend 创建包含 n 块模拟GPU的后端，初始状态为空闲
+func NewFakeBackend(n int) *FakeBackend {
+    b := &FakeBackend{status: make(map[string]GPUStatus), edits: make(map[string]func(*pb.GPUDetails))}
+    for i := 0; i < n; i++ {
+        numa, domain := 0, 4
+        if i >= (n+1)/2 {
+            numa, domain = 8, 0x35
+        }
+        b.gpus = append(b.gpus, &pb.GPUInfo{
+            Uuid:              FakeUUID(i),
+            Name:              "Tesla V100-SXM2-16GB",
+            TotalMemory:       fakeTotalMemory,
+            Index:             int32(i),
+            PciBusId:          fmt.Sprintf("%08X:%02X:00.0", domain, 4+i%((n+1)/2)),
+            NumaNode:          int32(numa),
+            DriverVersion:     "440.64.00",
+            CudaVersion:       "10.2",
+            ComputeCapability: "7.0",
+            PersistenceMode:   true,
+            ComputeMode:       "Default",
+        })
+    }
+    return b
+}
+
+// FakeUUID 返回第 i 块模拟GPU的UUID
+func FakeUUID(i int) string {
+    return fmt.Sprintf("GPU-fa4e0000-0000-0000-0000-%012x", i)
+}
+
+// FakeMIGUUID 返回第 i 块模拟GPU上GPU实例 gi 的UUID
+func FakeMIGUUID(i, gi int) string {
+    return fmt.Sprintf("MIG-fa4e0000-0000-0000-%04x-%012x", gi, i)
+}
+
+// EnableMIG 把第 i 块模拟GPU改为启用MIG的 A100-SXM4-40GB，并按 profiles 依次划分MIG实例
+// GPU实例ID从1开始依次分配，每个GPU实例包含一个计算实例（ID为0）；已启用时重新划分
+// 须在使用后端之前调用
+func (b *FakeBackend) EnableMIG(i int, profiles ...string) error {
+    parent := -1
+    for k, g := range b.gpus {
+        if g.Mig == nil && int(g.Index) == i {
+            parent = k
+        }
+    }
+    if parent < 0 {
+        return fmt.Errorf("fake GPU %d not found", i)
+    }
+    if err := checkMIGProfiles(profiles); err != nil {
+        return fmt.Errorf("fake GPU %d: %v", i, err)
+    }
+    g := b.gpus[parent]
+    g.Name = "A100-SXM4-40GB"
+    g.TotalMemory = 40536
+    g.ComputeCapability = "8.0"
+    g.MigEnabled = true
+
+    // 去掉原有实例，新实例紧跟在物理GPU之后
+    gpus := append([]*pb.GPUInfo(nil), b.gpus[:parent+1]...)
+    for gi, name := range profiles {
+        p, _ := LookupMIGProfile(name)
+        gpus = append(gpus, migInstance(g, FakeMIGUUID(i, gi+1), name, gi+1, 0, p.Memory))
+    }
+    for _, m := range b.gpus[parent+1:] {
+        if m.Mig == nil || m.Mig.ParentUuid != g.Uuid {
+            gpus = append(gpus, m)
+        }
+    }
+    b.gpus = gpus
+    return nil
+}
+
+func (b *FakeBackend) Name() string { return "fake" }
+
+// ListGPUs 返回模拟GPU信息的副本
+func (b *FakeBackend) ListGPUs() ([]*pb.GPUInfo, error) {
+    var result []*pb.GPUInfo
+    for _, g := range b.gpus {
+        result = append(result, proto.Clone(g).(*pb.GPUInfo))
+    }
+    return result, nil
+}
+
+// ListStatus 返回所有模拟GPU的状态，未设置的GPU为空闲
+func (b *FakeBackend) ListStatus() (map[string]GPUStatus, error) {
+    b.mu.Lock()
+    defer b.mu.Unlock()
+    result := make(map[string]GPUStatus, len(b.gpus))
+    for _, g := range b.gpus {
+        result[g.Uuid] = b.status[g.Uuid]
+    }
+    return result, nil
+}
+
+// Processes 返回设置的模拟进程
+func (b *FakeBackend) Processes() ([]Process, error) {
+    b.mu.Lock()
+    defer b.mu.Unlock()
+    return append([]Process(nil), b.procs...), nil
+}
+
+// Details 返回模拟GPU的详细信息，再应用 SetDetails 设置的修改
+// 与 nvidia-smi -q -x 一致，只返回物理GPU，不包含MIG实例
+func (b *FakeBackend) Details() ([]*pb.GPUDetails, error) {
+    b.mu.Lock()
+    defer b.mu.Unlock()
+    var result []*pb.GPUDetails
+    for _, g := range b.gpus {
+        if g.Mig != nil {
+            continue
+        }
+        d, err := fixtureDetails(g, b.status[g.Uuid], b.procs)
+        if err != nil {
+            return nil, err
+        }
+        if edit := b.edits[g.Uuid]; edit != nil {
+            edit(d)
+        }
+        result = append(result, d)
+    }
+    return result, nil
+}
+
+// Probe 返回设置的探测错误，GPU数量为0时视为不可用
+func (b *FakeBackend) Probe(timeout time.Duration) error {
+    b.mu.Lock()
+    defer b.mu.Unlock()
+    if b.probeErr != nil {
+        return b.probeErr
+    }
+    if len(b.gpus) == 0 {
+        return fmt.Errorf("fake backend has no GPUs")
+    }
+    return nil
+}
+
+// SetStatus 设置指定GPU的状态
+func (b *FakeBackend) SetStatus(uuid string, st GPUStatus) {
+    b.mu.Lock()
+    defer b.mu.Unlock()
+    b.status[uuid] = st
+}
+
+// SetProcesses 替换模拟进程列表
+func (b *FakeBackend) SetProcesses(procs []Process) {
+    b.mu.Lock()
+    defer b.mu.Unlock()
+    b.procs = append([]Process(nil), procs...)
+}
+
+// SetProbeError 设置 Probe 返回的错误，nil 表示恢复正常
+func (b *FakeBackend) SetProbeError(err error) {
+    b.mu.Lock()
+    defer b.mu.Unlock()
+    b.probeErr = err
+}
+
+// SetDetails 设置修改指定GPU详细信息的函数（如注入ECC错误或降频原因），nil 表示恢复模板
+func (b *FakeBackend) SetDetails(uuid string, edit func(*pb.GPUDetails)) {
+    b.mu.Lock()
+    defer b.mu.Unlock()
+    if edit == nil {
+        delete(b.edits, uuid)
+        return
+    }
+    b.edits[uuid] = edit
+}
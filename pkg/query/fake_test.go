@@ -0,0 +1,114 @@
+package query
+
+import (
+    "errors"
+    "reflect"
+    "testing"
+    "time"
+
+    "google.golang.org/protobuf/proto"
+
+    pb "github.com/hiicl/GPU-over-IP-AC922/proto"
+)
+
+// TestFakeBackendDeterministic 两次创建的模拟后端报告相同的GPU、状态、进程和详细信息
+func TestFakeBackendDeterministic(t *testing.T) {
+    a, b := NewFakeBackend(4), NewFakeBackend(4)
+    gpusA, err := a.ListGPUs()
+    if err != nil {
+        t.Fatalf("ListGPUs: %v", err)
+    }
+    gpusB, _ := b.ListGPUs()
+    if len(gpusA) != 4 || len(gpusB) != 4 {
+        t.Fatalf("ListGPUs returned %d and %d GPUs, want 4", len(gpusA), len(gpusB))
+    }
+    for i := range gpusA {
+        if !proto.Equal(gpusA[i], gpusB[i]) {
+            t.Errorf("GPU %d differs between backends:\n%v\n%v", i, gpusA[i], gpusB[i])
+        }
+    }
+
+    // 前一半位于 NUMA 0，后一半位于 NUMA 8
+    wantNUMA := []int32{0, 0, 8, 8}
+    for i, g := range gpusA {
+        if g.Uuid != FakeUUID(i) || g.Index != int32(i) || g.NumaNode != wantNUMA[i] {
+            t.Errorf("GPU %d = %s index %d NUMA %d, want %s index %d NUMA %d",
+                i, g.Uuid, g.Index, g.NumaNode, FakeUUID(i), i, wantNUMA[i])
+        }
+    }
+
+    detailsA, err := a.Details()
+    if err != nil {
+        t.Fatalf("Details: %v", err)
+    }
+    detailsB, _ := b.Details()
+    for i := range detailsA {
+        if !proto.Equal(detailsA[i], detailsB[i]) {
+            t.Errorf("details of GPU %d differ between backends", i)
+        }
+        if detailsA[i].Uuid != FakeUUID(i) || detailsA[i].TotalMemory != fakeTotalMemory {
+            t.Errorf("details %d = %s with %d MB, want %s with %d MB", i, detailsA[i].Uuid, detailsA[i].TotalMemory, FakeUUID(i), fakeTotalMemory)
+        }
+    }
+}
+
+// TestFakeBackendState 状态、进程、探测错误和详细信息修改按设置返回
+func TestFakeBackendState(t *testing.T) {
+    b := NewFakeBackend(2)
+
+    status, err := b.ListStatus()
+    if err != nil {
+        t.Fatalf("ListStatus: %v", err)
+    }
+    want := map[string]GPUStatus{FakeUUID(0): {}, FakeUUID(1): {}}
+    if !reflect.DeepEqual(status, want) {
+        t.Errorf("initial status = %v, want all idle", status)
+    }
+    b.SetStatus(FakeUUID(1), GPUStatus{UsedMemory: 8000, Utilization: 75})
+    status, _ = b.ListStatus()
+    if st := status[FakeUUID(1)]; st.UsedMemory != 8000 || st.Utilization != 75 {
+        t.Errorf("status = %+v, want 8000 MB at 75%%", st)
+    }
+
+    procs := []Process{{PID: 4242, UUID: FakeUUID(1), Name: "python", UsedMemory: 8000}}
+    b.SetProcesses(procs)
+    procs[0].PID = 1 // 后端保存的是副本
+    got, err := b.Processes()
+    if err != nil || len(got) != 1 || got[0].PID != 4242 {
+        t.Errorf("Processes = %v, %v; want pid 4242", got, err)
+    }
+    details, _ := b.Details()
+    if p := details[1].Processes; len(p) != 1 || p[0].Pid != 4242 || p[0].UsedMemory != 8000 {
+        t.Errorf("details processes = %v, want pid 4242 using 8000 MB", p)
+    }
+    if details[1].UsedMemory != 8000 || details[1].Utilization != 75 {
+        t.Errorf("details status = %d MB at %d%%, want 8000 MB at 75%%", details[1].UsedMemory, details[1].Utilization)
+    }
+
+    b.SetDetails(FakeUUID(0), func(d *pb.GPUDetails) { d.Ecc.VolatileDoubleBit = 3 })
+    details, _ = b.Details()
+    if details[0].Ecc.VolatileDoubleBit != 3 || details[1].Ecc.VolatileDoubleBit != 0 {
+        t.Errorf("double bit ECC = %d and %d, want 3 and 0", details[0].Ecc.VolatileDoubleBit, details[1].Ecc.VolatileDoubleBit)
+    }
+    b.SetDetails(FakeUUID(0), nil)
+    details, _ = b.Details()
+    if details[0].Ecc.VolatileDoubleBit != 0 {
+        t.Errorf("double bit ECC = %d after reset, want 0", details[0].Ecc.VolatileDoubleBit)
+    }
+
+    if err := b.Probe(time.Second); err != nil {
+        t.Errorf("Probe: %v", err)
+    }
+    probeErr := errors.New("driver gone")
+    b.SetProbeError(probeErr)
+    if err := b.Probe(time.Second); err != probeErr {
+        t.Errorf("Probe = %v, want %v", err, probeErr)
+    }
+    b.SetProbeError(nil)
+    if err := b.Probe(time.Second); err != nil {
+        t.Errorf("Probe after reset: %v", err)
+    }
+    if err := NewFakeBackend(0).Probe(time.Second); err == nil {
+        t.Error("Probe with no GPUs succeeded")
+    }
+}
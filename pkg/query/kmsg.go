@@ -0,0 +1,113 @@
+package query
+
+import (
+    "os/exec"
+    "regexp"
+    "strconv"
+    "strings"
+    "sync"
+    "time"
+
+    "github.com/hiicl/GPU-over-IP-AC922/pkg/util"
+)
+
+// kmsg.go 从内核日志中解析NVIDIA驱动报告的XID错误，供 nvidia-smi 后端使用
+// 驱动发生XID错误时向内核日志写入如下行（dmesg --time-format iso 输出）：
+//   2023-09-20T09:41:07,123456+08:00 NVRM: Xid (PCI:0000:07:00): 79, pid=1234, GPU has fallen off the bus.
+// 日志中只有PCI地址，按 ListGPUs 时记录的PCI地址映射为GPU UUID；
+// GPU掉线后 nvidia-smi 不再列出它，因此映射使用此前记录的结果
+
+// kmsgXIDLine 匹配内核日志中的XID行，捕获时间戳、PCI地址（域:总线:设备）和XID错误码
+var kmsgXIDLine = regexp.MustCompile(`^(\S+)\s+NVRM: Xid \(PCI:([0-9A-Fa-f]+:[0-9A-Fa-f]+:[0-9A-Fa-f]+)(?:\.[0-9A-Fa-f]+)?\): (\d+)`)
+
+// kmsgTimeLayout dmesg --time-format iso 的时间格式
+const kmsgTimeLayout = "2006-01-02T15:04:05,999999999-07:00"
+
+var (
+    pciUUIDMu sync.Mutex
+    pciUUIDs  = make(map[string]string) // key: pciKey 统一后的PCI地址，value: GPU UUID
+    kmsgWarn  sync.Once
+)
+
+// kernelXID 内核日志中的一条XID记录
+type kernelXID struct {
+    pci  string // pciKey 统一后的PCI地址
+    xid  int
+    time time.Time
+}
+
+// pciKey 将PCI地址统一为 "dddd:bb:dd" 格式（小写、4位域、不含功能号），
+// nvidia-smi 的 "00000000:07:00.0" 与内核日志的 "0000:07:00" 得到相同结果
+func pciKey(addr string) string {
+    addr = sysfsPCIAddr(addr)
+    if i := strings.LastIndex(addr, "."); i >= 0 {
+        addr = addr[:i]
+    }
+    return addr
+}
+
+// rememberPCI 记录GPU的PCI地址到UUID的映射
+func rememberPCI(busID, uuid string) {
+    if key := pciKey(busID); key != "" {
+        pciUUIDMu.Lock()
+        pciUUIDs[key] = uuid
+        pciUUIDMu.Unlock()
+    }
+}
+
+// lookupPCI 返回PCI地址对应的GPU UUID，未记录时返回空字符串
+func lookupPCI(key string) string {
+    pciUUIDMu.Lock()
+    defer pciUUIDMu.Unlock()
+    return pciUUIDs[key]
+}
+
+// parseKernelXIDs 解析 dmesg --time-format iso 输出中时间晚于 since 的XID记录
+func parseKernelXIDs(out []byte, since time.Time) []kernelXID {
+    var result []kernelXID
+    for _, line := range strings.Split(string(out), "\n") {
+        m := kmsgXIDLine.FindStringSubmatch(strings.TrimRight(line, "\r"))
+        if m == nil {
+            continue
+        }
+        ts, err := time.Parse(kmsgTimeLayout, m[1])
+        if err != nil || !ts.After(since) {
+            continue
+        }
+        xid, _ := strconv.Atoi(m[3])
+        result = append(result, kernelXID{pci: pciKey(m[2]), xid: xid, time: ts})
+    }
+    return result
+}
+
+// XIDEvents 读取内核日志中 since 之后的XID错误
+// 读取内核日志需要相应权限（通常为root或 CAP_SYSLOG），无法读取时只记录一次警告，不报告事件
+func (b smiBackend) XIDEvents(since time.Time) ([]XIDEvent, error) {
+    out, err := exec.Command("dmesg", "--time-format", "iso").Output()
+    if err != nil {
+        kmsgWarn.Do(func() {
+            util.Log("[Warn] cannot read kernel log, XID health rules disabled for nvidia-smi backend: %v", err)
+        })
+        return nil, nil
+    }
+    found := parseKernelXIDs(out, since)
+    if len(found) == 0 {
+        return nil, nil
+    }
+    var events []XIDEvent
+    for _, k := range found {
+        uuid := lookupPCI(k.pci)
+        if uuid == "" {
+            // 尚未记录该PCI地址（如启动前已发生的错误），刷新一次GPU列表
+            if _, err := b.ListGPUs(); err != nil {
+                return events, err
+            }
+            if uuid = lookupPCI(k.pci); uuid == "" {
+                util.Log("[Warn] XID %d on unknown GPU at PCI %s", k.xid, k.pci)
+                continue
+            }
+        }
+        events = append(events, XIDEvent{UUID: uuid, XID: k.xid, Time: k.time})
+    }
+    return events, nil
+}
@@ -0,0 +1,46 @@
+package query
+
+import (
+    "reflect"
+    "testing"
+    "time"
+)
+
+func TestParseKernelXIDs(t *testing.T) {
+    out := []byte("2023-09-20T09:40:59,000001+08:00 NVRM: Xid (PCI:0000:07:00): 13, pid=881, Graphics Exception\n" +
+        "2023-09-20T09:41:07,123456+08:00 NVRM: Xid (PCI:0000:07:00): 79, pid=1234, GPU has fallen off the bus.\r\n" +
+        "2023-09-20T09:41:07,500000+08:00 nvidia-nvlink: Unregistered the Nvlink Core\n" +
+        "2023-09-20T09:42:30,000000+08:00 NVRM: Xid (PCI:0035:03:00.0): 48, pid='<unknown>', An uncorrectable double bit error\n")
+    zone := time.FixedZone("", 8*3600)
+    since := time.Date(2023, 9, 20, 9, 41, 0, 0, zone)
+    want := []kernelXID{
+        {pci: "0000:07:00", xid: 79, time: time.Date(2023, 9, 20, 9, 41, 7, 123456000, zone)},
+        {pci: "0035:03:00", xid: 48, time: time.Date(2023, 9, 20, 9, 42, 30, 0, zone)},
+    }
+    got := parseKernelXIDs(out, since)
+    if len(got) != len(want) {
+        t.Fatalf("parseKernelXIDs = %+v, want %+v", got, want)
+    }
+    for i := range want {
+        if got[i].pci != want[i].pci || got[i].xid != want[i].xid || !got[i].time.Equal(want[i].time) {
+            t.Errorf("entry %d = %+v, want %+v", i, got[i], want[i])
+        }
+    }
+}
+
+func TestPCIKey(t *testing.T) {
+    tests := map[string]string{
+        "00000000:07:00.0": "0000:07:00", // nvidia-smi pci.bus_id
+        "0000:07:00":       "0000:07:00", // 内核日志
+        "00000035:03:00.0": "0035:03:00",
+        "0035:0A:00.0":     "0035:0a:00",
+        "":                 "",
+    }
+    got := make(map[string]string)
+    for in := range tests {
+        got[in] = pciKey(in)
+    }
+    if !reflect.DeepEqual(got, tests) {
+        t.Errorf("pciKey = %v, want %v", got, tests)
+    }
+}
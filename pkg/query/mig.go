@@ -0,0 +1,115 @@
+package query
+
+import (
+    "fmt"
+    "strconv"
+    "strings"
+
+    pb "github.com/hiicl/GPU-over-IP-AC922/proto"
+)
+
+// mig.go MIG（多实例GPU）实例的枚举
+// 启用MIG的物理GPU（GPUInfo.MigEnabled）不能直接运行CUDA程序，其MIG实例作为独立条目紧跟在物理GPU之后列出：
+// 实例有自己的UUID（MIG-...），TotalMemory 为实例显存，Mig 字段记录所属GPU、GI/CI ID 和配置名称
+// 将 CUDA_VISIBLE_DEVICES 设置为实例UUID即可只使用该实例，因此实例和整卡一样按UUID占用和执行命令
+
+// MIGProfile MIG实例配置
+type MIGProfile struct {
+    Name   string // 配置名称（如 1g.5gb）
+    Slices int    // 占用的计算切片数
+    Memory int64  // 实例显存（MB）
+}
+
+// MIGSlices 每块GPU的计算切片数
+const MIGSlices = 7
+
+// migProfiles A100-SXM4-40GB 的实例配置，模拟后端按此表划分实例
+var migProfiles = []MIGProfile{
+    {Name: "1g.5gb", Slices: 1, Memory: 4864},
+    {Name: "2g.10gb", Slices: 2, Memory: 9984},
+    {Name: "3g.20gb", Slices: 3, Memory: 20096},
+    {Name: "4g.20gb", Slices: 4, Memory: 20096},
+    {Name: "7g.40gb", Slices: 7, Memory: 40320},
+}
+
+// LookupMIGProfile 按名称查找实例配置
+func LookupMIGProfile(name string) (MIGProfile, bool) {
+    for _, p := range migProfiles {
+        if p.Name == name {
+            return p, true
+        }
+    }
+    return MIGProfile{}, false
+}
+
+// ParseMIGLayout 解析MIG分区布局，格式为 "<GPU序号>:<配置>,<配置>;..."，
+// 如 "0:3g.20gb,2g.10gb,1g.5gb;1:7g.40gb"，返回 key 为GPU序号的配置列表
+func ParseMIGLayout(s string) (map[int][]string, error) {
+    layout := make(map[int][]string)
+    for _, part := range strings.Split(s, ";") {
+        part = strings.TrimSpace(part)
+        if part == "" {
+            continue
+        }
+        idx, profiles, ok := strings.Cut(part, ":")
+        if !ok {
+            return nil, fmt.Errorf("mig layout %q: expected <index>:<profile>,...", part)
+        }
+        i, err := strconv.Atoi(strings.TrimSpace(idx))
+        if err != nil || i < 0 {
+            return nil, fmt.Errorf("mig layout %q: invalid GPU index %q", part, idx)
+        }
+        if _, dup := layout[i]; dup {
+            return nil, fmt.Errorf("mig layout: GPU %d listed twice", i)
+        }
+        for _, p := range strings.Split(profiles, ",") {
+            layout[i] = append(layout[i], strings.TrimSpace(p))
+        }
+        if err := checkMIGProfiles(layout[i]); err != nil {
+            return nil, fmt.Errorf("mig layout GPU %d: %v", i, err)
+        }
+    }
+    return layout, nil
+}
+
+// checkMIGProfiles 检查配置名称有效且切片总数不超过 MIGSlices
+func checkMIGProfiles(profiles []string) error {
+    if len(profiles) == 0 {
+        return fmt.Errorf("no MIG profiles")
+    }
+    slices := 0
+    for _, name := range profiles {
+        p, ok := LookupMIGProfile(name)
+        if !ok {
+            return fmt.Errorf("unknown MIG profile %q", name)
+        }
+        slices += p.Slices
+    }
+    if slices > MIGSlices {
+        return fmt.Errorf("MIG profiles %s use %d slices, GPU has %d", strings.Join(profiles, ","), slices, MIGSlices)
+    }
+    return nil
+}
+
+// migInstance 生成物理GPU的一个MIG实例条目，设备序号、PCI总线、NUMA节点等与物理GPU相同
+func migInstance(parent *pb.GPUInfo, uuid, profile string, gi, ci int, memory int64) *pb.GPUInfo {
+    return &pb.GPUInfo{
+        Uuid:              uuid,
+        Name:              parent.Name + " MIG " + profile,
+        TotalMemory:       memory,
+        Index:             parent.Index,
+        PciBusId:          parent.PciBusId,
+        NumaNode:          parent.NumaNode,
+        DriverVersion:     parent.DriverVersion,
+        CudaVersion:       parent.CudaVersion,
+        ComputeCapability: parent.ComputeCapability,
+        PersistenceMode:   parent.PersistenceMode,
+        ComputeMode:       parent.ComputeMode,
+        Mig: &pb.MIGInfo{
+            ParentUuid:        parent.Uuid,
+            GpuInstanceId:     int32(gi),
+            ComputeInstanceId: int32(ci),
+            Profile:           profile,
+        },
+    }
+}
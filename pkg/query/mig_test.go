@@ -0,0 +1,71 @@
+package query
+
+import (
+    "reflect"
+    "strings"
+    "testing"
+)
+
+func TestParseMIGLayout(t *testing.T) {
+    tests := []struct {
+        layout string
+        want   map[int][]string
+        err    string // 期望错误信息包含的内容，为空表示应成功
+    }{
+        {layout: "", want: map[int][]string{}},
+        {
+            layout: "0:3g.20gb,2g.10gb,1g.5gb;1:7g.40gb",
+            want:   map[int][]string{0: {"3g.20gb", "2g.10gb", "1g.5gb"}, 1: {"7g.40gb"}},
+        },
+        {
+            // 恰好7个切片，允许空白
+            layout: " 2 : 1g.5gb, 1g.5gb,1g.5gb,1g.5gb,1g.5gb,1g.5gb,1g.5gb ; ",
+            want:   map[int][]string{2: {"1g.5gb", "1g.5gb", "1g.5gb", "1g.5gb", "1g.5gb", "1g.5gb", "1g.5gb"}},
+        },
+        {layout: "0:4g.20gb,4g.20gb", err: "use 8 slices, GPU has 7"},
+        {layout: "0:7g.40gb,1g.5gb", err: "use 8 slices"},
+        {layout: "0:1g.5gb,1g.5gb,1g.5gb,1g.5gb,1g.5gb,1g.5gb,1g.5gb,1g.5gb", err: "use 8 slices"},
+        {layout: "0:3g.20gb,8g.80gb", err: `unknown MIG profile "8g.80gb"`},
+        {layout: "0:", err: `unknown MIG profile ""`},
+        {layout: "3g.20gb", err: "expected <index>:<profile>"},
+        {layout: "x:1g.5gb", err: "invalid GPU index"},
+        {layout: "-1:1g.5gb", err: "invalid GPU index"},
+        {layout: "0:1g.5gb;0:2g.10gb", err: "GPU 0 listed twice"},
+    }
+    for _, tt := range tests {
+        got, err := ParseMIGLayout(tt.layout)
+        if tt.err != "" {
+            if err == nil || !strings.Contains(err.Error(), tt.err) {
+                t.Errorf("ParseMIGLayout(%q) error = %v, want %q", tt.layout, err, tt.err)
+            }
+            continue
+        }
+        if err != nil {
+            t.Errorf("ParseMIGLayout(%q): %v", tt.layout, err)
+            continue
+        }
+        if !reflect.DeepEqual(got, tt.want) {
+            t.Errorf("ParseMIGLayout(%q) = %v, want %v", tt.layout, got, tt.want)
+        }
+    }
+}
+
+func TestCheckMIGProfiles(t *testing.T) {
+    tests := []struct {
+        profiles []string
+        ok       bool
+    }{
+        {profiles: nil, ok: false},
+        {profiles: []string{"7g.40gb"}, ok: true},
+        {profiles: []string{"4g.20gb", "2g.10gb", "1g.5gb"}, ok: true},
+        {profiles: []string{"4g.20gb", "3g.20gb"}, ok: true},
+        {profiles: []string{"4g.20gb", "3g.20gb", "1g.5gb"}, ok: false}, // 8个切片
+        {profiles: []string{"3g.20gb", "3g.20gb", "2g.10gb"}, ok: false}, // 8个切片
+        {profiles: []string{"1g.10gb"}, ok: false},                       // 非本型号配置
+    }
+    for _, tt := range tests {
+        if err := checkMIGProfiles(tt.profiles); (err == nil) != tt.ok {
+            t.Errorf("checkMIGProfiles(%v) = %v, want ok=%v", tt.profiles, err, tt.ok)
+        }
+    }
+}
@@ -0,0 +1,417 @@
+//go:build nvml
+
+package query
+
+import (
+    "fmt"
+    "strings"
+    "time"
+
+    "github.com/NVIDIA/go-nvml/pkg/nvml"
+
+    "github.com/hiicl/GPU-over-IP-AC922/pkg/util"
+    pb "github.com/hiicl/GPU-over-IP-AC922/proto"
+)
+
+// nvml.go 实现基于 NVML 的查询后端，直接调用驱动库而不启动 nvidia-smi 进程
+// 依赖 cgo 和 libnvidia-ml.so，需使用 -tags nvml 构建
+
+// nvmlBackend NVML 后端，NVML 在创建时初始化，进程退出前不关闭
+// xids: 注册了XID严重错误事件的事件集，创建失败或设备都不支持事件时为nil
+type nvmlBackend struct {
+    xids nvml.EventSet
+}
+
+// computeModes NVML计算模式到 nvidia-smi 输出名称的映射
+var computeModes = map[nvml.ComputeMode]string{
+    nvml.COMPUTEMODE_DEFAULT:           "Default",
+    nvml.COMPUTEMODE_EXCLUSIVE_THREAD:  "Exclusive_Thread",
+    nvml.COMPUTEMODE_PROHIBITED:        "Prohibited",
+    nvml.COMPUTEMODE_EXCLUSIVE_PROCESS: "Exclusive_Process",
+}
+
+func newNVMLBackend() (Backend, error) {
+    if ret := nvml.Init(); ret != nvml.SUCCESS {
+        return nil, fmt.Errorf("nvml init: %s", nvml.ErrorString(ret))
+    }
+    b := nvmlBackend{}
+    b.xids = b.registerXIDEvents()
+    return b, nil
+}
+
+// registerXIDEvents 创建事件集并为所有设备注册XID严重错误事件
+// 不支持事件的设备（如部分旧GPU）被跳过，没有设备注册成功时返回nil
+func (b nvmlBackend) registerXIDEvents() nvml.EventSet {
+    devs, err := b.devices()
+    if err != nil {
+        return nil
+    }
+    set, ret := nvml.EventSetCreate()
+    if ret != nvml.SUCCESS {
+        util.Log("[Warn] nvml event set: %s, XID health rules disabled", nvml.ErrorString(ret))
+        return nil
+    }
+    registered := 0
+    for i, dev := range devs {
+        if ret := dev.RegisterEvents(nvml.EventTypeXidCriticalError, set); ret != nvml.SUCCESS {
+            util.Log("[Warn] nvml device %d: XID events not available: %s", i, nvml.ErrorString(ret))
+            continue
+        }
+        registered++
+    }
+    if registered == 0 {
+        set.Free()
+        return nil
+    }
+    return set
+}
+
+func (nvmlBackend) Name() string { return "nvml" }
+
+// devices 返回所有设备句柄
+func (nvmlBackend) devices() ([]nvml.Device, error) {
+    count, ret := nvml.DeviceGetCount()
+    if ret != nvml.SUCCESS {
+        return nil, fmt.Errorf("nvml device count: %s", nvml.ErrorString(ret))
+    }
+    var devs []nvml.Device
+    for i := 0; i < count; i++ {
+        dev, ret := nvml.DeviceGetHandleByIndex(i)
+        if ret != nvml.SUCCESS {
+            return nil, fmt.Errorf("nvml device %d: %s", i, nvml.ErrorString(ret))
+        }
+        devs = append(devs, dev)
+    }
+    return devs, nil
+}
+
+// nvmlMIGDevices 返回已启用MIG的设备上的MIG设备句柄，未启用或不支持MIG时返回nil
+func nvmlMIGDevices(dev nvml.Device) []nvml.Device {
+    current, _, ret := dev.GetMigMode()
+    if ret != nvml.SUCCESS || current != nvml.DEVICE_MIG_ENABLE {
+        return nil
+    }
+    count, ret := dev.GetMaxMigDeviceCount()
+    if ret != nvml.SUCCESS {
+        return nil
+    }
+    var migs []nvml.Device
+    for i := 0; i < count; i++ {
+        mig, ret := dev.GetMigDeviceHandleByIndex(i)
+        if ret != nvml.SUCCESS {
+            continue // 该序号上没有划分实例
+        }
+        migs = append(migs, mig)
+    }
+    return migs
+}
+
+// ListGPUs 查询GPU静态信息，字段格式与 nvidia-smi 后端一致（内存单位MB，PCI总线ID为8位域）
+func (b nvmlBackend) ListGPUs() ([]*pb.GPUInfo, error) {
+    devs, err := b.devices()
+    if err != nil {
+        return nil, err
+    }
+    driver, _ := nvml.SystemGetDriverVersion()
+    cudaVersion := ""
+    if v, ret := nvml.SystemGetCudaDriverVersion(); ret == nvml.SUCCESS {
+        cudaVersion = fmt.Sprintf("%d.%d", v/1000, v%1000/10) // 如 10020 -> 10.2
+    }
+
+    var result []*pb.GPUInfo
+    for i, dev := range devs {
+        uuid, ret := dev.GetUUID()
+        if ret != nvml.SUCCESS {
+            return nil, fmt.Errorf("nvml device %d uuid: %s", i, nvml.ErrorString(ret))
+        }
+        info := &pb.GPUInfo{
+            Uuid:          uuid,
+            Index:         int32(i),
+            DriverVersion: driver,
+            CudaVersion:   cudaVersion,
+        }
+        if index, ret := dev.GetIndex(); ret == nvml.SUCCESS {
+            info.Index = int32(index)
+        }
+        info.Name, _ = dev.GetName()
+        if mem, ret := dev.GetMemoryInfo(); ret == nvml.SUCCESS {
+            info.TotalMemory = int64(mem.Total >> 20)
+        }
+        if pci, ret := dev.GetPciInfo(); ret == nvml.SUCCESS {
+            info.PciBusId = fmt.Sprintf("%08X:%02X:%02X.0", pci.Domain, pci.Bus, pci.Device)
+            info.NumaNode = int32(PCINUMANode(info.PciBusId))
+        }
+        if major, minor, ret := dev.GetCudaComputeCapability(); ret == nvml.SUCCESS {
+            info.ComputeCapability = fmt.Sprintf("%d.%d", major, minor)
+        }
+        if mode, ret := dev.GetPersistenceMode(); ret == nvml.SUCCESS {
+            info.PersistenceMode = mode == nvml.FEATURE_ENABLED
+        }
+        if mode, ret := dev.GetComputeMode(); ret == nvml.SUCCESS {
+            info.ComputeMode = computeModes[mode]
+        }
+        result = append(result, info)
+
+        for _, mig := range nvmlMIGDevices(dev) {
+            uuid, ret := mig.GetUUID()
+            if ret != nvml.SUCCESS {
+                continue
+            }
+            info.MigEnabled = true
+            gi, _ := mig.GetGpuInstanceId()
+            ci, _ := mig.GetComputeInstanceId()
+            var total int64
+            if mem, ret := mig.GetMemoryInfo(); ret == nvml.SUCCESS {
+                total = int64(mem.Total >> 20)
+            }
+            // 实例名称如 "NVIDIA A100-SXM4-40GB MIG 1g.5gb"，配置名称在 "MIG " 之后
+            name, _ := mig.GetName()
+            profile := name
+            if i := strings.LastIndex(name, "MIG "); i >= 0 {
+                profile = name[i+len("MIG "):]
+            }
+            result = append(result, migInstance(info, uuid, profile, gi, ci, total))
+        }
+    }
+    return result, nil
+}
+
+// ListStatus 查询已使用显存和GPU利用率
+func (b nvmlBackend) ListStatus() (map[string]GPUStatus, error) {
+    devs, err := b.devices()
+    if err != nil {
+        return nil, err
+    }
+    result := make(map[string]GPUStatus)
+    for _, dev := range devs {
+        uuid, ret := dev.GetUUID()
+        if ret != nvml.SUCCESS {
+            continue
+        }
+        var st GPUStatus
+        if mem, ret := dev.GetMemoryInfo(); ret == nvml.SUCCESS {
+            st.UsedMemory = int64(mem.Used >> 20)
+        }
+        if u, ret := dev.GetUtilizationRates(); ret == nvml.SUCCESS {
+            st.Utilization = int32(u.Gpu)
+        }
+        result[uuid] = st
+        // MIG实例只报告已使用显存，利用率无法按实例查询
+        for _, mig := range nvmlMIGDevices(dev) {
+            uuid, ret := mig.GetUUID()
+            if ret != nvml.SUCCESS {
+                continue
+            }
+            var mst GPUStatus
+            if mem, ret := mig.GetMemoryInfo(); ret == nvml.SUCCESS {
+                mst.UsedMemory = int64(mem.Used >> 20)
+            }
+            result[uuid] = mst
+        }
+    }
+    return result, nil
+}
+
+// Processes 查询所有GPU上的计算进程
+func (b nvmlBackend) Processes() ([]Process, error) {
+    devs, err := b.devices()
+    if err != nil {
+        return nil, err
+    }
+    var procs []Process
+    for _, dev := range devs {
+        uuid, ret := dev.GetUUID()
+        if ret != nvml.SUCCESS {
+            continue
+        }
+        infos, ret := dev.GetComputeRunningProcesses()
+        if ret != nvml.SUCCESS {
+            return nil, fmt.Errorf("nvml processes on %s: %s", uuid, nvml.ErrorString(ret))
+        }
+        for _, p := range infos {
+            name, _ := nvml.SystemGetProcessName(int(p.Pid))
+            procs = append(procs, Process{
+                PID:        int(p.Pid),
+                UUID:       uuid,
+                Name:       name,
+                UsedMemory: int64(p.UsedGpuMemory >> 20),
+            })
+        }
+    }
+    return procs, nil
+}
+
+// Probe 在超时时间内查询设备数量，至少有一个GPU时返回nil
+// 驱动异常时 NVML 调用可能阻塞，探测在单独的协程中执行
+func (nvmlBackend) Probe(timeout time.Duration) error {
+    done := make(chan error, 1)
+    go func() {
+        count, ret := nvml.DeviceGetCount()
+        switch {
+        case ret != nvml.SUCCESS:
+            done <- fmt.Errorf("nvml device count: %s", nvml.ErrorString(ret))
+        case count == 0:
+            done <- fmt.Errorf("nvml found no GPUs")
+        default:
+            done <- nil
+        }
+    }()
+    select {
+    case err := <-done:
+        return err
+    case <-time.After(timeout):
+        return fmt.Errorf("nvml timed out after %s", timeout)
+    }
+}
+
+// XIDEvents 取出事件集中已到达的XID严重错误事件，不阻塞
+// NVML事件不带时间戳，以取出时间作为事件时间；取出后的事件不会再次返回，因此都晚于 since
+func (b nvmlBackend) XIDEvents(since time.Time) ([]XIDEvent, error) {
+    if b.xids == nil {
+        return nil, nil
+    }
+    var events []XIDEvent
+    for {
+        data, ret := b.xids.Wait(0)
+        if ret == nvml.ERROR_TIMEOUT {
+            return events, nil
+        }
+        if ret != nvml.SUCCESS {
+            return events, fmt.Errorf("nvml event wait: %s", nvml.ErrorString(ret))
+        }
+        if data.EventType != nvml.EventTypeXidCriticalError || data.Device == nil {
+            continue
+        }
+        uuid, ret := data.Device.GetUUID()
+        if ret != nvml.SUCCESS {
+            continue
+        }
+        events = append(events, XIDEvent{UUID: uuid, XID: int(data.EventData), Time: time.Now()})
+    }
+}
+
+// throttleReasonBits NVML降频原因位（nvmlClocksThrottleReason*）到名称的映射
+var throttleReasonBits = []struct {
+    bit  uint64
+    name string
+}{
+    {0x1, ThrottleGPUIdle},
+    {0x2, ThrottleApplicationsClocks},
+    {0x4, ThrottleSWPowerCap},
+    {0x8, ThrottleHWSlowdown},
+    {0x10, ThrottleSyncBoost},
+    {0x20, ThrottleSWThermalSlowdown},
+    {0x40, ThrottleHWThermalSlowdown},
+    {0x80, ThrottleHWPowerBrake},
+    {0x100, ThrottleDisplayClocks},
+}
+
+// Details 查询GPU详细信息，不支持的字段保持零值
+func (b nvmlBackend) Details() ([]*pb.GPUDetails, error) {
+    devs, err := b.devices()
+    if err != nil {
+        return nil, err
+    }
+    procs, err := b.Processes()
+    if err != nil {
+        return nil, err
+    }
+
+    var result []*pb.GPUDetails
+    for i, dev := range devs {
+        uuid, ret := dev.GetUUID()
+        if ret != nvml.SUCCESS {
+            return nil, fmt.Errorf("nvml device %d uuid: %s", i, nvml.ErrorString(ret))
+        }
+        d := &pb.GPUDetails{Uuid: uuid, Ecc: &pb.ECCErrors{}, RetiredPages: &pb.RetiredPages{}}
+        d.ProductName, _ = dev.GetName()
+        d.Serial, _ = dev.GetSerial()
+        d.VbiosVersion, _ = dev.GetVbiosVersion()
+        if ps, ret := dev.GetPerformanceState(); ret == nvml.SUCCESS {
+            d.PerformanceState = fmt.Sprintf("P%d", ps)
+        }
+        if mem, ret := dev.GetMemoryInfo(); ret == nvml.SUCCESS {
+            d.TotalMemory = int64(mem.Total >> 20)
+            d.UsedMemory = int64(mem.Used >> 20)
+        }
+        if u, ret := dev.GetUtilizationRates(); ret == nvml.SUCCESS {
+            d.Utilization = int32(u.Gpu)
+        }
+
+        if v, ret := dev.GetTemperature(nvml.TEMPERATURE_GPU); ret == nvml.SUCCESS {
+            d.Temperature = int32(v)
+        }
+        if v, ret := dev.GetTemperatureThreshold(nvml.TEMPERATURE_THRESHOLD_SLOWDOWN); ret == nvml.SUCCESS {
+            d.SlowdownTemperature = int32(v)
+        }
+        if v, ret := dev.GetTemperatureThreshold(nvml.TEMPERATURE_THRESHOLD_SHUTDOWN); ret == nvml.SUCCESS {
+            d.ShutdownTemperature = int32(v)
+        }
+        if mw, ret := dev.GetPowerUsage(); ret == nvml.SUCCESS {
+            d.PowerDraw = float64(mw) / 1000
+        }
+        if mw, ret := dev.GetEnforcedPowerLimit(); ret == nvml.SUCCESS {
+            d.PowerLimit = float64(mw) / 1000
+        }
+        d.Clocks = nvmlClocks(dev.GetClockInfo)
+        d.MaxClocks = nvmlClocks(dev.GetMaxClockInfo)
+
+        if current, _, ret := dev.GetEccMode(); ret == nvml.SUCCESS {
+            d.Ecc.Enabled = current == nvml.FEATURE_ENABLED
+        }
+        ecc := func(typ nvml.MemoryErrorType, counter nvml.EccCounterType) int64 {
+            v, ret := dev.GetTotalEccErrors(typ, counter)
+            if ret != nvml.SUCCESS {
+                return 0
+            }
+            return int64(v)
+        }
+        d.Ecc.VolatileSingleBit = ecc(nvml.MEMORY_ERROR_TYPE_CORRECTED, nvml.VOLATILE_ECC)
+        d.Ecc.VolatileDoubleBit = ecc(nvml.MEMORY_ERROR_TYPE_UNCORRECTED, nvml.VOLATILE_ECC)
+        d.Ecc.AggregateSingleBit = ecc(nvml.MEMORY_ERROR_TYPE_CORRECTED, nvml.AGGREGATE_ECC)
+        d.Ecc.AggregateDoubleBit = ecc(nvml.MEMORY_ERROR_TYPE_UNCORRECTED, nvml.AGGREGATE_ECC)
+
+        if pages, ret := dev.GetRetiredPages(nvml.PAGE_RETIREMENT_CAUSE_MULTIPLE_SINGLE_BIT_ECC_ERRORS); ret == nvml.SUCCESS {
+            d.RetiredPages.SingleBit = int32(len(pages))
+        }
+        if pages, ret := dev.GetRetiredPages(nvml.PAGE_RETIREMENT_CAUSE_DOUBLE_BIT_ECC_ERROR); ret == nvml.SUCCESS {
+            d.RetiredPages.DoubleBit = int32(len(pages))
+        }
+        if pending, ret := dev.GetRetiredPagesPendingStatus(); ret == nvml.SUCCESS {
+            d.RetiredPages.PendingRetirement = pending == nvml.FEATURE_ENABLED
+        }
+
+        if reasons, ret := dev.GetCurrentClocksThrottleReasons(); ret == nvml.SUCCESS {
+            for _, r := range throttleReasonBits {
+                if reasons&r.bit != 0 {
+                    d.ThrottleReasons = append(d.ThrottleReasons, r.name)
+                }
+            }
+        }
+
+        for _, p := range procs {
+            if p.UUID == uuid {
+                d.Processes = append(d.Processes, &pb.GPUProcess{Pid: int32(p.PID), Name: p.Name, Type: "C", UsedMemory: p.UsedMemory})
+            }
+        }
+        result = append(result, d)
+    }
+    return result, nil
+}
+
+// nvmlClocks 使用 GetClockInfo 或 GetMaxClockInfo 查询各时钟域
+func nvmlClocks(get func(nvml.ClockType) (uint32, nvml.Return)) *pb.GPUClocks {
+    clock := func(t nvml.ClockType) int32 {
+        v, ret := get(t)
+        if ret != nvml.SUCCESS {
+            return 0
+        }
+        return int32(v)
+    }
+    return &pb.GPUClocks{
+        Graphics: clock(nvml.CLOCK_GRAPHICS),
+        Sm:       clock(nvml.CLOCK_SM),
+        Memory:   clock(nvml.CLOCK_MEM),
+        Video:    clock(nvml.CLOCK_VIDEO),
+    }
+}
@@ -0,0 +1,11 @@
+//go:build !nvml
+
+package query
+
+import "fmt"
+
+// nvml_stub.go 未使用 -tags nvml 构建时 NVML 后端不可用
+
+func newNVMLBackend() (Backend, error) {
+    return nil, fmt.Errorf("NVML backend not compiled in, rebuild with -tags nvml")
+}
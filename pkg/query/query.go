@@ -1,116 +1,122 @@
-package query
-
-import (
-    "bytes"
-    "encoding/csv"
-    "log"
-    "os/exec"
-    "strconv"
-    "strings"
-
-    pb "github.com/hiicl/GPU-over-IP-AC922/proto"
-)
-
-// query 包提供GPU查询功能，使用nvidia-smi命令行工具获取GPU信息
-
-// GPUStatus 表示GPU的当前使用状态
-// UsedMemory: 当前已使用内存（单位：MB）
-// Utilization: GPU利用率百分比（0-100）
-type GPUStatus struct {
-    UsedMemory  int64
-    Utilization int32
-}
-
-// ListGPUs 查询系统中所有可用的NVIDIA GPU信息
-// 返回包含GPU UUID、名称和总内存的GPUInfo对象列表
-// 使用nvidia-smi命令查询GPU信息：
-//   --query-gpu=uuid,name,memory.total: 查询GPU的UUID、名称和总内存
-//   --format=csv,noheader,nounits: 输出CSV格式，无标题行和单位
-func ListGPUs() []*pb.GPUInfo {
-    // 创建执行nvidia-smi的命令
-    cmd := exec.Command("nvidia-smi",
-        "--query-gpu=uuid,name,memory.total",
-        "--format=csv,noheader,nounits")
-    
-    // 执行命令并获取输出
-    out, err := cmd.Output()
-    if err != nil {
-        util.Log("nvidia-smi failed: %v", err)
-        return nil
-    }
-
-    // 创建CSV读取器
-    r := csv.NewReader(bytes.NewReader(out))
-    r.Comma = ',' // 设置分隔符为逗号
-
-    var result []*pb.GPUInfo
-    lines, _ := r.ReadAll() // 读取所有CSV记录
-    
-    // 遍历每条记录
-    for _, line := range lines {
-        // 去除每个字段的空格
-        for i := range line {
-            line[i] = strings.TrimSpace(line[i])
-        }
-        // 将内存字符串转换为int64
-        mem, _ := strconv.ParseInt(line[2], 10, 64)
-        
-        // 创建GPUInfo对象并添加到结果列表
-        result = append(result, &pb.GPUInfo{
-            Uuid:        line[0],  // UUID
-            Name:        line[1],  // GPU名称
-            TotalMemory: mem,      // 总内存（MB）
-        })
-    }
-
-    return result
-}
-
-// GetGPUStatus 根据GPU的UUID获取其当前使用状态
-// uuid: 要查询的GPU的唯一标识符
-// 返回GPUStatus结构体，包含已使用内存和利用率信息
-// 使用nvidia-smi命令查询GPU状态：
-//   --query-gpu=uuid,memory.used,utilization.gpu: 查询GPU的UUID、已使用内存和利用率
-//   --format=csv,noheader,nounits: 输出CSV格式，无标题行和单位
-func GetGPUStatus(uuid string) GPUStatus {
-    // 创建执行nvidia-smi的命令
-    cmd := exec.Command("nvidia-smi",
-        "--query-gpu=uuid,memory.used,utilization.gpu",
-        "--format=csv,noheader,nounits")
-    
-    // 执行命令并获取输出
-    out, err := cmd.Output()
-    if err != nil {
-        util.Log("nvidia-smi status failed: %v", err)
-        return GPUStatus{}
-    }
-
-    // 将输出按行分割
-    lines := strings.Split(string(out), "\n")
-    
-    // 遍历每行输出
-    for _, line := range lines {
-        // 按逗号分割字段
-        fields := strings.Split(line, ",")
-        if len(fields) < 3 {
-            continue // 跳过字段不足的行
-        }
-        
-        // 检查UUID是否匹配
-        if strings.TrimSpace(fields[0]) == uuid {
-            // 解析已使用内存
-            used, _ := strconv.ParseInt(strings.TrimSpace(fields[1]), 10, 64)
-            // 解析利用率
-            util, _ := strconv.Atoi(strings.TrimSpace(fields[2]))
-            
-            // 返回匹配的GPU状态
-            return GPUStatus{
-                UsedMemory:  used,         // 已使用内存（MB）
-                Utilization: int32(util),  // GPU利用率（0-100）
-            }
-        }
-    }
-
-    // 未找到匹配的GPU，返回空状态
-    return GPUStatus{}
-}
+package query
+
+import (
+    "time"
+
+    "github.com/hiicl/GPU-over-IP-AC922/pkg/util"
+    pb "github.com/hiicl/GPU-over-IP-AC922/proto"
+)
+
+// query 包提供GPU查询功能，通过可替换的后端（nvidia-smi、NVML 或模拟后端）获取GPU信息
+// 启用采集器（SetCollector）后，ListGPUs 和 GPU 状态查询从采集器的快照返回
+
+// GPUStatus 表示GPU的当前使用状态
+// UsedMemory: 当前已使用内存（单位：MB）
+// Utilization: GPU利用率百分比（0-100）
+type GPUStatus struct {
+    UsedMemory  int64
+    Utilization int32
+}
+
+// ListGPUs 查询系统中所有可用的NVIDIA GPU信息
+// 返回包含GPU UUID、名称、总内存、设备序号、PCI总线、NUMA节点、驱动/CUDA版本、
+// 计算能力以及持久化/计算模式的GPUInfo对象列表，查询失败时返回nil
+// 启用MIG时，MIG实例作为独立条目紧跟在所属物理GPU之后
+func ListGPUs() []*pb.GPUInfo {
+    if c := activeCollector(); c != nil {
+        return c.Latest().CloneGPUs()
+    }
+    b := Current()
+    gpus, err := b.ListGPUs()
+    if err != nil {
+        util.Log("%s failed: %v", b.Name(), err)
+        return nil
+    }
+    return gpus
+}
+
+// GetGPUStatus 根据GPU的UUID获取其当前使用状态
+// uuid: 要查询的GPU的唯一标识符
+// 返回GPUStatus结构体，包含已使用内存和利用率信息；未找到GPU时返回空状态
+func GetGPUStatus(uuid string) GPUStatus {
+    if c := activeCollector(); c != nil {
+        return c.Latest().Status[uuid]
+    }
+    return ListGPUStatus()[uuid]
+}
+
+// ListGPUStatus 一次性查询所有GPU的当前使用状态
+// 返回以GPU UUID为键的状态映射，查询失败时返回空映射
+func ListGPUStatus() map[string]GPUStatus {
+    if c := activeCollector(); c != nil {
+        result := make(map[string]GPUStatus)
+        for uuid, st := range c.Latest().Status {
+            result[uuid] = st
+        }
+        return result
+    }
+    b := Current()
+    status, err := b.ListStatus()
+    if err != nil {
+        util.Log("%s status failed: %v", b.Name(), err)
+        return make(map[string]GPUStatus)
+    }
+    return status
+}
+
+// ListProcesses 列出所有GPU上的计算进程
+func ListProcesses() ([]Process, error) {
+    return Current().Processes()
+}
+
+// XIDEvents 返回当前后端在 since 之后报告的XID事件，后端不支持时返回nil
+func XIDEvents(since time.Time) []XIDEvent {
+    b := Current()
+    r, ok := b.(XIDReporter)
+    if !ok {
+        return nil
+    }
+    events, err := r.XIDEvents(since)
+    if err != nil {
+        util.Log("%s xid events failed: %v", b.Name(), err)
+        return nil
+    }
+    return events
+}
+
+// ListDetails 查询所有GPU的详细信息，后端不支持时返回 ErrUnsupported
+func ListDetails() ([]*pb.GPUDetails, error) {
+    r, ok := Current().(DetailReporter)
+    if !ok {
+        return nil, ErrUnsupported
+    }
+    all, err := r.Details()
+    if err != nil {
+        return nil, err
+    }
+    now := time.Now().UnixMilli()
+    for _, d := range all {
+        d.Timestamp = now
+    }
+    return all, nil
+}
+
+// Details 查询指定GPU的详细信息
+// 后端不支持时返回 ErrUnsupported，未找到GPU时返回 ErrNotFound
+func Details(uuid string) (*pb.GPUDetails, error) {
+    all, err := ListDetails()
+    if err != nil {
+        return nil, err
+    }
+    for _, d := range all {
+        if d.Uuid == uuid {
+            return d, nil
+        }
+    }
+    return nil, ErrNotFound
+}
+
+// Probe 检查GPU后端是否可用，用于健康检查，返回nil表示后端正常
+func Probe(timeout time.Duration) error {
+    return Current().Probe(timeout)
+}
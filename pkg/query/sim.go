@@ -0,0 +1,507 @@
+package query
+
+import (
+    "fmt"
+    "os"
+    "sort"
+    "sync"
+    "time"
+
+    "google.golang.org/protobuf/proto"
+    "gopkg.in/yaml.v3"
+
+    pb "github.com/hiicl/GPU-over-IP-AC922/proto"
+)
+
+// sim.go 实现按YAML场景运行的模拟后端，用于在没有GPU的机器上演练调度、负载均衡和故障处理
+// 场景时间从创建后端时开始计算，指定 loop 时曲线、进程和故障按周期重复
+// 场景文件示例：
+//   name: ac922-flaky
+//   driverVersion: 440.64.00     # 可选
+//   cudaVersion: "10.2"          # 可选
+//   loop: 10m                    # 可选，场景周期
+//   numa:                        # GPU 按列出顺序依次编号
+//     - node: 0
+//       gpus: 2
+//       model: Tesla V100-SXM2-16GB  # 可选
+//       memory: 16160                # 可选，单块GPU显存（MB）
+//       nics: [ib0, enP4p1s0f0]      # 可选，NUMA节点上的网卡
+//       memGB: 256                   # 可选，NUMA节点内存
+//     - node: 8
+//       gpus: 2
+//   gpus:                        # 按GPU序号设置的曲线（线性插值，最后一个点之后保持不变）
+//     0:
+//       utilization: [{at: 0s, value: 0}, {at: 30s, value: 95}, {at: 5m, value: 10}]
+//       memory: [{at: 0s, value: 0}, {at: 30s, value: 14000}]   # 已使用显存（MB）
+//       temperature: [{at: 0s, value: 35}, {at: 5m, value: 80}]  # 可选，GetGPUDetails 的温度和功耗
+//       power: [{at: 0s, value: 40}, {at: 30s, value: 290}]
+//   processes:
+//     - {gpu: 1, pid: 4242, name: python, memory: 8000, at: 10s, duration: 2m}
+//   faults:
+//     - {type: xid, gpu: 1, xid: 79, at: 1m}          # XID错误事件
+//     - {type: disappear, gpu: 3, at: 2m, duration: 30s}  # GPU从列表中消失
+//     - {type: hang, at: 3m, duration: 20s}           # 查询阻塞直到故障结束
+//     - {type: error, at: 4m, duration: 10s}          # 查询失败
+//     - {type: ecc, gpu: 2, count: 2, at: 5m}         # 不可纠正ECC错误（GetGPUDetails）
+//     - {type: thermal, gpu: 0, at: 6m, duration: 1m} # 温度降频（GetGPUDetails）
+// duration 为0表示故障（或进程）一直持续
+
+// 故障类型
+const (
+    FaultXID       = "xid"       // 产生XID错误事件
+    FaultDisappear = "disappear" // GPU从查询结果中消失（如掉卡）
+    FaultHang      = "hang"      // 查询阻塞（如 nvidia-smi 挂起）
+    FaultError     = "error"     // 查询返回错误（如驱动无法通信）
+    FaultECC       = "ecc"       // 产生不可纠正（双比特）ECC错误
+    FaultThermal   = "thermal"   // GPU因温度过高降频
+)
+
+// Scenario 模拟场景
+type Scenario struct {
+    Name          string         `yaml:"name"`
+    DriverVersion string         `yaml:"driverVersion"`
+    CUDAVersion   string         `yaml:"cudaVersion"`
+    Loop          time.Duration  `yaml:"loop"`
+    NUMA          []SimNUMA      `yaml:"numa"`
+    GPUs          map[int]SimGPU `yaml:"gpus"` // key: GPU序号
+    Processes     []SimProcess   `yaml:"processes"`
+    Faults        []SimFault     `yaml:"faults"`
+}
+
+// SimNUMA 一个NUMA节点上的模拟GPU和网卡
+type SimNUMA struct {
+    Node   int      `yaml:"node"`
+    GPUs   int      `yaml:"gpus"`
+    Model  string   `yaml:"model"`
+    Memory int64    `yaml:"memory"`
+    NICs   []string `yaml:"nics"`
+    MemGB  uint64   `yaml:"memGB"`
+}
+
+// SimGPU 单块GPU随时间变化的利用率、已使用显存、温度和功耗
+type SimGPU struct {
+    Utilization Curve `yaml:"utilization"`
+    Memory      Curve `yaml:"memory"`
+    Temperature Curve `yaml:"temperature"` // 摄氏度，未设置时使用模板值
+    Power       Curve `yaml:"power"`       // 瓦，未设置时使用模板值
+}
+
+// SimProcess 模拟的GPU计算进程，在 [at, at+duration) 内存在
+type SimProcess struct {
+    GPU      int           `yaml:"gpu"`
+    PID      int           `yaml:"pid"`
+    Name     string        `yaml:"name"`
+    Memory   int64         `yaml:"memory"`
+    At       time.Duration `yaml:"at"`
+    Duration time.Duration `yaml:"duration"`
+}
+
+// SimFault 注入的故障，在 [at, at+duration) 内生效；xid 故障在 at 时刻产生一次事件
+type SimFault struct {
+    Type     string        `yaml:"type"`
+    GPU      int           `yaml:"gpu"`
+    XID      int           `yaml:"xid"`
+    Count    int64         `yaml:"count"` // ecc 故障的错误数，默认为1
+    At       time.Duration `yaml:"at"`
+    Duration time.Duration `yaml:"duration"`
+}
+
+// CurvePoint 曲线上的一个点
+type CurvePoint struct {
+    At    time.Duration `yaml:"at"`
+    Value float64       `yaml:"value"`
+}
+
+// Curve 按时间排序的曲线
+type Curve []CurvePoint
+
+// Value 返回 t 时刻的值：第一个点之前取第一个点的值，点之间线性插值，最后一个点之后保持不变
+func (c Curve) Value(t time.Duration) float64 {
+    if len(c) == 0 {
+        return 0
+    }
+    if t <= c[0].At {
+        return c[0].Value
+    }
+    for i := 1; i < len(c); i++ {
+        if t < c[i].At {
+            a, b := c[i-1], c[i]
+            return a.Value + (b.Value-a.Value)*float64(t-a.At)/float64(b.At-a.At)
+        }
+    }
+    return c[len(c)-1].Value
+}
+
+// active 判断 [at, at+duration) 是否包含 t，duration 为0表示一直持续
+func active(at, duration, t time.Duration) bool {
+    return t >= at && (duration == 0 || t < at+duration)
+}
+
+// LoadScenario 读取并校验YAML场景文件
+func LoadScenario(path string) (*Scenario, error) {
+    data, err := os.ReadFile(path)
+    if err != nil {
+        return nil, fmt.Errorf("read scenario file: %v", err)
+    }
+    sc := &Scenario{}
+    if err := yaml.Unmarshal(data, sc); err != nil {
+        return nil, fmt.Errorf("parse scenario file %s: %v", path, err)
+    }
+    if err := sc.init(); err != nil {
+        return nil, fmt.Errorf("scenario file %s: %v", path, err)
+    }
+    return sc, nil
+}
+
+// init 填充默认值并校验GPU序号、曲线和故障
+func (sc *Scenario) init() error {
+    if sc.DriverVersion == "" {
+        sc.DriverVersion = "440.64.00"
+    }
+    if sc.CUDAVersion == "" {
+        sc.CUDAVersion = "10.2"
+    }
+    if sc.Loop < 0 {
+        return fmt.Errorf("loop must not be negative")
+    }
+    total := 0
+    seen := make(map[int]bool)
+    for i := range sc.NUMA {
+        n := &sc.NUMA[i]
+        if seen[n.Node] {
+            return fmt.Errorf("numa node %d listed twice", n.Node)
+        }
+        seen[n.Node] = true
+        if n.GPUs < 0 {
+            return fmt.Errorf("numa node %d: gpus must not be negative", n.Node)
+        }
+        if n.Model == "" {
+            n.Model = "Tesla V100-SXM2-16GB"
+        }
+        if n.Memory == 0 {
+            n.Memory = fakeTotalMemory
+        }
+        total += n.GPUs
+    }
+    if total == 0 {
+        return fmt.Errorf("scenario has no GPUs")
+    }
+    checkGPU := func(where string, index int) error {
+        if index < 0 || index >= total {
+            return fmt.Errorf("%s: gpu %d out of range (0-%d)", where, index, total-1)
+        }
+        return nil
+    }
+    for index, g := range sc.GPUs {
+        if err := checkGPU("gpus", index); err != nil {
+            return err
+        }
+        curves := map[string]Curve{"utilization": g.Utilization, "memory": g.Memory, "temperature": g.Temperature, "power": g.Power}
+        for name, c := range curves {
+            if !sort.SliceIsSorted(c, func(i, j int) bool { return c[i].At < c[j].At }) {
+                return fmt.Errorf("gpu %d: %s points must be sorted by time", index, name)
+            }
+        }
+    }
+    for _, p := range sc.Processes {
+        if err := checkGPU(fmt.Sprintf("process %d", p.PID), p.GPU); err != nil {
+            return err
+        }
+    }
+    for i, f := range sc.Faults {
+        where := fmt.Sprintf("fault %d (%s)", i, f.Type)
+        switch f.Type {
+        case FaultXID:
+            if f.XID <= 0 {
+                return fmt.Errorf("%s: xid is required", where)
+            }
+            fallthrough
+        case FaultDisappear, FaultThermal:
+            if err := checkGPU(where, f.GPU); err != nil {
+                return err
+            }
+        case FaultECC:
+            if f.Count == 0 {
+                sc.Faults[i].Count = 1
+            }
+            if err := checkGPU(where, f.GPU); err != nil {
+                return err
+            }
+        case FaultHang, FaultError:
+        default:
+            return fmt.Errorf("%s: unknown fault type", where)
+        }
+    }
+    return nil
+}
+
+// SimBackend 按场景运行的模拟后端
+type SimBackend struct {
+    sc   *Scenario
+    gpus []*pb.GPUInfo
+
+    mu    sync.Mutex
+    start time.Time        // 场景开始时间
+    now   func() time.Time // 当前时间，可替换以加速或回放场景
+}
+
+// NewSimBackend 创建模拟后端，场景时间从此刻开始
+func NewSimBackend(sc *Scenario) *SimBackend {
+    b := &SimBackend{sc: sc, start: time.Now(), now: time.Now}
+    for _, n := range sc.NUMA {
+        for j := 0; j < n.GPUs; j++ {
+            index := len(b.gpus)
+            b.gpus = append(b.gpus, &pb.GPUInfo{
+                Uuid:              SimUUID(n.Node, index),
+                Name:              n.Model,
+                TotalMemory:       n.Memory,
+                Index:             int32(index),
+                PciBusId:          fmt.Sprintf("%08X:%02X:00.0", n.Node, 4+j),
+                NumaNode:          int32(n.Node),
+                DriverVersion:     sc.DriverVersion,
+                CudaVersion:       sc.CUDAVersion,
+                ComputeCapability: "7.0",
+                PersistenceMode:   true,
+                ComputeMode:       "Default",
+            })
+        }
+    }
+    return b
+}
+
+// SimUUID 返回模拟GPU的UUID
+func SimUUID(node, index int) string {
+    return fmt.Sprintf("GPU-5133a000-0000-0000-%04x-%012x", node, index)
+}
+
+func (b *SimBackend) Name() string { return "sim" }
+
+// Scenario 返回后端使用的场景
+func (b *SimBackend) Scenario() *Scenario { return b.sc }
+
+// SetClock 替换场景时钟，场景时间从 now() 当前值重新开始
+func (b *SimBackend) SetClock(now func() time.Time) {
+    b.mu.Lock()
+    defer b.mu.Unlock()
+    b.now = now
+    b.start = now()
+}
+
+// elapsed 返回当前场景时间（指定 loop 时为周期内的时间）和当前时刻
+func (b *SimBackend) elapsed() (time.Duration, time.Time) {
+    b.mu.Lock()
+    defer b.mu.Unlock()
+    now := b.now()
+    t := now.Sub(b.start)
+    if b.sc.Loop > 0 {
+        t %= b.sc.Loop
+    }
+    return t, now
+}
+
+// fault 返回 t 时刻生效的指定类型故障（gpu 为-1时不检查GPU）
+func (b *SimBackend) fault(typ string, gpu int, t time.Duration) *SimFault {
+    for i, f := range b.sc.Faults {
+        if f.Type == typ && (gpu < 0 || f.GPU == gpu) && active(f.At, f.Duration, t) {
+            return &b.sc.Faults[i]
+        }
+    }
+    return nil
+}
+
+// query 模拟一次查询：hang 故障期间阻塞到故障结束，error 故障期间返回错误
+// 返回查询完成时的场景时间
+func (b *SimBackend) query() (time.Duration, error) {
+    t, _ := b.elapsed()
+    if f := b.fault(FaultHang, -1, t); f != nil {
+        if f.Duration == 0 {
+            select {} // 永久挂起
+        }
+        time.Sleep(f.At + f.Duration - t)
+        t, _ = b.elapsed()
+    }
+    if b.fault(FaultError, -1, t) != nil {
+        return t, fmt.Errorf("simulated GPU query failure")
+    }
+    return t, nil
+}
+
+// visible 返回 t 时刻未消失的GPU
+func (b *SimBackend) visible(t time.Duration) []*pb.GPUInfo {
+    var result []*pb.GPUInfo
+    for _, g := range b.gpus {
+        if b.fault(FaultDisappear, int(g.Index), t) == nil {
+            result = append(result, g)
+        }
+    }
+    return result
+}
+
+// ListGPUs 返回当前可见GPU信息的副本
+func (b *SimBackend) ListGPUs() ([]*pb.GPUInfo, error) {
+    t, err := b.query()
+    if err != nil {
+        return nil, err
+    }
+    var result []*pb.GPUInfo
+    for _, g := range b.visible(t) {
+        result = append(result, proto.Clone(g).(*pb.GPUInfo))
+    }
+    return result, nil
+}
+
+// ListStatus 按场景曲线计算可见GPU的状态，已使用显存不超过总显存，利用率限制在0-100
+// 曲线未覆盖的显存按运行中的模拟进程累加
+func (b *SimBackend) ListStatus() (map[string]GPUStatus, error) {
+    t, err := b.query()
+    if err != nil {
+        return nil, err
+    }
+    procMem := make(map[int]int64)
+    for _, p := range b.sc.Processes {
+        if active(p.At, p.Duration, t) {
+            procMem[p.GPU] += p.Memory
+        }
+    }
+    result := make(map[string]GPUStatus)
+    for _, g := range b.visible(t) {
+        index := int(g.Index)
+        curves := b.sc.GPUs[index]
+        used := procMem[index]
+        if len(curves.Memory) > 0 {
+            used = int64(curves.Memory.Value(t))
+        }
+        result[g.Uuid] = GPUStatus{
+            UsedMemory:  clamp(used, 0, g.TotalMemory),
+            Utilization: int32(clamp(int64(curves.Utilization.Value(t)), 0, 100)),
+        }
+    }
+    return result, nil
+}
+
+// Processes 返回当前运行在可见GPU上的模拟进程
+func (b *SimBackend) Processes() ([]Process, error) {
+    t, err := b.query()
+    if err != nil {
+        return nil, err
+    }
+    uuids := make(map[int]string)
+    for _, g := range b.visible(t) {
+        uuids[int(g.Index)] = g.Uuid
+    }
+    var procs []Process
+    for _, p := range b.sc.Processes {
+        uuid, ok := uuids[p.GPU]
+        if ok && active(p.At, p.Duration, t) {
+            procs = append(procs, Process{PID: p.PID, UUID: uuid, Name: p.Name, UsedMemory: p.Memory})
+        }
+    }
+    return procs, nil
+}
+
+// Details 返回可见GPU的详细信息：以录制的模板为基础，状态、进程、温度和功耗取自场景
+func (b *SimBackend) Details() ([]*pb.GPUDetails, error) {
+    status, err := b.ListStatus()
+    if err != nil {
+        return nil, err
+    }
+    procs, err := b.Processes()
+    if err != nil {
+        return nil, err
+    }
+    t, _ := b.elapsed()
+    var result []*pb.GPUDetails
+    for _, g := range b.visible(t) {
+        st, ok := status[g.Uuid]
+        if !ok {
+            continue // 查询期间消失
+        }
+        d, err := fixtureDetails(g, st, procs)
+        if err != nil {
+            return nil, err
+        }
+        curves := b.sc.GPUs[int(g.Index)]
+        if len(curves.Temperature) > 0 {
+            d.Temperature = int32(curves.Temperature.Value(t))
+        }
+        if len(curves.Power) > 0 {
+            d.PowerDraw = curves.Power.Value(t)
+        }
+        if f := b.fault(FaultECC, int(g.Index), t); f != nil {
+            d.Ecc.VolatileDoubleBit += f.Count
+            d.Ecc.AggregateDoubleBit += f.Count
+        }
+        if b.fault(FaultThermal, int(g.Index), t) != nil {
+            d.ThrottleReasons = append(d.ThrottleReasons, ThrottleHWThermalSlowdown)
+            if d.Temperature < d.SlowdownTemperature {
+                d.Temperature = d.SlowdownTemperature
+            }
+        }
+        result = append(result, d)
+    }
+    return result, nil
+}
+
+// Probe 模拟 nvidia-smi -L：hang 故障超过 timeout 时返回超时错误，所有GPU消失时返回错误
+func (b *SimBackend) Probe(timeout time.Duration) error {
+    t, _ := b.elapsed()
+    if f := b.fault(FaultHang, -1, t); f != nil {
+        if f.Duration == 0 || f.At+f.Duration-t > timeout {
+            time.Sleep(timeout)
+            return fmt.Errorf("simulated GPU query timed out after %s", timeout)
+        }
+    }
+    t, err := b.query()
+    if err != nil {
+        return err
+    }
+    if len(b.visible(t)) == 0 {
+        return fmt.Errorf("simulated backend listed no GPUs")
+    }
+    return nil
+}
+
+// XIDEvents 返回 (since, 现在] 之间产生的XID事件，按时间排序
+// 指定 loop 时每个周期重复产生场景中的XID事件
+func (b *SimBackend) XIDEvents(since time.Time) ([]XIDEvent, error) {
+    _, now := b.elapsed()
+    b.mu.Lock()
+    start := b.start
+    b.mu.Unlock()
+
+    // 需要检查的周期范围
+    first, last := 0, 0
+    if loop := b.sc.Loop; loop > 0 {
+        if since.After(start) {
+            first = int(since.Sub(start) / loop)
+        }
+        last = int(now.Sub(start) / loop)
+    }
+
+    var events []XIDEvent
+    for cycle := first; cycle <= last; cycle++ {
+        base := start.Add(time.Duration(cycle) * b.sc.Loop)
+        for _, f := range b.sc.Faults {
+            if f.Type != FaultXID {
+                continue
+            }
+            ts := base.Add(f.At)
+            if ts.After(since) && !ts.After(now) {
+                events = append(events, XIDEvent{UUID: b.gpus[f.GPU].Uuid, XID: f.XID, Time: ts})
+            }
+        }
+    }
+    sort.Slice(events, func(i, j int) bool { return events[i].Time.Before(events[j].Time) })
+    return events, nil
+}
+
+// clamp 将 v 限制在 [lo, hi] 范围内
+func clamp(v, lo, hi int64) int64 {
+    if v < lo {
+        return lo
+    }
+    if v > hi {
+        return hi
+    }
+    return v
+}
@@ -0,0 +1,197 @@
+package query
+
+import (
+    "reflect"
+    "strings"
+    "testing"
+    "time"
+)
+
+// newSimTest 加载 fixtures/sim-ac922.yaml，返回场景时钟固定在 base 的模拟后端
+// 修改 *clock 即可把场景推进到任意时刻
+func newSimTest(t *testing.T) (b *SimBackend, base time.Time, clock *time.Time) {
+    t.Helper()
+    sc, err := LoadScenario("fixtures/sim-ac922.yaml")
+    if err != nil {
+        t.Fatalf("LoadScenario: %v", err)
+    }
+    base = time.Date(2020, 6, 1, 0, 0, 0, 0, time.UTC)
+    now := base
+    b = NewSimBackend(sc)
+    b.SetClock(func() time.Time { return now })
+    return b, base, &now
+}
+
+func TestCurveValue(t *testing.T) {
+    c := Curve{{At: 10 * time.Second, Value: 20}, {At: 30 * time.Second, Value: 60}, {At: time.Minute, Value: 0}}
+    tests := []struct {
+        at   time.Duration
+        want float64
+    }{
+        {at: 0, want: 20},                 // 第一个点之前
+        {at: 10 * time.Second, want: 20},
+        {at: 20 * time.Second, want: 40},  // 线性插值
+        {at: 30 * time.Second, want: 60},
+        {at: 45 * time.Second, want: 30},
+        {at: time.Minute, want: 0},
+        {at: time.Hour, want: 0},          // 最后一个点之后保持不变
+    }
+    for _, tt := range tests {
+        if got := c.Value(tt.at); got != tt.want {
+            t.Errorf("Value(%v) = %v, want %v", tt.at, got, tt.want)
+        }
+    }
+    if got := Curve(nil).Value(time.Second); got != 0 {
+        t.Errorf("empty curve Value = %v, want 0", got)
+    }
+}
+
+// TestSimStatus 状态按曲线和模拟进程计算，已使用显存不超过总显存，指定 loop 时按周期重复
+func TestSimStatus(t *testing.T) {
+    b, base, clock := newSimTest(t)
+    gpu0, gpu1 := SimUUID(0, 0), SimUUID(0, 1)
+
+    tests := []struct {
+        at         time.Duration
+        util0      int32
+        mem0, mem1 int64
+    }{
+        {at: 0, util0: 0, mem0: 0, mem1: 0},
+        {at: 15 * time.Second, util0: 45, mem0: 7000, mem1: 8000},   // 进程 4242 从 10s 开始
+        {at: 40 * time.Second, util0: 87, mem0: 16000, mem1: 8000},
+        {at: 90 * time.Second, util0: 72, mem0: fakeTotalMemory, mem1: 8000}, // 曲线超出总显存
+        {at: 165 * time.Second, util0: 50, mem0: fakeTotalMemory, mem1: 0},   // 进程在 2m10s 结束
+        {at: 8 * time.Minute, util0: 10, mem0: fakeTotalMemory, mem1: 0},
+        {at: 10*time.Minute + 15*time.Second, util0: 45, mem0: 7000, mem1: 8000}, // 第二个周期
+    }
+    for _, tt := range tests {
+        *clock = base.Add(tt.at)
+        status, err := b.ListStatus()
+        if err != nil {
+            t.Errorf("ListStatus at %v: %v", tt.at, err)
+            continue
+        }
+        if st := status[gpu0]; st.Utilization != tt.util0 || st.UsedMemory != tt.mem0 {
+            t.Errorf("GPU 0 at %v = %d%% %d MB, want %d%% %d MB", tt.at, st.Utilization, st.UsedMemory, tt.util0, tt.mem0)
+        }
+        if st := status[gpu1]; st.UsedMemory != tt.mem1 {
+            t.Errorf("GPU 1 at %v uses %d MB, want %d MB", tt.at, st.UsedMemory, tt.mem1)
+        }
+    }
+}
+
+// TestSimFaults 故障在 [at, at+duration) 内生效
+func TestSimFaults(t *testing.T) {
+    b, base, clock := newSimTest(t)
+
+    tests := []struct {
+        at    time.Duration
+        gpus  int    // 可见GPU数量
+        procs int    // 运行中的模拟进程数量
+        err   string // 查询错误，为空表示应成功
+    }{
+        {at: 0, gpus: 4, procs: 0},
+        {at: 10 * time.Second, gpus: 4, procs: 1},
+        {at: 2 * time.Minute, gpus: 3, procs: 1},                       // GPU 3 消失
+        {at: 2*time.Minute + 29*time.Second, gpus: 3, procs: 0},
+        {at: 2*time.Minute + 30*time.Second, gpus: 4, procs: 0},         // GPU 3 恢复
+        {at: 4 * time.Minute, err: "simulated GPU query failure"},
+        {at: 4*time.Minute + 10*time.Second, gpus: 4, procs: 0},
+        {at: 12 * time.Minute, gpus: 3, procs: 1},                      // 第二个周期
+    }
+    for _, tt := range tests {
+        *clock = base.Add(tt.at)
+        gpus, err := b.ListGPUs()
+        if tt.err != "" {
+            if err == nil || !strings.Contains(err.Error(), tt.err) {
+                t.Errorf("ListGPUs at %v error = %v, want %q", tt.at, err, tt.err)
+            }
+            continue
+        }
+        if err != nil {
+            t.Errorf("ListGPUs at %v: %v", tt.at, err)
+            continue
+        }
+        procs, _ := b.Processes()
+        if len(gpus) != tt.gpus || len(procs) != tt.procs {
+            t.Errorf("at %v: %d GPUs and %d processes, want %d and %d", tt.at, len(gpus), len(procs), tt.gpus, tt.procs)
+        }
+        if tt.gpus == 3 {
+            for _, g := range gpus {
+                if g.Uuid == SimUUID(8, 3) {
+                    t.Errorf("at %v: disappeared GPU 3 still listed", tt.at)
+                }
+            }
+        }
+    }
+}
+
+// TestSimHang hang 故障期间查询阻塞到故障结束，超过 Probe 超时时间时返回超时错误
+func TestSimHang(t *testing.T) {
+    b, base, clock := newSimTest(t)
+
+    *clock = base.Add(3*time.Minute + 5*time.Second)
+    if err := b.Probe(10 * time.Millisecond); err == nil || !strings.Contains(err.Error(), "timed out") {
+        t.Errorf("Probe during hang = %v, want a timeout", err)
+    }
+
+    // 故障还剩 50ms：查询阻塞到故障结束后返回
+    *clock = base.Add(3*time.Minute + 20*time.Second - 50*time.Millisecond)
+    start := time.Now()
+    if _, err := b.ListGPUs(); err != nil {
+        t.Errorf("ListGPUs at the end of a hang: %v", err)
+    }
+    if elapsed := time.Since(start); elapsed < 50*time.Millisecond {
+        t.Errorf("ListGPUs returned after %v, want it to block until the hang ends", elapsed)
+    }
+    if err := b.Probe(time.Second); err != nil {
+        t.Errorf("Probe at the end of a hang: %v", err)
+    }
+
+    *clock = base.Add(3*time.Minute + 20*time.Second)
+    if err := b.Probe(10 * time.Millisecond); err != nil {
+        t.Errorf("Probe after hang: %v", err)
+    }
+}
+
+// TestSimXIDEvents XID事件在 (since, now] 窗口内返回，指定 loop 时每个周期重复
+func TestSimXIDEvents(t *testing.T) {
+    b, base, clock := newSimTest(t)
+    gpu1, gpu2 := SimUUID(0, 1), SimUUID(8, 2)
+    xid79 := func(at time.Duration) XIDEvent { return XIDEvent{UUID: gpu1, XID: 79, Time: base.Add(at)} }
+    xid48 := func(at time.Duration) XIDEvent { return XIDEvent{UUID: gpu2, XID: 48, Time: base.Add(at)} }
+
+    tests := []struct {
+        name       string
+        since, now time.Duration
+        want       []XIDEvent
+    }{
+        {name: "before first event", since: 0, now: 59 * time.Second},
+        {name: "now is inclusive", since: 0, now: time.Minute, want: []XIDEvent{xid79(time.Minute)}},
+        {name: "since is exclusive", since: time.Minute, now: 2 * time.Minute, want: []XIDEvent{xid48(90 * time.Second)}},
+        {name: "since before scenario start", since: -time.Hour, now: 2 * time.Minute,
+            want: []XIDEvent{xid79(time.Minute), xid48(90 * time.Second)}},
+        {name: "across a loop boundary", since: 80 * time.Second, now: 10*time.Minute + 95*time.Second,
+            want: []XIDEvent{xid48(90 * time.Second), xid79(11 * time.Minute), xid48(11*time.Minute + 30*time.Second)}},
+        {name: "within a later cycle", since: 21 * time.Minute, now: 21*time.Minute + 30*time.Second,
+            want: []XIDEvent{xid48(21*time.Minute + 30*time.Second)}},
+        {name: "across several cycles", since: 5 * time.Minute, now: 25 * time.Minute,
+            want: []XIDEvent{xid79(11 * time.Minute), xid48(11*time.Minute + 30*time.Second), xid79(21 * time.Minute), xid48(21*time.Minute + 30*time.Second)}},
+        {name: "empty window", since: 25 * time.Minute, now: 25 * time.Minute},
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            *clock = base.Add(tt.now)
+            got, err := b.XIDEvents(base.Add(tt.since))
+            if err != nil {
+                t.Fatalf("XIDEvents: %v", err)
+            }
+            if len(got) == 0 && len(tt.want) == 0 {
+                return
+            }
+            if !reflect.DeepEqual(got, tt.want) {
+                t.Errorf("XIDEvents(%v) at %v = %v, want %v", tt.since, tt.now, got, tt.want)
+            }
+        })
+    }
+}
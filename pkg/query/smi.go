@@ -0,0 +1,368 @@
+package query
+
+import (
+    "bytes"
+    "context"
+    "encoding/csv"
+    "fmt"
+    "os/exec"
+    "regexp"
+    "strconv"
+    "strings"
+    "sync"
+    "time"
+
+    pb "github.com/hiicl/GPU-over-IP-AC922/proto"
+)
+
+// smi.go 实现基于 nvidia-smi 命令行的查询后端（CSV输出），XID错误从内核日志读取（kmsg.go）
+
+// smiBackend nvidia-smi 后端
+type smiBackend struct{}
+
+// smiTimeout 单次 nvidia-smi 调用的超时时间（Probe 使用调用方指定的超时）
+// 驱动异常时 nvidia-smi 可能挂起，超时后调用方得到错误，健康检查和后台采样不会被永久阻塞
+const smiTimeout = 30 * time.Second
+
+// gpuInfoFields ListGPUs 查询的nvidia-smi字段（顺序与解析一致）
+// 末尾的 mig.mode.current 和 compute_cap 需要较新的驱动，旧驱动不支持时从末尾逐个去掉重试
+var gpuInfoFields = []string{
+    "uuid", "name", "memory.total", "index", "pci.bus_id",
+    "driver_version", "persistence_mode", "compute_mode", "mig.mode.current", "compute_cap",
+}
+
+// gpuInfoRequired gpuInfoFields 中所有驱动都支持的字段数
+const gpuInfoRequired = 8
+
+func (smiBackend) Name() string { return "nvidia-smi" }
+
+// ListGPUs 使用nvidia-smi命令查询GPU信息：
+//   --query-gpu=uuid,name,memory.total,...: 查询gpuInfoFields中的字段
+//   --format=csv,noheader,nounits: 输出CSV格式，无标题行和单位
+// NUMA节点从sysfs读取，CUDA版本从nvidia-smi输出头部解析；
+// 有GPU启用MIG模式时由 migDevices 查询MIG实例，结果供随后的 ListStatus 复用
+func (smiBackend) ListGPUs() ([]*pb.GPUInfo, error) {
+    fields := gpuInfoFields
+    out, err := queryCSV("--query-gpu", fields)
+    for err != nil && len(fields) > gpuInfoRequired {
+        // 旧驱动不支持 compute_cap 或 mig.mode.current，去掉后重试
+        fields = fields[:len(fields)-1]
+        out, err = queryCSV("--query-gpu", fields)
+    }
+    if err != nil {
+        return nil, err
+    }
+    if len(fields) == gpuInfoRequired {
+        setMIGModeUnsupported()
+    }
+
+    cudaVersion := CUDAVersion()
+
+    var result []*pb.GPUInfo
+    anyMIG := false
+    for _, line := range parseCSV(out, len(fields)) {
+        // 将内存字符串转换为int64
+        mem, _ := strconv.ParseInt(line[2], 10, 64)
+        index, _ := strconv.Atoi(line[3])
+
+        // 创建GPUInfo对象并添加到结果列表
+        info := &pb.GPUInfo{
+            Uuid:            line[0],                      // UUID
+            Name:            line[1],                      // GPU名称
+            TotalMemory:     mem,                          // 总内存（MB）
+            Index:           int32(index),                 // 设备序号
+            PciBusId:        line[4],                      // PCI总线ID
+            NumaNode:        int32(PCINUMANode(line[4])),  // NUMA节点
+            DriverVersion:   line[5],                      // 驱动版本
+            CudaVersion:     cudaVersion,                  // CUDA版本
+            PersistenceMode: line[6] == "Enabled",         // 持久化模式
+            ComputeMode:     line[7],                      // 计算模式
+        }
+        if len(line) > 8 && line[8] == "Enabled" {
+            anyMIG = true // MIG模式（不支持MIG的GPU为 [N/A]）
+        }
+        if len(line) > 9 {
+            info.ComputeCapability = line[9] // 计算能力（如 7.0）
+        }
+        rememberPCI(info.PciBusId, info.Uuid) // 供内核日志中的XID记录映射到GPU
+        result = append(result, info)
+    }
+
+    if !anyMIG {
+        return result, nil
+    }
+    migs, err := migDevices()
+    if err != nil {
+        return nil, err
+    }
+    rememberMIG(migs)
+    if len(migs) == 0 {
+        return result, nil
+    }
+    var withMIG []*pb.GPUInfo
+    for _, info := range result {
+        withMIG = append(withMIG, info)
+        for _, m := range migs {
+            if m.parent == info.Uuid {
+                info.MigEnabled = true
+                withMIG = append(withMIG, migInstance(info, m.uuid, m.profile, m.gi, m.ci, m.total))
+            }
+        }
+    }
+    return withMIG, nil
+}
+
+// ListStatus 查询GPU的UUID、已使用内存、利用率和MIG模式
+// 有GPU启用MIG模式时补充MIG实例的已使用显存，优先复用刚刚 ListGPUs 的MIG查询结果
+func (smiBackend) ListStatus() (map[string]GPUStatus, error) {
+    fields := []string{"uuid", "memory.used", "utilization.gpu", "mig.mode.current"}
+    if !migModeSupported() {
+        fields = fields[:3]
+    }
+    out, err := queryCSV("--query-gpu", fields)
+    if err != nil && len(fields) == 4 {
+        // 旧驱动不支持 mig.mode.current，这类驱动也不支持MIG
+        fields = fields[:3]
+        if out, err = queryCSV("--query-gpu", fields); err == nil {
+            setMIGModeUnsupported()
+        }
+    }
+    if err != nil {
+        return nil, err
+    }
+    result := make(map[string]GPUStatus)
+    anyMIG := false
+    for _, line := range parseCSV(out, len(fields)) {
+        used, _ := strconv.ParseInt(line[1], 10, 64)
+        utilization, _ := strconv.Atoi(line[2])
+        result[line[0]] = GPUStatus{
+            UsedMemory:  used,               // 已使用内存（MB）
+            Utilization: int32(utilization), // GPU利用率（0-100）
+        }
+        if len(line) > 3 && line[3] == "Enabled" {
+            anyMIG = true
+        }
+    }
+    if !anyMIG {
+        return result, nil
+    }
+    // MIG实例只报告已使用显存，利用率无法按实例查询
+    migs, ok := recentMIG()
+    if !ok {
+        if migs, err = migDevices(); err != nil {
+            return nil, err
+        }
+    }
+    for _, m := range migs {
+        result[m.uuid] = GPUStatus{UsedMemory: m.used}
+    }
+    return result, nil
+}
+
+// Processes 查询计算进程：--query-compute-apps=gpu_uuid,pid,process_name,used_memory
+func (smiBackend) Processes() ([]Process, error) {
+    out, err := queryCSV("--query-compute-apps", []string{"gpu_uuid", "pid", "process_name", "used_memory"})
+    if err != nil {
+        return nil, err
+    }
+    var procs []Process
+    for _, line := range parseCSV(out, 4) {
+        pid, err := strconv.Atoi(line[1])
+        if err != nil {
+            continue
+        }
+        used, _ := strconv.ParseInt(line[3], 10, 64) // 无权限时为 [N/A]，记为0
+        procs = append(procs, Process{PID: pid, UUID: line[0], Name: line[2], UsedMemory: used})
+    }
+    return procs, nil
+}
+
+// Details 执行 nvidia-smi -q -x 并解析XML输出
+func (smiBackend) Details() ([]*pb.GPUDetails, error) {
+    out, err := smiOutput(smiTimeout, "-q", "-x")
+    if err != nil {
+        return nil, err
+    }
+    return ParseSMIXML(out)
+}
+
+// Probe 在超时时间内执行 nvidia-smi -L 并至少列出一个GPU
+func (smiBackend) Probe(timeout time.Duration) error {
+    out, err := smiOutput(timeout, "-L")
+    if err != nil {
+        return err
+    }
+    if !strings.Contains(string(out), "GPU ") {
+        return fmt.Errorf("nvidia-smi listed no GPUs")
+    }
+    return nil
+}
+
+// nvidia-smi -L 中的物理GPU行和MIG设备行，如
+//   GPU 0: NVIDIA A100-SXM4-40GB (UUID: GPU-5d5ba0d6-...)
+//     MIG 3g.20gb     Device  0: (UUID: MIG-2a2b8b8c-...)
+var (
+    smiGPULine = regexp.MustCompile(`^GPU \d+: .*\(UUID: (GPU-[^)\s]+)\)`)
+    smiMIGLine = regexp.MustCompile(`^\s+MIG (\S+)\s+Device\s+(\d+): \(UUID: (MIG-[^)\s]+)\)`)
+)
+
+// migDevice nvidia-smi 报告的MIG实例
+type migDevice struct {
+    parent  string // 物理GPU的UUID
+    index   int    // 物理GPU上的MIG设备序号
+    uuid    string
+    profile string
+    gi, ci  int   // GPU实例ID和计算实例ID
+    total   int64 // 实例显存（MB）
+    used    int64 // 已使用显存（MB）
+}
+
+// migReuse ListGPUs 的MIG查询结果可供紧接着的 ListStatus 复用的时长，
+// 先后调用两者时（如未启用采集器的 query.Latest，或采集器刷新静态信息时）只执行一次 -L 和 -q -x
+const migReuse = 2 * time.Second
+
+var (
+    migMu         sync.Mutex
+    migLast       []migDevice // ListGPUs 最近一次查询的MIG实例，被 ListStatus 取走后清空
+    migLastTime   time.Time
+    migModeAbsent bool // 驱动不支持 mig.mode.current 字段
+)
+
+// rememberMIG 记录 ListGPUs 查询的MIG实例
+func rememberMIG(devs []migDevice) {
+    migMu.Lock()
+    defer migMu.Unlock()
+    migLast, migLastTime = devs, time.Now()
+}
+
+// recentMIG 取走 migReuse 内由 ListGPUs 查询的MIG实例，每次查询结果只复用一次
+func recentMIG() ([]migDevice, bool) {
+    migMu.Lock()
+    defer migMu.Unlock()
+    devs, fresh := migLast, !migLastTime.IsZero() && time.Since(migLastTime) <= migReuse
+    migLast, migLastTime = nil, time.Time{}
+    return devs, fresh
+}
+
+// setMIGModeUnsupported 记录驱动不支持 mig.mode.current，之后的状态查询不再请求该字段
+func setMIGModeUnsupported() {
+    migMu.Lock()
+    defer migMu.Unlock()
+    migModeAbsent = true
+}
+
+// migModeSupported 驱动是否支持 mig.mode.current 字段（尚未确定时视为支持）
+func migModeSupported() bool {
+    migMu.Lock()
+    defer migMu.Unlock()
+    return !migModeAbsent
+}
+
+// migDevices 查询MIG实例：nvidia-smi -L 列出实例的UUID和配置，
+// nvidia-smi -q -x 的 mig_devices 按设备序号补充GI/CI ID和显存；没有MIG实例时只执行 -L
+// 调用方先通过 mig.mode.current 确认有GPU启用MIG模式，未启用时不调用
+func migDevices() ([]migDevice, error) {
+    out, err := smiOutput(smiTimeout, "-L")
+    if err != nil {
+        return nil, err
+    }
+    devs := parseMIGList(out)
+    if len(devs) == 0 {
+        return nil, nil
+    }
+    out, err = smiOutput(smiTimeout, "-q", "-x")
+    if err != nil {
+        return nil, err
+    }
+    xmlDevs, err := parseSMIMIG(out)
+    if err != nil {
+        return nil, err
+    }
+    for i := range devs {
+        d := &devs[i]
+        for _, x := range xmlDevs[d.parent] {
+            if int(smiNumber(x.Index)) != d.index {
+                continue
+            }
+            d.gi = int(smiNumber(x.GPUInstanceID))
+            d.ci = int(smiNumber(x.ComputeInstanceID))
+            d.total = int64(smiNumber(x.FBMemory.Total))
+            d.used = int64(smiNumber(x.FBMemory.Used))
+        }
+    }
+    return devs, nil
+}
+
+// parseMIGList 解析 nvidia-smi -L 输出中的MIG设备行
+func parseMIGList(out []byte) []migDevice {
+    var devs []migDevice
+    parent := ""
+    for _, line := range strings.Split(string(out), "\n") {
+        line = strings.TrimRight(line, "\r")
+        if m := smiGPULine.FindStringSubmatch(line); m != nil {
+            parent = m[1]
+            continue
+        }
+        m := smiMIGLine.FindStringSubmatch(line)
+        if m == nil || parent == "" {
+            continue
+        }
+        index, _ := strconv.Atoi(m[2])
+        devs = append(devs, migDevice{parent: parent, index: index, uuid: m[3], profile: m[1]})
+    }
+    return devs
+}
+
+// queryCSV 执行nvidia-smi查询指定字段，返回CSV输出
+func queryCSV(query string, fields []string) ([]byte, error) {
+    return smiOutput(smiTimeout, query+"="+strings.Join(fields, ","), "--format=csv,noheader,nounits")
+}
+
+// smiOutput 执行 nvidia-smi 并返回标准输出，超过 timeout 时结束进程并返回超时错误
+// 处于不可中断睡眠（驱动挂起）的进程可能无法立即结束，超时后不再等待其退出
+func smiOutput(timeout time.Duration, args ...string) ([]byte, error) {
+    ctx, cancel := context.WithTimeout(context.Background(), timeout)
+    defer cancel()
+    type result struct {
+        out []byte
+        err error
+    }
+    done := make(chan result, 1)
+    go func() {
+        out, err := exec.CommandContext(ctx, "nvidia-smi", args...).Output()
+        done <- result{out, err}
+    }()
+    select {
+    case r := <-done:
+        if r.err == nil {
+            return r.out, nil
+        }
+        if ctx.Err() == nil {
+            return nil, fmt.Errorf("nvidia-smi failed: %v", r.err)
+        }
+    case <-ctx.Done():
+    }
+    return nil, fmt.Errorf("nvidia-smi timed out after %s", timeout)
+}
+
+// parseCSV 解析nvidia-smi的CSV输出并去除字段空格，字段数不符的行被跳过
+func parseCSV(out []byte, fields int) [][]string {
+    r := csv.NewReader(bytes.NewReader(out))
+    r.FieldsPerRecord = -1
+    r.TrimLeadingSpace = true
+    var lines [][]string
+    for {
+        line, err := r.Read()
+        if err != nil {
+            break // io.EOF 或格式错误
+        }
+        if len(line) != fields {
+            continue
+        }
+        for i := range line {
+            line[i] = strings.TrimSpace(line[i])
+        }
+        lines = append(lines, line)
+    }
+    return lines
+}
@@ -1,24 +1,19 @@
-package query
-
-import (
-    "github.com/hiicl/GPU-over-IP-AC922/pkg/gpu"
-)
-
-// GPUUUIDsByIDs 根据 GPU 的 deviceID 列表返回其 UUID 列表
-func GPUUUIDsByIDs(deviceIDs []int) []string {
-    uuidList := []string{}
-    allGPUs := ListGPUs() // 已有函数，返回所有 GPUInfo（包含 UUID 和 index）
-
-    indexMap := make(map[int]string)
-    for _, g := range allGPUs {
-        indexMap[int(g.Index)] = g.Uuid
-    }
-
-    for _, id := range deviceIDs {
-        if uuid, ok := indexMap[id]; ok {
-            uuidList = append(uuidList, uuid)
-        }
-    }
-
-    return uuidList
-}
+package query
+
+// GPUUUIDsByIDs 根据 GPU 的 deviceID 列表返回其 UUID 列表
+// 启用MIG的GPU同时返回其MIG实例的UUID（实例与物理GPU的设备序号相同）
+func GPUUUIDsByIDs(deviceIDs []int) []string {
+    uuidList := []string{}
+    allGPUs := ListGPUs() // 返回所有 GPUInfo（包含 UUID 和 index）
+
+    indexMap := make(map[int][]string)
+    for _, g := range allGPUs {
+        indexMap[int(g.Index)] = append(indexMap[int(g.Index)], g.Uuid)
+    }
+
+    for _, id := range deviceIDs {
+        uuidList = append(uuidList, indexMap[id]...)
+    }
+
+    return uuidList
+}
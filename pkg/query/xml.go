@@ -0,0 +1,279 @@
+package query
+
+import (
+    "encoding/xml"
+    "fmt"
+    "strconv"
+    "strings"
+
+    pb "github.com/hiicl/GPU-over-IP-AC922/proto"
+)
+
+// xml.go 解析 nvidia-smi -q -x 的XML输出
+// 只解析 GPUDetails 需要的元素；各驱动版本的元素名差异（如 clocks_throttle_reasons 在新驱动中
+// 改名为 clocks_event_reasons，pending_blacklist 改名为 pending_retirement）在此统一处理
+// 数值字段带单位（如 "35 C"、"39.45 W"、"1024 MiB"），不支持时为 "N/A"，均按0处理
+
+// 降频原因，与 nvidia-smi XML 元素名去掉 clocks_throttle_reason_ / clocks_event_reason_ 前缀后一致
+const (
+    ThrottleGPUIdle            = "gpu_idle"
+    ThrottleApplicationsClocks = "applications_clocks_setting"
+    ThrottleSWPowerCap         = "sw_power_cap"
+    ThrottleHWSlowdown         = "hw_slowdown"
+    ThrottleHWThermalSlowdown  = "hw_thermal_slowdown"
+    ThrottleHWPowerBrake       = "hw_power_brake_slowdown"
+    ThrottleSyncBoost          = "sync_boost"
+    ThrottleSWThermalSlowdown  = "sw_thermal_slowdown"
+    ThrottleDisplayClocks      = "display_clocks_setting"
+)
+
+// smiLog nvidia-smi -q -x 的根元素
+type smiLog struct {
+    XMLName       xml.Name `xml:"nvidia_smi_log"`
+    DriverVersion string   `xml:"driver_version"`
+    CUDAVersion   string   `xml:"cuda_version"`
+    GPUs          []smiGPU `xml:"gpu"`
+}
+
+// smiGPU 单块GPU
+type smiGPU struct {
+    ID               string         `xml:"id,attr"` // PCI总线ID
+    ProductName      string         `xml:"product_name"`
+    Serial           string         `xml:"serial"`
+    UUID             string         `xml:"uuid"`
+    VBIOSVersion     string         `xml:"vbios_version"`
+    PerformanceState string         `xml:"performance_state"`
+    ThrottleReasons  smiReasons     `xml:"clocks_throttle_reasons"`
+    EventReasons     smiReasons     `xml:"clocks_event_reasons"`
+    FBMemory         smiMemory      `xml:"fb_memory_usage"`
+    Utilization      smiUtilization `xml:"utilization"`
+    ECCMode          smiECCMode     `xml:"ecc_mode"`
+    ECCErrors        smiECCErrors   `xml:"ecc_errors"`
+    RetiredPages     smiRetired     `xml:"retired_pages"`
+    Temperature      smiTemperature `xml:"temperature"`
+    Power            smiPower       `xml:"power_readings"`
+    GPUPower         smiPower       `xml:"gpu_power_readings"` // 新驱动中功耗读数的元素名
+    Clocks           smiClocks      `xml:"clocks"`
+    MaxClocks        smiClocks      `xml:"max_clocks"`
+    Processes        []smiProcess   `xml:"processes>process_info"`
+    MIGDevices       []smiMIGDevice `xml:"mig_devices>mig_device"`
+}
+
+// smiReasons 降频原因，每个子元素的值为 "Active" 或 "Not Active"
+type smiReasons struct {
+    Reasons []struct {
+        XMLName xml.Name
+        Value   string `xml:",chardata"`
+    } `xml:",any"`
+}
+
+type smiMemory struct {
+    Total string `xml:"total"`
+    Used  string `xml:"used"`
+}
+
+type smiUtilization struct {
+    GPU string `xml:"gpu_util"`
+}
+
+type smiECCMode struct {
+    Current string `xml:"current_ecc"`
+}
+
+type smiECCCounts struct {
+    SingleBit struct {
+        Total string `xml:"total"`
+    } `xml:"single_bit"`
+    DoubleBit struct {
+        Total string `xml:"total"`
+    } `xml:"double_bit"`
+    // 新驱动使用可纠正/不可纠正计数代替单/双比特
+    SRAMCorrectable   string `xml:"sram_correctable"`
+    SRAMUncorrectable string `xml:"sram_uncorrectable"`
+    DRAMCorrectable   string `xml:"dram_correctable"`
+    DRAMUncorrectable string `xml:"dram_uncorrectable"`
+}
+
+type smiECCErrors struct {
+    Volatile  smiECCCounts `xml:"volatile"`
+    Aggregate smiECCCounts `xml:"aggregate"`
+}
+
+type smiRetired struct {
+    SingleBit struct {
+        Count string `xml:"retired_count"`
+    } `xml:"multiple_single_bit_retirement"`
+    DoubleBit struct {
+        Count string `xml:"retired_count"`
+    } `xml:"double_bit_retirement"`
+    PendingBlacklist  string `xml:"pending_blacklist"`
+    PendingRetirement string `xml:"pending_retirement"`
+}
+
+type smiTemperature struct {
+    GPU      string `xml:"gpu_temp"`
+    Shutdown string `xml:"gpu_temp_max_threshold"`
+    Slowdown string `xml:"gpu_temp_slow_threshold"`
+    Memory   string `xml:"memory_temp"`
+}
+
+type smiPower struct {
+    Draw         string `xml:"power_draw"`
+    InstantDraw  string `xml:"instant_power_draw"`
+    Limit        string `xml:"power_limit"`
+    CurrentLimit string `xml:"current_power_limit"`
+}
+
+type smiClocks struct {
+    Graphics string `xml:"graphics_clock"`
+    SM       string `xml:"sm_clock"`
+    Memory   string `xml:"mem_clock"`
+    Video    string `xml:"video_clock"`
+}
+
+type smiMIGDevice struct {
+    Index             string    `xml:"index"`
+    GPUInstanceID     string    `xml:"gpu_instance_id"`
+    ComputeInstanceID string    `xml:"compute_instance_id"`
+    FBMemory          smiMemory `xml:"fb_memory_usage"`
+}
+
+type smiProcess struct {
+    PID        string `xml:"pid"`
+    Type       string `xml:"type"`
+    Name       string `xml:"process_name"`
+    UsedMemory string `xml:"used_memory"`
+}
+
+// ParseSMIXML 解析 nvidia-smi -q -x 的输出，返回每块GPU的详细信息（未设置 Timestamp）
+func ParseSMIXML(data []byte) ([]*pb.GPUDetails, error) {
+    var log smiLog
+    if err := xml.Unmarshal(data, &log); err != nil {
+        return nil, fmt.Errorf("parse nvidia-smi xml: %v", err)
+    }
+    var result []*pb.GPUDetails
+    for _, g := range log.GPUs {
+        result = append(result, g.details())
+    }
+    return result, nil
+}
+
+// parseSMIMIG 解析 nvidia-smi -q -x 输出中的MIG设备，key: 物理GPU UUID
+func parseSMIMIG(data []byte) (map[string][]smiMIGDevice, error) {
+    var log smiLog
+    if err := xml.Unmarshal(data, &log); err != nil {
+        return nil, fmt.Errorf("parse nvidia-smi xml: %v", err)
+    }
+    result := make(map[string][]smiMIGDevice)
+    for _, g := range log.GPUs {
+        if len(g.MIGDevices) > 0 {
+            result[g.UUID] = g.MIGDevices
+        }
+    }
+    return result, nil
+}
+
+// details 将XML元素转换为 GPUDetails
+func (g *smiGPU) details() *pb.GPUDetails {
+    d := &pb.GPUDetails{
+        Uuid:                g.UUID,
+        ProductName:         g.ProductName,
+        Serial:              g.Serial,
+        VbiosVersion:        g.VBIOSVersion,
+        PerformanceState:    g.PerformanceState,
+        TotalMemory:         int64(smiNumber(g.FBMemory.Total)),
+        UsedMemory:          int64(smiNumber(g.FBMemory.Used)),
+        Utilization:         int32(smiNumber(g.Utilization.GPU)),
+        Temperature:         int32(smiNumber(g.Temperature.GPU)),
+        MemoryTemperature:   int32(smiNumber(g.Temperature.Memory)),
+        SlowdownTemperature: int32(smiNumber(g.Temperature.Slowdown)),
+        ShutdownTemperature: int32(smiNumber(g.Temperature.Shutdown)),
+        PowerDraw:           smiFirst(g.Power.Draw, g.GPUPower.Draw, g.GPUPower.InstantDraw),
+        PowerLimit:          smiFirst(g.Power.Limit, g.GPUPower.CurrentLimit),
+        Clocks:              g.Clocks.pb(),
+        MaxClocks:           g.MaxClocks.pb(),
+        Ecc: &pb.ECCErrors{
+            Enabled:            g.ECCMode.Current == "Enabled",
+            VolatileSingleBit:  g.ECCErrors.Volatile.singleBit(),
+            VolatileDoubleBit:  g.ECCErrors.Volatile.doubleBit(),
+            AggregateSingleBit: g.ECCErrors.Aggregate.singleBit(),
+            AggregateDoubleBit: g.ECCErrors.Aggregate.doubleBit(),
+        },
+        RetiredPages: &pb.RetiredPages{
+            SingleBit:         int32(smiNumber(g.RetiredPages.SingleBit.Count)),
+            DoubleBit:         int32(smiNumber(g.RetiredPages.DoubleBit.Count)),
+            PendingRetirement: g.RetiredPages.PendingBlacklist == "Yes" || g.RetiredPages.PendingRetirement == "Yes",
+        },
+    }
+    for _, reasons := range []smiReasons{g.ThrottleReasons, g.EventReasons} {
+        for _, r := range reasons.Reasons {
+            if strings.TrimSpace(r.Value) != "Active" {
+                continue
+            }
+            name := strings.TrimPrefix(r.XMLName.Local, "clocks_throttle_reason_")
+            name = strings.TrimPrefix(name, "clocks_event_reason_")
+            d.ThrottleReasons = append(d.ThrottleReasons, name)
+        }
+    }
+    for _, p := range g.Processes {
+        pid, err := strconv.Atoi(strings.TrimSpace(p.PID))
+        if err != nil {
+            continue
+        }
+        d.Processes = append(d.Processes, &pb.GPUProcess{
+            Pid:        int32(pid),
+            Name:       strings.TrimSpace(p.Name),
+            Type:       strings.TrimSpace(p.Type),
+            UsedMemory: int64(smiNumber(p.UsedMemory)),
+        })
+    }
+    return d
+}
+
+func (c smiClocks) pb() *pb.GPUClocks {
+    return &pb.GPUClocks{
+        Graphics: int32(smiNumber(c.Graphics)),
+        Sm:       int32(smiNumber(c.SM)),
+        Memory:   int32(smiNumber(c.Memory)),
+        Video:    int32(smiNumber(c.Video)),
+    }
+}
+
+// singleBit 可纠正错误总数：旧驱动为 single_bit/total，新驱动为 SRAM 与 DRAM 可纠正错误之和
+func (c smiECCCounts) singleBit() int64 {
+    if c.SingleBit.Total != "" {
+        return int64(smiNumber(c.SingleBit.Total))
+    }
+    return int64(smiNumber(c.SRAMCorrectable) + smiNumber(c.DRAMCorrectable))
+}
+
+// doubleBit 不可纠正错误总数
+func (c smiECCCounts) doubleBit() int64 {
+    if c.DoubleBit.Total != "" {
+        return int64(smiNumber(c.DoubleBit.Total))
+    }
+    return int64(smiNumber(c.SRAMUncorrectable) + smiNumber(c.DRAMUncorrectable))
+}
+
+// smiNumber 解析带单位的数值（如 "39.45 W"），N/A 或无法解析时返回0
+func smiNumber(s string) float64 {
+    fields := strings.Fields(s)
+    if len(fields) == 0 {
+        return 0
+    }
+    v, err := strconv.ParseFloat(fields[0], 64)
+    if err != nil {
+        return 0
+    }
+    return v
+}
+
+// smiFirst 返回第一个非0的数值
+func smiFirst(values ...string) float64 {
+    for _, s := range values {
+        if v := smiNumber(s); v != 0 {
+            return v
+        }
+    }
+    return 0
+}
@@ -0,0 +1,185 @@
+package query
+
+import (
+    "os"
+    "path/filepath"
+    "reflect"
+    "testing"
+
+    "google.golang.org/protobuf/proto"
+    pb "github.com/hiicl/GPU-over-IP-AC922/proto"
+)
+
+// readFixture 读取 fixtures 目录下录制的 nvidia-smi -q -x 输出
+func readFixture(t *testing.T, name string) []byte {
+    t.Helper()
+    data, err := os.ReadFile(filepath.Join("fixtures", name))
+    if err != nil {
+        t.Fatalf("read fixture: %v", err)
+    }
+    return data
+}
+
+func TestParseSMIXML(t *testing.T) {
+    tests := []struct {
+        fixture string
+        want    *pb.GPUDetails
+    }{
+        {
+            // 旧驱动：single_bit/double_bit 计数、clocks_throttle_reasons、power_readings、pending_blacklist
+            fixture: "v100-440.xml",
+            want: &pb.GPUDetails{
+                Uuid:                "GPU-6b1a5a83-4f3c-6a5e-2b8f-0d1c7e9a3f21",
+                ProductName:         "Tesla V100-SXM2-16GB",
+                Serial:              "0323218104562",
+                VbiosVersion:        "88.00.80.00.03",
+                PerformanceState:    "P0",
+                TotalMemory:         16160,
+                UsedMemory:          12419,
+                Utilization:         97,
+                Temperature:         61,
+                MemoryTemperature:   58,
+                SlowdownTemperature: 87,
+                ShutdownTemperature: 90,
+                PowerDraw:           297.45,
+                PowerLimit:          300,
+                Clocks:              &pb.GPUClocks{Graphics: 1380, Sm: 1380, Memory: 877, Video: 1245},
+                MaxClocks:           &pb.GPUClocks{Graphics: 1530, Sm: 1530, Memory: 877, Video: 1372},
+                Ecc: &pb.ECCErrors{
+                    Enabled:            true,
+                    VolatileSingleBit:  2,
+                    VolatileDoubleBit:  0,
+                    AggregateSingleBit: 17,
+                    AggregateDoubleBit: 0,
+                },
+                RetiredPages:    &pb.RetiredPages{SingleBit: 1, DoubleBit: 0, PendingRetirement: false},
+                ThrottleReasons: []string{ThrottleSWPowerCap},
+                Processes: []*pb.GPUProcess{
+                    {Pid: 48213, Name: "/opt/anaconda3/envs/megatron/bin/python", Type: "C", UsedMemory: 12407},
+                },
+            },
+        },
+        {
+            // 启用MIG的新驱动：SRAM/DRAM 计数、clocks_event_reasons、gpu_power_readings，
+            // MIG模式下利用率和退役页为 N/A
+            fixture: "a100-mig-535.xml",
+            want: &pb.GPUDetails{
+                Uuid:                "GPU-5d5ba0d6-3f2c-8e4b-1a7d-9c0e6b2f4a18",
+                ProductName:         "NVIDIA A100-SXM4-40GB",
+                Serial:              "1562120019857",
+                VbiosVersion:        "92.00.45.00.06",
+                PerformanceState:    "P0",
+                TotalMemory:         40960,
+                UsedMemory:          8222,
+                Utilization:         0,
+                Temperature:         86,
+                MemoryTemperature:   79,
+                SlowdownTemperature: 89,
+                ShutdownTemperature: 92,
+                PowerDraw:           211.37,
+                PowerLimit:          400,
+                Clocks:              &pb.GPUClocks{Graphics: 1275, Sm: 1275, Memory: 1215, Video: 1080},
+                MaxClocks:           &pb.GPUClocks{Graphics: 1410, Sm: 1410, Memory: 1215, Video: 1290},
+                Ecc: &pb.ECCErrors{
+                    Enabled:            true,
+                    VolatileSingleBit:  4,
+                    VolatileDoubleBit:  1,
+                    AggregateSingleBit: 33,
+                    AggregateDoubleBit: 1,
+                },
+                RetiredPages:    &pb.RetiredPages{},
+                ThrottleReasons: []string{ThrottleHWSlowdown, ThrottleHWThermalSlowdown},
+                Processes: []*pb.GPUProcess{
+                    {Pid: 91827, Name: "python3", Type: "C", UsedMemory: 8192},
+                },
+            },
+        },
+    }
+    for _, tt := range tests {
+        t.Run(tt.fixture, func(t *testing.T) {
+            got, err := ParseSMIXML(readFixture(t, tt.fixture))
+            if err != nil {
+                t.Fatalf("ParseSMIXML: %v", err)
+            }
+            if len(got) != 1 {
+                t.Fatalf("ParseSMIXML returned %d GPUs, want 1", len(got))
+            }
+            d, want := got[0], tt.want
+            if d.Temperature != want.Temperature || d.MemoryTemperature != want.MemoryTemperature ||
+                d.SlowdownTemperature != want.SlowdownTemperature || d.ShutdownTemperature != want.ShutdownTemperature {
+                t.Errorf("temperature = %d/%d/%d/%d, want %d/%d/%d/%d",
+                    d.Temperature, d.MemoryTemperature, d.SlowdownTemperature, d.ShutdownTemperature,
+                    want.Temperature, want.MemoryTemperature, want.SlowdownTemperature, want.ShutdownTemperature)
+            }
+            if d.PowerDraw != want.PowerDraw || d.PowerLimit != want.PowerLimit {
+                t.Errorf("power = %v/%v W, want %v/%v W", d.PowerDraw, d.PowerLimit, want.PowerDraw, want.PowerLimit)
+            }
+            if !proto.Equal(d.Clocks, want.Clocks) || !proto.Equal(d.MaxClocks, want.MaxClocks) {
+                t.Errorf("clocks = %v, max %v; want %v, max %v", d.Clocks, d.MaxClocks, want.Clocks, want.MaxClocks)
+            }
+            if !proto.Equal(d.Ecc, want.Ecc) {
+                t.Errorf("ecc = %v, want %v", d.Ecc, want.Ecc)
+            }
+            if !proto.Equal(d.RetiredPages, want.RetiredPages) {
+                t.Errorf("retired pages = %v, want %v", d.RetiredPages, want.RetiredPages)
+            }
+            if !reflect.DeepEqual(d.ThrottleReasons, want.ThrottleReasons) {
+                t.Errorf("throttle reasons = %v, want %v", d.ThrottleReasons, want.ThrottleReasons)
+            }
+            if len(d.Processes) != len(want.Processes) {
+                t.Fatalf("processes = %v, want %v", d.Processes, want.Processes)
+            }
+            for i := range want.Processes {
+                if !proto.Equal(d.Processes[i], want.Processes[i]) {
+                    t.Errorf("process %d = %v, want %v", i, d.Processes[i], want.Processes[i])
+                }
+            }
+            // 其余字段（名称、序列号、显存、利用率等）整体比较
+            if !proto.Equal(d, want) {
+                t.Errorf("details = %v, want %v", d, want)
+            }
+        })
+    }
+}
+
+func TestParseSMIMIG(t *testing.T) {
+    tests := []struct {
+        fixture string
+        want    map[string][]migDevice // 只比较设备序号、GI/CI ID 和显存
+    }{
+        {fixture: "v100-440.xml", want: map[string][]migDevice{}},
+        {
+            fixture: "a100-mig-535.xml",
+            want: map[string][]migDevice{
+                "GPU-5d5ba0d6-3f2c-8e4b-1a7d-9c0e6b2f4a18": {
+                    {index: 0, gi: 1, ci: 0, total: 20096, used: 13},
+                    {index: 1, gi: 5, ci: 0, total: 9984, used: 8203},
+                    {index: 2, gi: 13, ci: 0, total: 4864, used: 6},
+                },
+            },
+        },
+    }
+    for _, tt := range tests {
+        t.Run(tt.fixture, func(t *testing.T) {
+            parsed, err := parseSMIMIG(readFixture(t, tt.fixture))
+            if err != nil {
+                t.Fatalf("parseSMIMIG: %v", err)
+            }
+            got := make(map[string][]migDevice)
+            for uuid, devs := range parsed {
+                for _, x := range devs {
+                    got[uuid] = append(got[uuid], migDevice{
+                        index: int(smiNumber(x.Index)),
+                        gi:    int(smiNumber(x.GPUInstanceID)),
+                        ci:    int(smiNumber(x.ComputeInstanceID)),
+                        total: int64(smiNumber(x.FBMemory.Total)),
+                        used:  int64(smiNumber(x.FBMemory.Used)),
+                    })
+                }
+            }
+            if !reflect.DeepEqual(got, tt.want) {
+                t.Errorf("parseSMIMIG = %+v, want %+v", got, tt.want)
+            }
+        })
+    }
+}
@@ -0,0 +1,154 @@
+package registry
+
+import (
+    "bytes"
+    "context"
+    "encoding/json"
+    "errors"
+    "fmt"
+    "net/http"
+    "time"
+
+    "github.com/hiicl/GPU-over-IP-AC922/modules/netbalance"
+    "github.com/hiicl/GPU-over-IP-AC922/pkg/auth"
+    "github.com/hiicl/GPU-over-IP-AC922/pkg/util"
+)
+
+// ErrUnknownNode 心跳时控制器中没有该节点，需要重新注册
+var ErrUnknownNode = errors.New("node not registered with controller")
+
+// Client 控制器客户端
+type Client struct {
+    addr  string       // 控制器地址 host:port
+    base  string       // 控制器URL前缀（http:// 或 https://）
+    http  *http.Client // 启用TLS时使用客户端证书和CA
+    token string       // Bearer令牌（为空时不发送）
+}
+
+// NewClient 创建控制器客户端，addr 为 host:port；opts 启用TLS时使用 https
+func NewClient(addr string, opts auth.ClientOptions) (*Client, error) {
+    cfg, err := opts.TLSConfig()
+    if err != nil {
+        return nil, err
+    }
+    token, err := opts.BearerToken()
+    if err != nil {
+        return nil, err
+    }
+    c := &Client{addr: addr, base: "http://" + addr, token: token}
+    tr := http.DefaultTransport.(*http.Transport).Clone()
+    if cfg != nil {
+        c.base = "https://" + addr
+        tr.TLSClientConfig = cfg
+    }
+    c.http = &http.Client{Transport: tr, Timeout: 10 * time.Second}
+    return c, nil
+}
+
+// do 发送请求，body 非nil时以JSON发送，out 非nil时解析JSON响应
+func (c *Client) do(ctx context.Context, method, path string, body []byte, out interface{}) error {
+    req, err := http.NewRequestWithContext(ctx, method, c.base+path, bytes.NewReader(body))
+    if err != nil {
+        return err
+    }
+    if body != nil {
+        req.Header.Set("Content-Type", "application/json")
+    }
+    if c.token != "" {
+        req.Header.Set("Authorization", "Bearer "+c.token)
+    }
+    resp, err := c.http.Do(req)
+    if err != nil {
+        return err
+    }
+    defer resp.Body.Close()
+    if resp.StatusCode >= 300 {
+        var e errorBody
+        json.NewDecoder(resp.Body).Decode(&e)
+        if resp.StatusCode == http.StatusNotFound && path == "/v1/nodes:heartbeat" {
+            return ErrUnknownNode
+        }
+        return fmt.Errorf("controller %s %s: %s: %s", method, path, resp.Status, e.Message)
+    }
+    if out != nil {
+        return json.NewDecoder(resp.Body).Decode(out)
+    }
+    return nil
+}
+
+// send 发送节点信息
+func (c *Client) send(ctx context.Context, path string, node *netbalance.NodeInfo) error {
+    data, err := node.ToJSON()
+    if err != nil {
+        return err
+    }
+    return c.do(ctx, http.MethodPost, path, []byte(data), nil)
+}
+
+// Register 注册节点
+func (c *Client) Register(ctx context.Context, node *netbalance.NodeInfo) error {
+    return c.send(ctx, "/v1/nodes:register", node)
+}
+
+// Heartbeat 发送心跳，节点未注册时返回 ErrUnknownNode
+func (c *Client) Heartbeat(ctx context.Context, node *netbalance.NodeInfo) error {
+    return c.send(ctx, "/v1/nodes:heartbeat", node)
+}
+
+// Nodes 获取在线节点列表
+func (c *Client) Nodes(ctx context.Context) ([]*netbalance.NodeInfo, error) {
+    var nodes []*netbalance.NodeInfo
+    if err := c.do(ctx, http.MethodGet, "/v1/nodes", nil, &nodes); err != nil {
+        return nil, err
+    }
+    return nodes, nil
+}
+
+// Sync 从控制器获取在线节点并写入本地注册表，供 netbalance.DialTargets / GPUTarget 使用
+func (c *Client) Sync(ctx context.Context) (int, error) {
+    nodes, err := c.Nodes(ctx)
+    if err != nil {
+        return 0, err
+    }
+    for _, node := range nodes {
+        netbalance.UpdateNode(node)
+    }
+    return len(nodes), nil
+}
+
+// Announce 注册节点并每隔 interval 发送心跳，直到 ctx 结束
+// node 每次调用生成最新的节点信息；控制器不可达时持续重试，控制器遗失节点时自动重新注册
+func (c *Client) Announce(ctx context.Context, node func() *netbalance.NodeInfo, interval time.Duration) {
+    registered, failing := false, false
+    ticker := time.NewTicker(interval)
+    defer ticker.Stop()
+    for {
+        n := node()
+        var err error
+        if registered {
+            if err = c.Heartbeat(ctx, n); errors.Is(err, ErrUnknownNode) {
+                util.Log("[Controller] controller lost node %s, registering again", n.IP)
+                registered = false
+            }
+        }
+        if !registered {
+            if err = c.Register(ctx, n); err == nil {
+                registered = true
+                util.Log("[Controller] registered with %s as %s", c.addr, n.IP)
+            }
+        }
+        // 连续失败只记录第一次，恢复后再记录一次
+        if err != nil && !failing && ctx.Err() == nil {
+            util.Log("[Warn] controller heartbeat failed: %v", err)
+        } else if err == nil && failing {
+            util.Log("[Controller] heartbeat to controller recovered")
+        }
+        failing = err != nil
+
+        select {
+        case <-ctx.Done():
+            return
+        case <-ticker.C:
+        }
+    }
+}
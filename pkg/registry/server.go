@@ -0,0 +1,138 @@
+package registry
+
+import (
+    "encoding/json"
+    "io"
+    "net"
+    "net/http"
+    "strings"
+    "time"
+
+    "github.com/hiicl/GPU-over-IP-AC922/modules/netbalance"
+    "github.com/hiicl/GPU-over-IP-AC922/pkg/auth"
+    "github.com/hiicl/GPU-over-IP-AC922/pkg/util"
+)
+
+// registry 包实现控制器的节点注册表：grpcserver 启动时注册并定期发送心跳，
+// 控制器移除超时未心跳的节点，并向客户端提供在线节点列表
+// 接口为 HTTP/JSON，请求体和响应体为 netbalance.NodeInfo 的JSON表示：
+//   POST /v1/nodes:register    注册节点
+//   POST /v1/nodes:heartbeat   心跳；节点未注册（如控制器重启）时返回404，节点应重新注册
+//   GET  /v1/nodes             在线节点列表
+//   GET  /v1/nodes/{ip}        单个节点
+//   GET  /v1/dial-target       按网卡带宽排序的 gRPC 目标地址，每个节点的各NUMA分组端口各一个
+//                              （?port= 指定未注册端口的节点使用的端口，默认50051）
+
+// maxNodeSize 节点信息请求体大小上限
+const maxNodeSize = 1 << 20
+
+// Server 控制器注册服务，实现 http.Handler
+type Server struct {
+    tokens  *auth.TokenStore // Bearer令牌库（为nil时不校验）
+    timeout time.Duration    // 节点心跳超时
+}
+
+// NewServer 创建注册服务，timeout<=0 时使用 netbalance.NodeTimeout
+func NewServer(tokens *auth.TokenStore, timeout time.Duration) *Server {
+    if timeout <= 0 {
+        timeout = netbalance.NodeTimeout
+    }
+    return &Server{tokens: tokens, timeout: timeout}
+}
+
+// Expire 每隔 interval 移除超时节点，阻塞运行
+func (s *Server) Expire(interval time.Duration) {
+    for range time.Tick(interval) {
+        for _, ip := range netbalance.ExpireNodes(s.timeout) {
+            util.Log("[Controller] node %s expired, no heartbeat for %s", ip, s.timeout)
+        }
+    }
+}
+
+// ServeHTTP 按路径分发请求
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+    if !s.authorized(r) {
+        writeError(w, http.StatusUnauthorized, "missing or invalid bearer token")
+        return
+    }
+    path := r.URL.Path
+    switch {
+    case path == "/v1/nodes:register" && r.Method == http.MethodPost:
+        s.register(w, r, false)
+    case path == "/v1/nodes:heartbeat" && r.Method == http.MethodPost:
+        s.register(w, r, true)
+    case path == "/v1/nodes" && r.Method == http.MethodGet:
+        writeJSON(w, http.StatusOK, netbalance.Nodes())
+    case strings.HasPrefix(path, "/v1/nodes/") && r.Method == http.MethodGet:
+        node := netbalance.LookupNode(strings.TrimPrefix(path, "/v1/nodes/"))
+        if node == nil {
+            writeError(w, http.StatusNotFound, "node not registered")
+            return
+        }
+        writeJSON(w, http.StatusOK, node)
+    case path == "/v1/dial-target" && r.Method == http.MethodGet:
+        port := r.URL.Query().Get("port")
+        if port == "" {
+            port = "50051"
+        }
+        writeJSON(w, http.StatusOK, map[string]string{"targets": netbalance.GetBestDialTarget(port)})
+    default:
+        writeError(w, http.StatusNotFound, r.Method+" "+path+" not found")
+    }
+}
+
+// authorized 校验Bearer令牌，未配置令牌库时放行
+func (s *Server) authorized(r *http.Request) bool {
+    if s.tokens == nil {
+        return true
+    }
+    const prefix = "bearer "
+    v := r.Header.Get("Authorization")
+    if len(v) <= len(prefix) || !strings.EqualFold(v[:len(prefix)], prefix) {
+        return false
+    }
+    _, ok := s.tokens.Lookup(strings.TrimSpace(v[len(prefix):]))
+    return ok
+}
+
+// register 处理注册和心跳：心跳时节点必须已注册
+func (s *Server) register(w http.ResponseWriter, r *http.Request, heartbeat bool) {
+    data, err := io.ReadAll(io.LimitReader(r.Body, maxNodeSize))
+    if err != nil {
+        writeError(w, http.StatusBadRequest, err.Error())
+        return
+    }
+    node, err := netbalance.FromJSON(string(data))
+    if err != nil {
+        writeError(w, http.StatusBadRequest, "invalid node info: "+err.Error())
+        return
+    }
+    if net.ParseIP(node.IP) == nil {
+        writeError(w, http.StatusBadRequest, "invalid node ip "+node.IP)
+        return
+    }
+    if heartbeat && netbalance.LookupNode(node.IP) == nil {
+        writeError(w, http.StatusNotFound, "node not registered")
+        return
+    }
+    node.LastSeen = time.Now().Unix() // 以控制器时钟为准
+    if netbalance.UpdateNode(node) || !heartbeat {
+        util.Log("[Controller] node %s (%s) registered: %d GPUs, ports %v, %d Mbps", node.IP, node.Hostname, len(node.GPUs), node.Ports, node.NetBandwidth)
+    }
+    w.WriteHeader(http.StatusNoContent)
+}
+
+// errorBody 错误响应
+type errorBody struct {
+    Message string `json:"message"`
+}
+
+func writeError(w http.ResponseWriter, code int, msg string) {
+    writeJSON(w, code, errorBody{Message: msg})
+}
+
+func writeJSON(w http.ResponseWriter, code int, v interface{}) {
+    w.Header().Set("Content-Type", "application/json")
+    w.WriteHeader(code)
+    json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,60 @@
+package scheduler
+
+import (
+    "errors"
+    "fmt"
+
+    "github.com/hiicl/GPU-over-IP-AC922/pkg/util"
+)
+
+// cordon.go 实现GPU隔离：健康检查判定为不健康的GPU不再分配新租约，直到管理员解除隔离
+// 隔离不影响已发放的租约，持有者可以继续使用或自行释放；物理GPU被隔离时其MIG实例同样不可分配
+
+// ErrUnhealthy GPU已被隔离
+var ErrUnhealthy = errors.New("GPU is unhealthy")
+
+// Cordon 隔离GPU，reason 为隔离原因；GPU已被隔离时保留最初的原因并返回false
+func (s *Scheduler) Cordon(uuid, reason string) bool {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    if _, ok := s.cordoned[uuid]; ok {
+        return false
+    }
+    s.cordoned[uuid] = reason
+    util.Log("GPU %s cordoned: %s", uuid, reason)
+    return true
+}
+
+// Uncordon 解除GPU隔离，GPU未被隔离时返回false
+func (s *Scheduler) Uncordon(uuid string) bool {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    if _, ok := s.cordoned[uuid]; !ok {
+        return false
+    }
+    delete(s.cordoned, uuid)
+    util.Log("GPU %s uncordoned", uuid)
+    return true
+}
+
+// Cordoned 返回被隔离的GPU（key: GPU UUID，value: 隔离原因）
+func (s *Scheduler) Cordoned() map[string]string {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    result := make(map[string]string, len(s.cordoned))
+    for uuid, reason := range s.cordoned {
+        result[uuid] = reason
+    }
+    return result
+}
+
+// checkHealthy 检查GPU是否可以分配，调用方需持有锁
+func (s *Scheduler) checkHealthy(uuid string) error {
+    if reason, ok := s.cordoned[uuid]; ok {
+        return fmt.Errorf("%w: %s", ErrUnhealthy, reason)
+    }
+    if reason, ok := s.cordoned[s.parent[uuid]]; ok {
+        return fmt.Errorf("%w: GPU %s: %s", ErrUnhealthy, s.parent[uuid], reason)
+    }
+    return nil
+}
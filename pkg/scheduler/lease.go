@@ -0,0 +1,99 @@
+package scheduler
+
+import (
+    "crypto/rand"
+    "encoding/hex"
+    "sort"
+    "time"
+
+    "github.com/hiicl/GPU-over-IP-AC922/pkg/util"
+)
+
+// lease.go 定义GPU租约及续期、校验操作
+
+// Lease 表示一次GPU占用的租约
+// ID: 租约令牌（释放、续期、执行命令时需提供）
+// UUID: 被占用GPU的UUID
+// Owner: 租约持有者
+// Tenant: 持有者所属租户（为空表示不计入配额）
+// Expiry: 到期时间，到期未续期将被自动回收
+// TTL: 最近一次占用或续期时使用的租约时长
+type Lease struct {
+    ID     string
+    UUID   string
+    Owner  string
+    Tenant string
+    Expiry time.Time
+    TTL    time.Duration
+
+    timer *time.Timer // 到期回收定时器
+}
+
+// snapshot 返回不含内部定时器的租约副本，供调用方只读使用
+func (l *Lease) snapshot() *Lease {
+    return &Lease{ID: l.ID, UUID: l.UUID, Owner: l.Owner, Tenant: l.Tenant, Expiry: l.Expiry, TTL: l.TTL}
+}
+
+// Lookup 按租约ID返回有效租约的副本，租约不存在或已过期时返回ErrInvalidLease
+func (s *Scheduler) Lookup(leaseID string) (*Lease, error) {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    lease, ok := s.byID[leaseID]
+    if !ok || !time.Now().Before(lease.Expiry) {
+        return nil, ErrInvalidLease
+    }
+    return lease.snapshot(), nil
+}
+
+// Leases 返回所有有效租约的副本，按GPU UUID排序
+func (s *Scheduler) Leases() []*Lease {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    leases := make([]*Lease, 0, len(s.leases))
+    for _, l := range s.leases {
+        leases = append(leases, l.snapshot())
+    }
+    sort.Slice(leases, func(i, j int) bool { return leases[i].UUID < leases[j].UUID })
+    return leases
+}
+
+// newLeaseID 生成随机租约ID（128位，十六进制）
+func newLeaseID() string {
+    b := make([]byte, 16)
+    if _, err := rand.Read(b); err != nil {
+        util.Fatal("generate lease id: " + err.Error())
+    }
+    return hex.EncodeToString(b)
+}
+
+// Renew 延长租约有效期
+// leaseID: 要续期的租约ID
+// ttl: 从当前时间起的新租约时长，<=0 时使用调度器默认值
+// 返回续期后的租约；租约不存在或已过期时返回ErrInvalidLease
+func (s *Scheduler) Renew(leaseID string, ttl time.Duration) (*Lease, error) {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+
+    lease, ok := s.byID[leaseID]
+    if !ok || !time.Now().Before(lease.Expiry) {
+        return nil, ErrInvalidLease
+    }
+    // 只更新到期时间；定时器触发时会发现租约已续期并重新计时
+    lease.TTL = s.leaseTTL(ttl)
+    lease.Expiry = time.Now().Add(lease.TTL)
+    util.DebugLog("GPU %s lease %s renewed until %s", lease.UUID, leaseID, lease.Expiry.Format(time.RFC3339))
+    return lease.snapshot(), nil
+}
+
+// Validate 校验租约是否为指定GPU的当前有效租约
+// 返回值：租约无效时返回ErrInvalidLease
+func (s *Scheduler) Validate(uuid, leaseID string) error {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+
+    lease, ok := s.leases[uuid]
+    if !ok || lease.ID != leaseID || !time.Now().Before(lease.Expiry) {
+        return ErrInvalidLease
+    }
+    return nil
+}
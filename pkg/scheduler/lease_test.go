@@ -0,0 +1,165 @@
+package scheduler
+
+import (
+    "errors"
+    "testing"
+    "time"
+)
+
+// releaseObserver 将租约结束事件写入通道，便于等待定时器回收
+type releaseObserver struct {
+    nopObserver
+    released chan bool // 值为 expired
+}
+
+func (o releaseObserver) Released(uuid string, expired bool) {
+    o.released <- expired
+}
+
+// TestLeaseExpiry 到期未续期的租约由定时器回收，旧令牌随之失效
+func TestLeaseExpiry(t *testing.T) {
+    s := NewScheduler(time.Minute)
+    obs := releaseObserver{released: make(chan bool, 1)}
+    s.SetObserver(obs)
+
+    lease, err := s.Acquire("GPU-0", "alice", "", 50*time.Millisecond)
+    if err != nil {
+        t.Fatalf("Acquire: %v", err)
+    }
+    select {
+    case expired := <-obs.released:
+        if !expired {
+            t.Fatalf("lease released with expired = false, want true")
+        }
+    case <-time.After(5 * time.Second):
+        t.Fatal("lease was not reclaimed after its TTL")
+    }
+
+    if s.IsInUse("GPU-0") {
+        t.Error("GPU still in use after its lease expired")
+    }
+    if _, err := s.Lookup(lease.ID); !errors.Is(err, ErrInvalidLease) {
+        t.Errorf("Lookup(expired) = %v, want %v", err, ErrInvalidLease)
+    }
+    if _, err := s.Renew(lease.ID, time.Minute); !errors.Is(err, ErrInvalidLease) {
+        t.Errorf("Renew(expired) = %v, want %v", err, ErrInvalidLease)
+    }
+    if _, err := s.Acquire("GPU-0", "bob", "", 0); err != nil {
+        t.Errorf("Acquire after expiry: %v", err)
+    }
+}
+
+// TestRenewBeforeExpiry 到期前续期的租约在原定时器触发后仍然有效
+func TestRenewBeforeExpiry(t *testing.T) {
+    s := NewScheduler(time.Minute)
+    obs := releaseObserver{released: make(chan bool, 1)}
+    s.SetObserver(obs)
+
+    const ttl = 300 * time.Millisecond
+    lease, err := s.Acquire("GPU-0", "alice", "", ttl)
+    if err != nil {
+        t.Fatalf("Acquire: %v", err)
+    }
+    time.Sleep(ttl * 2 / 3)
+    renewed, err := s.Renew(lease.ID, time.Minute)
+    if err != nil {
+        t.Fatalf("Renew: %v", err)
+    }
+    if renewed.TTL != time.Minute || !renewed.Expiry.After(lease.Expiry) {
+        t.Errorf("renewed lease TTL = %v, expiry %v; want 1m after %v", renewed.TTL, renewed.Expiry, lease.Expiry)
+    }
+
+    // 等待原定时器触发：租约已续期，定时器应重新计时而不是回收
+    select {
+    case <-obs.released:
+        t.Fatal("renewed lease was reclaimed by the original timer")
+    case <-time.After(ttl):
+    }
+    if err := s.Validate("GPU-0", lease.ID); err != nil {
+        t.Errorf("Validate after original expiry: %v", err)
+    }
+    if got, err := s.Lookup(lease.ID); err != nil || got.TTL != time.Minute {
+        t.Errorf("Lookup = %+v, %v; want TTL 1m", got, err)
+    }
+}
+
+// TestReleaseRejectsToken 错误或过期的令牌不能释放GPU，当前租约保持有效
+func TestReleaseRejectsToken(t *testing.T) {
+    tests := []struct {
+        name  string
+        token func(t *testing.T, s *Scheduler, current *Lease) string
+    }{
+        {
+            name:  "unknown token",
+            token: func(*testing.T, *Scheduler, *Lease) string { return newLeaseID() },
+        },
+        {
+            name:  "empty token",
+            token: func(*testing.T, *Scheduler, *Lease) string { return "" },
+        },
+        {
+            name: "token of another GPU",
+            token: func(t *testing.T, s *Scheduler, _ *Lease) string {
+                other, err := s.Acquire("GPU-1", "bob", "", 0)
+                if err != nil {
+                    t.Fatalf("Acquire(GPU-1): %v", err)
+                }
+                return other.ID
+            },
+        },
+        {
+            name: "token of a released lease",
+            token: func(t *testing.T, s *Scheduler, current *Lease) string {
+                old := current.ID
+                if err := s.Release("GPU-0", old); err != nil {
+                    t.Fatalf("Release: %v", err)
+                }
+                reacquire(t, s, current)
+                return old
+            },
+        },
+        {
+            name: "token of an expired lease",
+            token: func(t *testing.T, s *Scheduler, current *Lease) string {
+                // 将当前租约置为已过期并触发回收，再重新占用，旧令牌即为过期令牌
+                old := current.ID
+                s.mu.Lock()
+                s.leases["GPU-0"].Expiry = time.Now().Add(-time.Second)
+                s.mu.Unlock()
+                s.expire("GPU-0", old)
+                reacquire(t, s, current)
+                return old
+            },
+        },
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            s := NewScheduler(time.Minute)
+            current, err := s.Acquire("GPU-0", "alice", "", 0)
+            if err != nil {
+                t.Fatalf("Acquire: %v", err)
+            }
+            token := tt.token(t, s, current)
+
+            if err := s.Release("GPU-0", token); !errors.Is(err, ErrInvalidLease) {
+                t.Errorf("Release with %s = %v, want %v", tt.name, err, ErrInvalidLease)
+            }
+            if !s.IsInUse("GPU-0") {
+                t.Fatal("rejected release freed the GPU")
+            }
+            if err := s.Release("GPU-0", current.ID); err != nil {
+                t.Errorf("Release with the current token: %v", err)
+            }
+        })
+    }
+}
+
+// reacquire 重新占用 GPU-0，并将 current 更新为新租约
+func reacquire(t *testing.T, s *Scheduler, current *Lease) {
+    t.Helper()
+    lease, err := s.Acquire("GPU-0", "alice", "", 0)
+    if err != nil {
+        t.Fatalf("Acquire: %v", err)
+    }
+    *current = *lease
+}
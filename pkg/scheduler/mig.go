@@ -0,0 +1,50 @@
+package scheduler
+
+import (
+    "github.com/hiicl/GPU-over-IP-AC922/pkg/util"
+)
+
+// mig.go 记录MIG实例与物理GPU的从属关系
+// MIG实例和整卡一样按UUID发放租约，但物理GPU与其实例互斥：任一实例被占用时整卡不可占用，
+// 整卡被占用（如重新划分实例）时实例不可占用；物理GPU被隔离时其所有实例同样不可分配
+// 已划分实例的物理GPU不参与多GPU占用，AcquireN 只从实例和未启用MIG的GPU中选择
+
+// SetParent 记录MIG实例所属的物理GPU
+// 关系来源于启动时的GPU列表，运行期间重新划分实例需重启服务
+func (s *Scheduler) SetParent(uuid, parent string) {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    s.parent[uuid] = parent
+    util.DebugLog("GPU %s is a MIG instance of %s", uuid, parent)
+}
+
+// busy 判断GPU本身、其物理GPU或其任一MIG实例是否已被占用，调用方需持有锁
+func (s *Scheduler) busy(uuid string) bool {
+    if _, ok := s.leases[uuid]; ok {
+        return true
+    }
+    if p, ok := s.parent[uuid]; ok {
+        if _, ok := s.leases[p]; ok {
+            return true
+        }
+    }
+    for child, p := range s.parent {
+        if p != uuid {
+            continue
+        }
+        if _, ok := s.leases[child]; ok {
+            return true
+        }
+    }
+    return false
+}
+
+// partitioned 判断GPU是否已划分MIG实例，调用方需持有锁
+func (s *Scheduler) partitioned(uuid string) bool {
+    for _, p := range s.parent {
+        if p == uuid {
+            return true
+        }
+    }
+    return false
+}
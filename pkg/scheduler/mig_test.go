@@ -0,0 +1,115 @@
+package scheduler
+
+import (
+    "errors"
+    "testing"
+    "time"
+
+    "github.com/hiicl/GPU-over-IP-AC922/pkg/query"
+)
+
+// newMIGScheduler 按模拟后端的GPU列表创建调度器：GPU 0 划分为 3g.20gb、2g.10gb、1g.5gb 三个实例，
+// GPU 1 未启用MIG；与 grpcserver 启动时一样通过 SetParent 记录实例从属关系
+func newMIGScheduler(t *testing.T) *Scheduler {
+    t.Helper()
+    b := query.NewFakeBackend(2)
+    if err := b.EnableMIG(0, "3g.20gb", "2g.10gb", "1g.5gb"); err != nil {
+        t.Fatalf("EnableMIG: %v", err)
+    }
+    gpus, err := b.ListGPUs()
+    if err != nil {
+        t.Fatalf("ListGPUs: %v", err)
+    }
+    s := NewScheduler(time.Minute)
+    for _, info := range gpus {
+        if info.Mig != nil {
+            s.SetParent(info.Uuid, info.Mig.ParentUuid)
+        }
+    }
+    return s
+}
+
+func TestMIGExclusion(t *testing.T) {
+    parent := query.FakeUUID(0)
+    inst1, inst2 := query.FakeMIGUUID(0, 1), query.FakeMIGUUID(0, 2)
+
+    tests := []struct {
+        name    string
+        setup   func(t *testing.T, s *Scheduler)
+        acquire string
+        want    error
+    }{
+        {
+            name:    "leased instance blocks parent",
+            setup:   func(t *testing.T, s *Scheduler) { mustAcquire(t, s, inst1) },
+            acquire: parent,
+            want:    ErrInUse,
+        },
+        {
+            name:    "leased instance leaves sibling free",
+            setup:   func(t *testing.T, s *Scheduler) { mustAcquire(t, s, inst1) },
+            acquire: inst2,
+        },
+        {
+            name:    "leased parent blocks instance",
+            setup:   func(t *testing.T, s *Scheduler) { mustAcquire(t, s, parent) },
+            acquire: inst2,
+            want:    ErrInUse,
+        },
+        {
+            name:    "cordoned parent blocks instance",
+            setup:   func(t *testing.T, s *Scheduler) { s.Cordon(parent, "test") },
+            acquire: inst1,
+            want:    ErrUnhealthy,
+        },
+        {
+            name:    "cordoned instance leaves parent free",
+            setup:   func(t *testing.T, s *Scheduler) { s.Cordon(inst1, "test") },
+            acquire: parent,
+        },
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            s := newMIGScheduler(t)
+            tt.setup(t, s)
+            _, err := s.Acquire(tt.acquire, "alice", "", 0)
+            if !errors.Is(err, tt.want) {
+                t.Errorf("Acquire(%s) = %v, want %v", tt.acquire, err, tt.want)
+            }
+        })
+    }
+}
+
+// TestAcquireNSkipsPartitioned 多GPU占用不选择已划分实例的物理GPU
+func TestAcquireNSkipsPartitioned(t *testing.T) {
+    s := newMIGScheduler(t)
+    parent, other := query.FakeUUID(0), query.FakeUUID(1)
+    inst1 := query.FakeMIGUUID(0, 1)
+
+    if _, err := s.AcquireN([]string{parent, other}, Request{Count: 2, Owner: "alice"}); !errors.Is(err, ErrInsufficient) {
+        t.Fatalf("AcquireN(parent, other) = %v, want %v", err, ErrInsufficient)
+    }
+    if s.IsInUse(other) {
+        t.Fatalf("failed AcquireN left %s leased", other)
+    }
+
+    leases, err := s.AcquireN([]string{parent, inst1, other}, Request{Count: 2, Owner: "alice"})
+    if err != nil {
+        t.Fatalf("AcquireN: %v", err)
+    }
+    got := map[string]bool{}
+    for _, l := range leases {
+        got[l.UUID] = true
+    }
+    if len(got) != 2 || !got[inst1] || !got[other] {
+        t.Errorf("AcquireN picked %v, want %s and %s", got, inst1, other)
+    }
+}
+
+// mustAcquire 占用GPU，失败时终止测试
+func mustAcquire(t *testing.T, s *Scheduler, uuid string) {
+    t.Helper()
+    if _, err := s.Acquire(uuid, "bob", "", 0); err != nil {
+        t.Fatalf("Acquire(%s): %v", uuid, err)
+    }
+}
@@ -0,0 +1,118 @@
+package scheduler
+
+import (
+    "errors"
+    "fmt"
+    "sort"
+    "time"
+
+    "github.com/hiicl/GPU-over-IP-AC922/pkg/util"
+)
+
+// multi.go 实现多GPU原子占用：一次性占用多个GPU，要么全部成功，要么一个都不占用
+// 所有GPU在同一次加锁中分配，多个客户端同时申请时不会出现互相持有部分GPU的死锁
+
+// ErrInsufficient 满足约束的空闲GPU数量不足
+var ErrInsufficient = errors.New("not enough free GPUs satisfy the constraints")
+
+// Request 描述一次多GPU占用请求
+// Count: 需要占用的GPU数量
+// Owner: 租约持有者
+// Tenant: 持有者所属租户，为空时不检查配额
+// TTL: 租约时长，<=0 时使用调度器默认值
+// SameNUMA: 是否要求所有GPU位于同一NUMA节点
+type Request struct {
+    Count    int
+    Owner    string
+    Tenant   string
+    TTL      time.Duration
+    SameNUMA bool
+}
+
+// SetNUMA 记录GPU所在的NUMA节点，供 SameNUMA 约束使用
+// 拓扑来源于 netbalance.MapNUMATopology 的分组结果
+func (s *Scheduler) SetNUMA(uuid string, node int) {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    s.numa[uuid] = node
+}
+
+// AcquireN 从候选GPU中原子地占用 req.Count 个GPU
+// candidates: 调用方已按显存、型号等约束过滤后的候选GPU UUID
+// 返回按UUID排序的租约列表；无法满足时返回ErrInsufficient且不占用任何GPU
+// SameNUMA 时优先选择空闲GPU数刚好满足需求的NUMA节点，减少碎片
+func (s *Scheduler) AcquireN(candidates []string, req Request) ([]*Lease, error) {
+    if req.Count <= 0 {
+        return nil, fmt.Errorf("invalid GPU count %d", req.Count)
+    }
+
+    start := time.Now()
+    s.mu.Lock()
+    defer s.mu.Unlock()
+
+    if err := s.checkQuota(req.Tenant, req.Count); err != nil {
+        s.observer.Acquired("", time.Since(start), err)
+        return nil, err
+    }
+
+    // 筛选空闲且未被隔离的GPU，已划分MIG实例的物理GPU只能整卡单独占用
+    var free []string
+    for _, uuid := range candidates {
+        if s.busy(uuid) || s.partitioned(uuid) || s.checkHealthy(uuid) != nil {
+            continue
+        }
+        free = append(free, uuid)
+    }
+    sort.Strings(free)
+
+    picked := s.pick(free, req)
+    if picked == nil {
+        s.observer.Acquired("", time.Since(start), ErrInsufficient)
+        return nil, ErrInsufficient
+    }
+
+    ttl := s.leaseTTL(req.TTL)
+    leases := make([]*Lease, 0, len(picked))
+    for _, uuid := range picked {
+        leases = append(leases, s.grant(uuid, req.Owner, req.Tenant, ttl).snapshot())
+        s.observer.Acquired(uuid, time.Since(start), nil)
+    }
+    util.Log("GPUs %v acquired by %s", picked, req.Owner)
+    return leases, nil
+}
+
+// pick 从空闲GPU中选出满足请求的GPU，无法满足时返回nil，调用方需持有锁
+func (s *Scheduler) pick(free []string, req Request) []string {
+    if !req.SameNUMA {
+        if len(free) < req.Count {
+            return nil
+        }
+        return free[:req.Count]
+    }
+
+    // 按NUMA节点分组（未知节点的GPU记为-1）
+    groups := make(map[int][]string)
+    for _, uuid := range free {
+        node, ok := s.numa[uuid]
+        if !ok {
+            node = -1
+        }
+        groups[node] = append(groups[node], uuid)
+    }
+
+    // 选择空闲GPU数满足需求且最少的节点，数量相同时选择编号较小的节点
+    var best []string
+    bestNode, found := 0, false
+    for node, gpus := range groups {
+        if len(gpus) < req.Count {
+            continue
+        }
+        if !found || len(gpus) < len(best) || (len(gpus) == len(best) && node < bestNode) {
+            best, bestNode, found = gpus, node, true
+        }
+    }
+    if !found {
+        return nil
+    }
+    return best[:req.Count]
+}
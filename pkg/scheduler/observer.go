@@ -0,0 +1,39 @@
+package scheduler
+
+import "time"
+
+// observer.go 定义调度事件观察接口，供监控模块统计租约数量和占用等待时间
+// 回调在调度器持有锁时同步调用，实现必须快速返回且不能回调调度器
+
+// Observer 调度事件观察者
+type Observer interface {
+    // Acquired 一次占用请求结束：uuid 为被占用（或请求占用）的GPU，多GPU占用失败时为空
+    // wait 为从请求到完成判定的耗时（含等待调度器锁），err 为nil表示占用成功
+    Acquired(uuid string, wait time.Duration, err error)
+    // Released 租约结束：expired 为 true 表示到期回收，否则为主动释放
+    Released(uuid string, expired bool)
+}
+
+// nopObserver 默认观察者，忽略所有事件
+type nopObserver struct{}
+
+func (nopObserver) Acquired(string, time.Duration, error) {}
+func (nopObserver) Released(string, bool)                 {}
+
+// SetObserver 设置调度事件观察者，需在开始调度前调用
+func (s *Scheduler) SetObserver(o Observer) {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    s.observer = o
+}
+
+// Leased 返回当前持有租约的GPU（key: GPU UUID，value: 持有者）
+func (s *Scheduler) Leased() map[string]string {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    held := make(map[string]string, len(s.leases))
+    for uuid, l := range s.leases {
+        held[uuid] = l.Owner
+    }
+    return held
+}
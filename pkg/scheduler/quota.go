@@ -0,0 +1,58 @@
+package scheduler
+
+import (
+    "errors"
+    "fmt"
+
+    "github.com/hiicl/GPU-over-IP-AC922/pkg/util"
+)
+
+// quota.go 实现租户GPU配额：限制每个租户同时持有的GPU数量
+
+// ErrQuotaExceeded 租户持有的GPU数量将超出配额
+var ErrQuotaExceeded = errors.New("tenant GPU quota exceeded")
+
+// SetQuota 设置租户可同时持有的GPU数量上限，max<=0 表示不限制
+func (s *Scheduler) SetQuota(tenant string, max int) {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    if max <= 0 {
+        delete(s.quotas, tenant)
+        return
+    }
+    s.quotas[tenant] = max
+    util.Log("tenant %s GPU quota set to %d", tenant, max)
+}
+
+// TenantUsage 返回租户当前持有的GPU数量和配额上限（0表示不限制）
+func (s *Scheduler) TenantUsage(tenant string) (held, max int) {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    return s.held(tenant), s.quotas[tenant]
+}
+
+// held 统计租户当前持有的GPU数量，调用方需持有锁
+func (s *Scheduler) held(tenant string) int {
+    n := 0
+    for _, l := range s.leases {
+        if l.Tenant == tenant {
+            n++
+        }
+    }
+    return n
+}
+
+// checkQuota 检查租户再占用 n 个GPU是否超出配额，调用方需持有锁
+func (s *Scheduler) checkQuota(tenant string, n int) error {
+    if tenant == "" {
+        return nil
+    }
+    max, ok := s.quotas[tenant]
+    if !ok {
+        return nil
+    }
+    if held := s.held(tenant); held+n > max {
+        return fmt.Errorf("%w: tenant %s holds %d of %d GPUs, requested %d more", ErrQuotaExceeded, tenant, held, max, n)
+    }
+    return nil
+}
@@ -1,95 +1,181 @@
-package scheduler
-
-import (
-    "errors"
-    "sync"
-    "time"
-
-    "github.com/hiicl/GPU-over-IP-AC922/pkg/util"
-)
-
-// scheduler 包提供GPU资源调度功能，管理GPU资源的占用和释放
-// 通过互斥锁确保并发安全，并实现超时自动释放机制防止死锁
-// 主要功能：
-//   - 安全地占用和释放GPU资源
-//   - 提供GPU占用状态查询
-//   - 超时自动释放机制防止资源死锁
-
-// Scheduler 结构体管理GPU资源调度
-// mu: 互斥锁，保护inUse映射的并发访问
-// inUse: 记录GPU占用状态的映射表（key: GPU UUID, value: 是否被占用）
-// timeout: 资源占用超时时间（超过此时间未释放将自动释放）
-type Scheduler struct {
-    mu      sync.Mutex     // 互斥锁，保护inUse映射的并发访问
-    inUse   map[string]bool // key: GPU UUID，value: 是否被占用
-    timeout time.Duration   // 资源占用超时时间（单位：duration）
-}
-
-// NewScheduler 创建并初始化一个新的调度器实例
-// timeout: 资源锁自动释放的超时时间（防止死锁）
-// 返回初始化后的Scheduler指针
-func NewScheduler(timeout time.Duration) *Scheduler {
-    return &Scheduler{
-        inUse:   make(map[string]bool), // 初始化GPU占用状态映射
-        timeout: timeout,                // 设置超时时间
-    }
-}
-
-// Acquire 尝试占用指定的GPU资源
-// uuid: 要占用的GPU的唯一标识符
-// 返回值：error - 如果GPU已被占用则返回错误，否则返回nil
-// 注意：占用成功后会自动启动超时释放协程（防止死锁）
-func (s *Scheduler) Acquire(uuid string) error {
-    // 加锁确保并发安全
-    s.mu.Lock()
-    defer s.mu.Unlock() // 确保函数返回时解锁
-
-    // 检查GPU是否已被占用
-    if s.inUse[uuid] {
-        return errors.New("GPU already in use")
-    }
-
-    // 标记GPU为已占用状态
-    s.inUse[uuid] = true
-
-    // 启动goroutine在超时后自动释放资源（防止死锁）
-    go func() {
-        // 等待超时时间
-        time.Sleep(s.timeout)
-        // 超时后释放资源
-        s.Release(uuid)
-    }()
-
-    // 记录资源获取日志
-    util.Log.Infof("GPU %s acquired", uuid)
-    return nil
-}
-
-// Release 释放指定的GPU资源
-// uuid: 要释放的GPU的唯一标识符
-// 注意：如果GPU未被占用，则不执行任何操作
-func (s *Scheduler) Release(uuid string) {
-    // 加锁确保并发安全
-    s.mu.Lock()
-    defer s.mu.Unlock() // 确保函数返回时解锁
-
-    // 检查GPU是否处于占用状态
-    if s.inUse[uuid] {
-        // 从占用映射中删除该GPU（释放资源）
-        delete(s.inUse, uuid)
-        // 记录资源释放日志
-        util.Log.Infof("GPU %s released", uuid)
-    }
-}
-
-// IsInUse 检查指定GPU是否被占用
-// uuid: 要检查的GPU的唯一标识符
-// 返回值：bool - true表示GPU已被占用，false表示可用
-func (s *Scheduler) IsInUse(uuid string) bool {
-    // 加锁确保并发安全
-    s.mu.Lock()
-    defer s.mu.Unlock() // 确保函数返回时解锁
-
-    // 返回GPU的占用状态
-    return s.inUse[uuid]
-}
+package scheduler
+
+import (
+    "errors"
+    "sync"
+    "time"
+
+    "github.com/hiicl/GPU-over-IP-AC922/pkg/util"
+)
+
+// scheduler 包提供GPU资源调度功能，管理GPU资源的占用和释放
+// 通过互斥锁确保并发安全，并以租约（Lease）形式发放GPU，租约到期自动回收
+// 主要功能：
+//   - 安全地占用和释放GPU资源（释放需持有租约令牌）
+//   - 提供GPU占用状态查询
+//   - 租约续期与到期回收，防止资源死锁
+
+// 调度错误
+var (
+    ErrInUse        = errors.New("GPU already in use")
+    ErrInvalidLease = errors.New("invalid or expired lease")
+)
+
+// Scheduler 结构体管理GPU资源调度
+// mu: 互斥锁，保护租约映射的并发访问
+// leases: 当前有效的租约（key: GPU UUID）
+// byID: 租约ID索引（key: 租约ID）
+// numa: GPU所在NUMA节点（key: GPU UUID），用于多GPU同NUMA约束
+// quotas: 租户可同时持有的GPU数量上限（key: 租户）
+// cordoned: 被隔离、不再分配的GPU（key: GPU UUID）
+// parent: MIG实例所属的物理GPU（key: MIG实例UUID）
+// observer: 调度事件观察者（导出监控指标）
+// timeout: 默认租约时长（超过此时间未续期将自动回收）
+type Scheduler struct {
+    mu       sync.Mutex        // 互斥锁，保护租约映射的并发访问
+    leases   map[string]*Lease // key: GPU UUID，value: 当前租约
+    byID     map[string]*Lease // key: 租约ID，value: 租约
+    numa     map[string]int    // key: GPU UUID，value: NUMA节点
+    quotas   map[string]int    // key: 租户，value: GPU数量上限
+    cordoned map[string]string // key: GPU UUID，value: 隔离原因
+    parent   map[string]string // key: MIG实例UUID，value: 物理GPU UUID
+    observer Observer          // 调度事件观察者，默认不做任何处理
+    timeout  time.Duration     // 默认租约时长（单位：duration）
+}
+
+// NewScheduler 创建并初始化一个新的调度器实例
+// timeout: 默认租约时长（防止死锁）
+// 返回初始化后的Scheduler指针
+func NewScheduler(timeout time.Duration) *Scheduler {
+    return &Scheduler{
+        leases:   make(map[string]*Lease),  // 初始化GPU租约映射
+        byID:     make(map[string]*Lease),  // 初始化租约ID索引
+        numa:     make(map[string]int),     // 初始化NUMA拓扑
+        quotas:   make(map[string]int),     // 初始化租户配额
+        cordoned: make(map[string]string),  // 初始化隔离列表
+        parent:   make(map[string]string),  // 初始化MIG从属关系
+        observer: nopObserver{},            // 未设置观察者时忽略调度事件
+        timeout:  timeout,                  // 设置默认租约时长
+    }
+}
+
+// Acquire 尝试占用指定的GPU资源并发放租约
+// uuid: 要占用的GPU的唯一标识符
+// owner: 租约持有者标识
+// tenant: 持有者所属租户，为空时不检查配额
+// ttl: 租约时长，<=0 时使用调度器默认值
+// 返回值：新租约；如果GPU已被占用（包括MIG实例与其物理GPU互相占用）则返回ErrInUse，
+// 已被隔离返回ErrUnhealthy，超出租户配额返回ErrQuotaExceeded
+// 注意：租约到期后由定时器自动回收（已续期的租约不会被回收）
+func (s *Scheduler) Acquire(uuid, owner, tenant string, ttl time.Duration) (*Lease, error) {
+    // 加锁确保并发安全
+    start := time.Now()
+    s.mu.Lock()
+    defer s.mu.Unlock() // 确保函数返回时解锁
+
+    // 检查GPU是否已被占用
+    if s.busy(uuid) {
+        s.observer.Acquired(uuid, time.Since(start), ErrInUse)
+        return nil, ErrInUse
+    }
+    if err := s.checkHealthy(uuid); err != nil {
+        s.observer.Acquired(uuid, time.Since(start), err)
+        return nil, err
+    }
+    if err := s.checkQuota(tenant, 1); err != nil {
+        s.observer.Acquired(uuid, time.Since(start), err)
+        return nil, err
+    }
+
+    lease := s.grant(uuid, owner, tenant, s.leaseTTL(ttl))
+    s.observer.Acquired(uuid, time.Since(start), nil)
+
+    // 记录资源获取日志
+    util.Log("GPU %s acquired by %s (lease %s)", uuid, owner, lease.ID)
+    return lease.snapshot(), nil
+}
+
+// grant 创建租约并启动到期回收定时器，调用方需持有锁
+func (s *Scheduler) grant(uuid, owner, tenant string, ttl time.Duration) *Lease {
+    lease := &Lease{
+        ID:     newLeaseID(),
+        UUID:   uuid,
+        Owner:  owner,
+        Tenant: tenant,
+        Expiry: time.Now().Add(ttl),
+        TTL:    ttl,
+    }
+    s.leases[uuid] = lease
+    s.byID[lease.ID] = lease
+
+    id := lease.ID
+    lease.timer = time.AfterFunc(ttl, func() { s.expire(uuid, id) })
+    return lease
+}
+
+// leaseTTL 返回有效的租约时长
+func (s *Scheduler) leaseTTL(ttl time.Duration) time.Duration {
+    if ttl <= 0 {
+        return s.timeout
+    }
+    return ttl
+}
+
+// Release 使用租约释放指定的GPU资源
+// uuid: 要释放的GPU的唯一标识符
+// leaseID: 占用时获得的租约ID
+// 返回值：租约不存在、已过期或不属于该GPU时返回ErrInvalidLease
+func (s *Scheduler) Release(uuid, leaseID string) error {
+    // 加锁确保并发安全
+    s.mu.Lock()
+    defer s.mu.Unlock() // 确保函数返回时解锁
+
+    lease, ok := s.leases[uuid]
+    if !ok || lease.ID != leaseID {
+        return ErrInvalidLease
+    }
+    s.remove(lease)
+    s.observer.Released(uuid, false)
+
+    // 记录资源释放日志
+    util.Log("GPU %s released (lease %s)", uuid, leaseID)
+    return nil
+}
+
+// remove 删除租约并停止其回收定时器，调用方需持有锁
+func (s *Scheduler) remove(lease *Lease) {
+    lease.timer.Stop()
+    delete(s.leases, lease.UUID)
+    delete(s.byID, lease.ID)
+}
+
+// expire 租约定时器回调：仅当租约仍是同一个且确已到期时才回收
+// 如果租约在定时器触发前被续期，则按新的到期时间重新计时
+func (s *Scheduler) expire(uuid, leaseID string) {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+
+    lease, ok := s.leases[uuid]
+    if !ok || lease.ID != leaseID {
+        return // 已被释放或GPU已重新分配
+    }
+    if remaining := time.Until(lease.Expiry); remaining > 0 {
+        lease.timer.Reset(remaining) // 已续期，继续等待
+        return
+    }
+    s.remove(lease)
+    s.observer.Released(uuid, true)
+    util.Log("GPU %s lease %s expired, reclaimed", uuid, leaseID)
+}
+
+// IsInUse 检查指定GPU是否被占用
+// uuid: 要检查的GPU的唯一标识符
+// 返回值：bool - true表示GPU已被占用（包括MIG实例与其物理GPU互相占用），false表示可用
+func (s *Scheduler) IsInUse(uuid string) bool {
+    // 加锁确保并发安全
+    s.mu.Lock()
+    defer s.mu.Unlock() // 确保函数返回时解锁
+
+    // 返回GPU的占用状态
+    return s.busy(uuid)
+}